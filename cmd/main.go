@@ -9,6 +9,13 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := server.Migrate(os.Args[2:]); err != nil {
+			log.Error(errors.Wrap(err, "Failed to migrate DB"))
+			os.Exit(1)
+		}
+		return
+	}
 	if err := server.Launch(); err != nil {
 		log.Error(errors.Wrap(err, "Failed to launch server"))
 		os.Exit(1)
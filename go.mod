@@ -4,9 +4,10 @@ go 1.16
 
 require (
 	github.com/brianvoe/gofakeit/v6 v6.12.2 // indirect
-	github.com/gorilla/mux v1.8.0 // indirect
-	github.com/mattn/go-sqlite3 v1.14.10 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
-	github.com/sirupsen/logrus v1.8.1 // indirect
-	github.com/stretchr/testify v1.7.0 // indirect
+	github.com/gorilla/mux v1.8.0
+	github.com/lib/pq v1.9.0
+	github.com/mattn/go-sqlite3 v1.14.10
+	github.com/pkg/errors v0.9.1
+	github.com/sirupsen/logrus v1.8.1
+	github.com/stretchr/testify v1.7.0
 )
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"car-rental/internal/server/auth"
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"crypto/rand"
+	"net/http"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type tokenRequest struct {
+	CustomerID int `json:"customerID"`
+}
+
+/*
+Authenticator of REST API's, nil is returned when authentication is disabled
+*/
+func newAuthenticator(cfg config.AuthConfig) (*auth.Authenticator, error) {
+	if cfg.Disabled {
+		log.Warn("Authentication is disabled, every request is let through")
+		return nil, nil
+	}
+	keys, err := auth.NewLocalKeyStore(nil)
+	if len(cfg.KeysFile) > 0 {
+		keys, err = auth.LoadKeyStore(cfg.KeysFile)
+	} else {
+		log.Warnf("%s is not set, requests with API key are rejected", config.APIKeysFileEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	secret := []byte(cfg.JWTSecret)
+	if len(secret) == 0 {
+		// Tokens issued with random secret are not accepted after restart
+		log.Warnf("%s is not set, random secret is used", config.JWTSecretEnv)
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, errors.Wrap(err, "Failed to generate token secret")
+		}
+	}
+	return auth.NewAuthenticator(keys, auth.NewSigner(secret, cfg.TokenTTL)), nil
+}
+
+/*
+Method responsible for issuing customer token, it is called by back-office integration with API key.
+Branch agent issues tokens only for customers who rent at own branches
+*/
+func (restPr *RestProcessor) issueToken(writer http.ResponseWriter, request *http.Request) {
+	customerProcessor := cmds.NewCustomerProcessor(restPr.repos)
+	responseCode := http.StatusCreated
+	var responseMessage interface{}
+	var err error
+
+	var body tokenRequest
+	err = parseBodyToObj(request, &body)
+	if err != nil {
+		responseCode = http.StatusBadRequest
+	} else if restPr.authenticator == nil {
+		responseCode = http.StatusNotFound
+		responseMessage = "Authentication is disabled"
+	} else {
+		var customer *domain.Customer
+		customer, err = customerProcessor.GetCustomerFromDB(body.CustomerID)
+		if errors.Is(err, storage.ErrNotFound) {
+			responseCode = http.StatusUnprocessableEntity
+			responseMessage = "Customer not found"
+		} else if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to issue token"
+		} else if err = restPr.checkCustomerScope(request, customer.CustomerID); errors.Is(err, auth.ErrForbidden) {
+			responseCode = http.StatusForbidden
+			responseMessage = http.StatusText(http.StatusForbidden)
+		} else if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to issue token"
+		} else {
+			token := domain.AuthToken{TokenType: "Bearer", CustomerID: customer.CustomerID}
+			token.Token, token.ExpiresAt, err = restPr.issueCustomerToken(customer.CustomerID)
+			if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to issue token"
+			} else {
+				responseMessage = token
+			}
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+func (restPr *RestProcessor) issueCustomerToken(customerID int) (string, string, error) {
+	token, expiresAt, err := restPr.authenticator.Signer().Issue(customerID)
+	if err != nil {
+		return "", "", err
+	}
+	return token, expiresAt.Format(domain.TimeLayout), nil
+}
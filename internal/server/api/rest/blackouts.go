@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Method responsible for blackouts listing and new blackout creating
+*/
+func (restPr *RestProcessor) carBlackouts(writer http.ResponseWriter, request *http.Request) {
+	blackoutProcessor := cmds.NewBlackoutProcessorWithRules(restPr.repos, restPr.cfg.Availability).WithLogger(domain.Logger(request))
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var carID int
+	skipProcessing := false
+	carID, err = extractPathID(request, domain.CarIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+		skipProcessing = true
+	}
+	if !skipProcessing {
+		switch request.Method {
+		case http.MethodGet:
+			responseMessage, err = blackoutProcessor.GetCarBlackoutsFromDB(carID)
+			if errors.Is(err, storage.ErrNotFound) {
+				responseCode = http.StatusNotFound
+				responseMessage = "Car not found"
+			} else if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to get blackouts"
+			}
+		case http.MethodPost:
+			insertBlackoutProcessing := func() {
+				var blackout domain.Blackout
+				err = parseBodyToObj(request, &blackout)
+				if err != nil {
+					responseCode = http.StatusBadRequest
+					return
+				}
+				restPr.carMutex.Lock()
+				defer restPr.carMutex.Unlock()
+				var id int64
+				id, err = blackoutProcessor.InsertBlackoutInDB(blackout, carID)
+				responseCode, responseMessage = blackoutResponse(err)
+				if err == nil {
+					responseCode = http.StatusCreated
+					responseMessage = fmt.Sprintf("New Blackout Sussesfully Added. Blackout ID number = %d", id)
+				}
+			}
+			insertBlackoutProcessing()
+		default:
+			responseCode = http.StatusBadRequest
+			responseMessage = "This method is not allowed"
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for blackout listing, blackout update and blackout deletion
+*/
+func (restPr *RestProcessor) crudBlackouts(writer http.ResponseWriter, request *http.Request) {
+	blackoutProcessor := cmds.NewBlackoutProcessorWithRules(restPr.repos, restPr.cfg.Availability).WithLogger(domain.Logger(request))
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var carID, blackoutID int
+	skipProcessing := false
+	carID, err = extractPathID(request, domain.CarIDPathParam)
+	if err == nil {
+		blackoutID, err = extractPathID(request, domain.BlackoutIDPathParam)
+	}
+	if err != nil {
+		responseCode = http.StatusNotFound
+		skipProcessing = true
+	}
+	if !skipProcessing {
+		switch request.Method {
+		case http.MethodGet:
+			responseMessage, err = blackoutProcessor.GetBlackoutFromDB(carID, blackoutID)
+			if err != nil {
+				responseCode, responseMessage = blackoutResponse(err)
+			}
+		case http.MethodPut:
+			updateBlackoutProcessing := func() {
+				var blackout domain.Blackout
+				err = parseBodyToObj(request, &blackout)
+				if err != nil {
+					responseCode = http.StatusBadRequest
+					return
+				}
+				restPr.carMutex.Lock()
+				defer restPr.carMutex.Unlock()
+				_, err = blackoutProcessor.UpdateBlackoutInDB(blackout, carID, blackoutID)
+				responseCode, responseMessage = blackoutResponse(err)
+				if err == nil {
+					responseMessage = "Blackout sussesfully updated"
+				}
+			}
+			updateBlackoutProcessing()
+		case http.MethodDelete:
+			removeBlackoutProcessing := func() {
+				restPr.carMutex.Lock()
+				defer restPr.carMutex.Unlock()
+				_, err = blackoutProcessor.RemoveBlackoutFromDB(carID, blackoutID)
+				responseCode, responseMessage = blackoutResponse(err)
+				if err == nil {
+					responseMessage = "Blackout sussesfully removed"
+				}
+			}
+			removeBlackoutProcessing()
+		default:
+			responseCode = http.StatusBadRequest
+			responseMessage = "This method is not allowed"
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Map blackout processing error to response, nil error gets 200 without message
+*/
+func blackoutResponse(err error) (int, interface{}) {
+	var notAvailableErr *cmds.CarNotAvailableError
+	var inUseErr *cmds.CarInUseError
+	if violationCode, violationMessage, ok := validationResponse(err); ok {
+		return violationCode, violationMessage
+	} else if errors.As(err, &notAvailableErr) {
+		return http.StatusConflict, rentConflict("Car has rents in such dates", notAvailableErr)
+	} else if errors.As(err, &inUseErr) {
+		return http.StatusConflict, domain.CarInUse{Message: "Rents pick the car up where transfer leaves it", Rents: inUseErr.Rents}
+	} else if errors.Is(err, storage.ErrNotFound) {
+		return http.StatusNotFound, "Blackout not found"
+	} else if err != nil {
+		return http.StatusInternalServerError, "Failed to process blackout"
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,120 @@
+package rest
+
+import (
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Method responsible for branches listing and new branch creating
+*/
+func (restPr *RestProcessor) branches(writer http.ResponseWriter, request *http.Request) {
+	branchProcessor := cmds.NewBranchProcessor(restPr.repos)
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	switch request.Method {
+	case http.MethodPost:
+		var branch domain.Branch
+		err = parseBodyToObj(request, &branch)
+		if err != nil {
+			responseCode = http.StatusBadRequest
+			break
+		}
+		var id int64
+		id, err = branchProcessor.InsertBranchInDB(branch)
+		if violationCode, violationMessage, ok := validationResponse(err); ok {
+			responseCode = violationCode
+			responseMessage = violationMessage
+		} else if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to insert branch"
+		} else {
+			responseCode = http.StatusCreated
+			responseMessage = fmt.Sprintf("New Branch Sussesfully Added. Branch ID number = %d", id)
+		}
+	case http.MethodGet:
+		responseMessage, err = branchProcessor.GetBranchesFromDB()
+	default:
+		responseCode = http.StatusBadRequest
+		responseMessage = "This method is not allowed"
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for branch listing, branch update and branch deletion
+*/
+func (restPr *RestProcessor) crudBranches(writer http.ResponseWriter, request *http.Request) {
+	branchProcessor := cmds.NewBranchProcessor(restPr.repos)
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var branchID int
+	skipProcessing := false
+	branchID, err = extractPathID(request, domain.BranchIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+		skipProcessing = true
+	}
+	if !skipProcessing {
+		switch request.Method {
+		case http.MethodGet:
+			responseMessage, err = branchProcessor.GetBranchFromDB(branchID)
+			if errors.Is(err, storage.ErrNotFound) {
+				responseCode = http.StatusNotFound
+			}
+		case http.MethodPut:
+			var branch domain.Branch
+			err = parseBodyToObj(request, &branch)
+			if err != nil {
+				responseCode = http.StatusBadRequest
+				break
+			}
+			var affected int64
+			affected, err = branchProcessor.UpdateBranchInDB(branch, branchID)
+			if violationCode, violationMessage, ok := validationResponse(err); ok {
+				responseCode = violationCode
+				responseMessage = violationMessage
+			} else if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to update branch"
+			} else if affected == 0 {
+				responseCode = http.StatusNotFound
+				responseMessage = "Branch not found"
+			} else {
+				responseMessage = "Branch sussesfully updated"
+			}
+		case http.MethodDelete:
+			var affected int64
+			affected, err = branchProcessor.RemoveBranchFromDB(branchID)
+			if errors.Is(err, storage.ErrInUse) {
+				responseCode = http.StatusConflict
+				responseMessage = "Branch has rents and can not be removed"
+			} else if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to remove branch"
+			} else if affected == 0 {
+				responseCode = http.StatusNotFound
+				responseMessage = "Branch not found"
+			} else {
+				responseMessage = "Branch sussesfully removed"
+			}
+		default:
+			responseCode = http.StatusBadRequest
+			responseMessage = "This method is not allowed"
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
@@ -3,6 +3,8 @@ package rest
 import (
 	"car-rental/internal/server/cmds"
 	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -11,14 +13,13 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 )
 
 /*
 Method responsible for cars listing and new car creating
 */
 func (restPr *RestProcessor) cars(writer http.ResponseWriter, request *http.Request) {
-	carProcessor := cmds.NewCarProcessor(restPr.dbStruct)
+	carProcessor := cmds.NewCarProcessorWithRules(restPr.repos, restPr.cfg.Availability)
 	responseCode := http.StatusOK
 	var responseMessage interface{}
 	var err error
@@ -27,14 +28,15 @@ func (restPr *RestProcessor) cars(writer http.ResponseWriter, request *http.Requ
 		var car domain.Car
 		err = parseBodyToObj(request, &car)
 		if err != nil {
-			log.Error(err)
 			responseCode = http.StatusBadRequest
 			break
 		}
 		var id int64
 		id, err = carProcessor.InsertCarInDB(car)
-		if err != nil {
-			log.Error(err)
+		if violationCode, violationMessage, ok := validationResponse(err); ok {
+			responseCode = violationCode
+			responseMessage = violationMessage
+		} else if err != nil {
 			responseCode = http.StatusInternalServerError
 			responseMessage = "Failed to insert car"
 		} else {
@@ -47,6 +49,13 @@ func (restPr *RestProcessor) cars(writer http.ResponseWriter, request *http.Requ
 			responseMessage, err = carProcessor.GetCarsFromDB()
 		} else {
 			responseMessage, err = carProcessor.GetCarsFromDBWithParams(urlValues)
+			if violationCode, violationMessage, ok := validationResponse(err); ok {
+				responseCode = violationCode
+				responseMessage = violationMessage
+			} else if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to search cars"
+			}
 		}
 	default:
 		responseCode = http.StatusBadRequest
@@ -54,7 +63,7 @@ func (restPr *RestProcessor) cars(writer http.ResponseWriter, request *http.Requ
 	}
 
 	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
-		log.Error(errors.Wrap(err, "Error occurred during writing response"))
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
 	}
 
 }
@@ -63,7 +72,7 @@ func (restPr *RestProcessor) cars(writer http.ResponseWriter, request *http.Requ
 Method responsible for car listing, car update and car deletion
 */
 func (restPr *RestProcessor) crudCars(writer http.ResponseWriter, request *http.Request) {
-	carProcessor := cmds.NewCarProcessor(restPr.dbStruct)
+	carProcessor := cmds.NewCarProcessorWithRules(restPr.repos, restPr.cfg.Availability)
 	responseCode := http.StatusOK
 	var responseMessage interface{}
 	var err error
@@ -71,7 +80,6 @@ func (restPr *RestProcessor) crudCars(writer http.ResponseWriter, request *http.
 	skipProcessing := false
 	carID, err = extractPathID(request, domain.CarIDPathParam)
 	if err != nil {
-		log.Error(err)
 		responseCode = http.StatusNotFound
 		skipProcessing = true
 	}
@@ -86,13 +94,14 @@ func (restPr *RestProcessor) crudCars(writer http.ResponseWriter, request *http.
 				var car domain.Car
 				err = parseBodyToObj(request, &car)
 				if err != nil {
-					log.Error(err)
 					responseCode = http.StatusBadRequest
 					return
 				}
 				_, err = carProcessor.UpdateCarInDB(car, carID)
-				if err != nil {
-					log.Error(err)
+				if violationCode, violationMessage, ok := validationResponse(err); ok {
+					responseCode = violationCode
+					responseMessage = violationMessage
+				} else if err != nil {
 					responseCode = http.StatusInternalServerError
 					responseMessage = "Failed to update car"
 				} else {
@@ -102,15 +111,27 @@ func (restPr *RestProcessor) crudCars(writer http.ResponseWriter, request *http.
 			updateCarProcessing()
 		case http.MethodDelete:
 			removeCarProcessing := func() {
+				var reassign bool
+				reassign, err = parseBoolValue(request, domain.ReassignUrlValue)
+				if violationCode, violationMessage, ok := validationResponse(err); ok {
+					responseCode = violationCode
+					responseMessage = violationMessage
+					return
+				}
 				restPr.carMutex.Lock()
 				defer restPr.carMutex.Unlock()
-				_, err = carProcessor.RemoveCarFromDB(carID)
-				if err != nil {
-					log.Error(err)
+				rentProcessor := cmds.NewRentProcessorWithRules(restPr.repos, restPr.cfg.Availability, restPr.cfg.Pricing, restPr.cfg.Cancellation, restPr.cfg.Licence).WithLogger(domain.Logger(request))
+				responseMessage, err = rentProcessor.RetireCar(carID, reassign)
+				var inUseErr *cmds.CarInUseError
+				if errors.As(err, &inUseErr) {
+					responseCode = http.StatusConflict
+					responseMessage = domain.CarInUse{Message: inUseErr.Error(), Rents: inUseErr.Rents}
+				} else if errors.Is(err, storage.ErrNotFound) {
+					responseCode = http.StatusNotFound
+					responseMessage = "Car not found"
+				} else if err != nil {
 					responseCode = http.StatusInternalServerError
-					responseMessage = "Failed to remove car"
-				} else {
-					responseMessage = "Car sussesfully removed"
+					responseMessage = "Failed to retire car"
 				}
 			}
 			removeCarProcessing()
@@ -121,7 +142,36 @@ func (restPr *RestProcessor) crudCars(writer http.ResponseWriter, request *http.
 	}
 
 	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
-		log.Error(errors.Wrap(err, "Error occurred during writing response"))
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for listing where car will be after each of its rents
+*/
+func (restPr *RestProcessor) carPositions(writer http.ResponseWriter, request *http.Request) {
+	carProcessor := cmds.NewCarProcessorWithRules(restPr.repos, restPr.cfg.Availability)
+
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var carID int
+	carID, err = extractPathID(request, domain.CarIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+	} else {
+		responseMessage, err = carProcessor.GetCarPositionsFromDB(carID)
+		if errors.Is(err, storage.ErrNotFound) {
+			responseCode = http.StatusNotFound
+			responseMessage = "Car not found"
+		} else if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to get car positions"
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
 	}
 }
 
@@ -130,7 +180,7 @@ func parseBodyToObj(request *http.Request, obj interface{}) error {
 	if err != nil {
 		return errors.Wrap(err, "Incorrect body in request")
 	}
-	log.Debugf("Request body [%s]", string(requestBody))
+	domain.Logger(request).Debugf("Request body [%s]", string(requestBody))
 	err = json.Unmarshal(requestBody, obj)
 	if err != nil {
 		return errors.Wrap(err, "Incorrect format of body")
@@ -138,6 +188,37 @@ func parseBodyToObj(request *http.Request, obj interface{}) error {
 	return nil
 }
 
+/*
+Response code and message for request rejected by validation.
+Malformed request gets 400, well formed request which breaks rules of stored data gets 422
+*/
+func validationResponse(err error) (int, interface{}, bool) {
+	violations, ok := validation.From(err)
+	if !ok {
+		return 0, nil, false
+	}
+	responseCode := http.StatusUnprocessableEntity
+	if violations.Malformed() {
+		responseCode = http.StatusBadRequest
+	}
+	return responseCode, domain.ValidationFailure{Message: "Request is not valid", Violations: violations}, true
+}
+
+/*
+Optional boolean query parameter, missing parameter is false
+*/
+func parseBoolValue(request *http.Request, name string) (bool, error) {
+	value := request.URL.Query().Get(name)
+	if len(value) == 0 {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, validation.Violations{*validation.New(name, validation.InvalidFormat, "[%s] should be true or false", value)}
+	}
+	return parsed, nil
+}
+
 func extractPathID(request *http.Request, stringParam string) (int, error) {
 	requestPathParams := mux.Vars(request)
 
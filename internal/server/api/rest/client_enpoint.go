@@ -1,8 +1,12 @@
 package rest
 
 import (
-	"car-rental/internal/server/db"
+	"car-rental/internal/server/auth"
+	"car-rental/internal/server/config"
 	"car-rental/internal/server/domain"
+	"car-rental/internal/server/metrics"
+	"car-rental/internal/server/quotes"
+	"car-rental/internal/server/storage"
 	"fmt"
 	"net/http"
 	"sync"
@@ -13,22 +17,69 @@ import (
 )
 
 type RestProcessor struct {
-	dbStruct *db.DBStruct
+	repos    storage.Repositories
+	cfg      *config.Config
+	quotes   *quotes.Store
 	Router   *mux.Router
 	carMutex *sync.RWMutex
+	// Nil when authentication is disabled
+	authenticator *auth.Authenticator
+	// Served by /metrics, fleet gauges of repos are registered in it
+	registry *metrics.Registry
 }
 
 /*
-Creates router and defines REST API's
+Creates router and defines REST API's, metrics of registry are served by /metrics
 */
-func NewServer(dbStruct *db.DBStruct) (*mux.Router, error) {
+func NewServer(repos storage.Repositories, cfg *config.Config, registry *metrics.Registry) (*mux.Router, error) {
 	log.Info("Launching REST API's")
 	rtr := mux.NewRouter()
-	restProcessor := RestProcessor{dbStruct: dbStruct, carMutex: &sync.RWMutex{}}
-	rtr.Handle("/api/cars", domain.WrapREST(restProcessor.cars)).Methods(http.MethodGet, http.MethodPost)
-	rtr.Handle(fmt.Sprintf("/api/cars/{%s}", domain.CarIDPathParam), domain.WrapREST(restProcessor.crudCars)).Methods(http.MethodGet, http.MethodPut, http.MethodDelete)
-	rtr.Handle("/api/rents", domain.WrapREST(restProcessor.rents)).Methods(http.MethodGet, http.MethodPost)
-	rtr.Handle(fmt.Sprintf("/api/rents/{%s}", domain.RentIDPathParam), domain.WrapREST(restProcessor.rentDetails)).Methods(http.MethodGet, http.MethodDelete)
+	authenticator, err := newAuthenticator(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	restProcessor := RestProcessor{repos: repos, cfg: cfg, quotes: quotes.NewStore(cfg.QuoteTTL), carMutex: &sync.RWMutex{}, authenticator: authenticator, registry: registry}
+	registerFleetGauges(registry, repos)
+	pipeline := domain.NewPipeline().Hook(metricsHook)
+	if accessLogger := newAccessLogger(cfg.Log); accessLogger != nil {
+		pipeline = pipeline.Hook(accessLog(accessLogger))
+	}
+	for _, route := range restProcessor.routes() {
+		rtr.Handle(route.path, pipeline.Filter(restProcessor.routeFilters(route.policy)...).Wrap(route.handler)).Methods(route.policy.Methods()...)
+	}
 	restProcessor.Router = rtr
 	return rtr, nil
 }
+
+/*
+Every REST API together with roles allowed to call it. Admin manages the fleet, branch agent acts on cars and rents
+of own branches, customer reads catalogue and reads or cancels own rents
+*/
+func (restPr *RestProcessor) routes() []route {
+	return []route{
+		{"/api/cars", restPr.cars, auth.Policy{http.MethodGet: everyone, http.MethodPost: adminOnly}},
+		{fmt.Sprintf("/api/cars/{%s}", domain.CarIDPathParam), restPr.crudCars, auth.Policy{http.MethodGet: everyone, http.MethodPut: adminOnly, http.MethodDelete: adminOnly}},
+		{fmt.Sprintf("/api/cars/{%s}/reassignments", domain.CarIDPathParam), restPr.carReassignments, auth.Policy{http.MethodPost: branchStaff}},
+		{fmt.Sprintf("/api/cars/{%s}/positions", domain.CarIDPathParam), restPr.carPositions, auth.Policy{http.MethodGet: branchStaff}},
+		{fmt.Sprintf("/api/cars/{%s}/blackouts", domain.CarIDPathParam), restPr.carBlackouts, auth.Policy{http.MethodGet: branchStaff, http.MethodPost: branchStaff}},
+		{fmt.Sprintf("/api/cars/{%s}/blackouts/{%s}", domain.CarIDPathParam, domain.BlackoutIDPathParam), restPr.crudBlackouts,
+			auth.Policy{http.MethodGet: branchStaff, http.MethodPut: branchStaff, http.MethodDelete: branchStaff}},
+		{"/api/rents", restPr.rents, auth.Policy{http.MethodGet: rentOwners, http.MethodPost: branchStaff}},
+		{fmt.Sprintf("/api/rents/{%s}", domain.RentIDPathParam), restPr.rentDetails,
+			auth.Policy{http.MethodGet: rentOwners, http.MethodPut: branchStaff, http.MethodPatch: branchStaff, http.MethodDelete: rentOwners}},
+		{fmt.Sprintf("/api/rents/{%s}/{%s:pickup|return|close}", domain.RentIDPathParam, domain.RentActionPathParam), restPr.rentTransitions, auth.Policy{http.MethodPost: branchStaff}},
+		{fmt.Sprintf("/api/rents/{%s}/{%s:cancel}", domain.RentIDPathParam, domain.RentActionPathParam), restPr.rentTransitions, auth.Policy{http.MethodPost: rentOwners}},
+		{fmt.Sprintf("/api/rents/{%s}/history", domain.RentIDPathParam), restPr.rentHistory, auth.Policy{http.MethodGet: rentOwners}},
+		{fmt.Sprintf("/api/rents/{%s}/reassignments", domain.RentIDPathParam), restPr.rentReassignments, auth.Policy{http.MethodGet: branchStaff}},
+		{"/api/branches", restPr.branches, auth.Policy{http.MethodGet: everyone, http.MethodPost: adminOnly}},
+		{fmt.Sprintf("/api/branches/{%s}", domain.BranchIDPathParam), restPr.crudBranches, auth.Policy{http.MethodGet: everyone, http.MethodPut: adminOnly, http.MethodDelete: adminOnly}},
+		{"/api/customers", restPr.customers, auth.Policy{http.MethodGet: branchStaff, http.MethodPost: staff}},
+		{fmt.Sprintf("/api/customers/{%s}", domain.CustomerIDPathParam), restPr.crudCustomers, auth.Policy{http.MethodGet: profileOwners, http.MethodPut: branchStaff, http.MethodDelete: adminOnly}},
+		{"/api/fleet/rebalance-plan", restPr.rebalancePlan, auth.Policy{http.MethodGet: adminOnly}},
+		{"/api/fleet/rebalance-plan/apply", restPr.applyRebalancePlan, auth.Policy{http.MethodPost: adminOnly}},
+		{"/api/quotes", restPr.createQuote, auth.Policy{http.MethodPost: everyone}},
+		{fmt.Sprintf("/api/quotes/{%s}", domain.QuoteIDPathParam), restPr.quoteDetails, auth.Policy{http.MethodGet: everyone}},
+		{"/api/auth/token", restPr.issueToken, auth.Policy{http.MethodPost: branchStaff}},
+		{"/metrics", restPr.serveMetrics, auth.Policy{http.MethodGet: adminOnly}},
+	}
+}
@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Method responsible for customers listing and new customer creating
+*/
+func (restPr *RestProcessor) customers(writer http.ResponseWriter, request *http.Request) {
+	customerProcessor := cmds.NewCustomerProcessor(restPr.repos)
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	switch request.Method {
+	case http.MethodPost:
+		var customer domain.Customer
+		err = parseBodyToObj(request, &customer)
+		if err != nil {
+			responseCode = http.StatusBadRequest
+			break
+		}
+		var id int64
+		id, err = customerProcessor.InsertCustomerInDB(customer)
+		if violationCode, violationMessage, ok := validationResponse(err); ok {
+			responseCode = violationCode
+			responseMessage = violationMessage
+		} else if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to insert customer"
+		} else {
+			responseCode = http.StatusCreated
+			responseMessage = fmt.Sprintf("New Customer Sussesfully Added. Customer ID number = %d", id)
+		}
+	case http.MethodGet:
+		var customers []domain.Customer
+		customers, err = customerProcessor.GetCustomersFromDB()
+		if err == nil {
+			customers, err = restPr.customersInScope(request, customers)
+		}
+		if err == nil {
+			responseMessage = customers
+		}
+	default:
+		responseCode = http.StatusBadRequest
+		responseMessage = "This method is not allowed"
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for customer listing, customer update and customer deletion
+*/
+func (restPr *RestProcessor) crudCustomers(writer http.ResponseWriter, request *http.Request) {
+	customerProcessor := cmds.NewCustomerProcessor(restPr.repos)
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var customerID int
+	skipProcessing := false
+	customerID, err = extractPathID(request, domain.CustomerIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+		skipProcessing = true
+	}
+	if !skipProcessing {
+		switch request.Method {
+		case http.MethodGet:
+			responseMessage, err = customerProcessor.GetCustomerFromDB(customerID)
+			if errors.Is(err, storage.ErrNotFound) {
+				responseCode = http.StatusNotFound
+			}
+		case http.MethodPut:
+			var customer domain.Customer
+			err = parseBodyToObj(request, &customer)
+			if err != nil {
+				responseCode = http.StatusBadRequest
+				break
+			}
+			var affected int64
+			affected, err = customerProcessor.UpdateCustomerInDB(customer, customerID)
+			if violationCode, violationMessage, ok := validationResponse(err); ok {
+				responseCode = violationCode
+				responseMessage = violationMessage
+			} else if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to update customer"
+			} else if affected == 0 {
+				responseCode = http.StatusNotFound
+				responseMessage = "Customer not found"
+			} else {
+				responseMessage = "Customer sussesfully updated"
+			}
+		case http.MethodDelete:
+			removeCustomerProcessing := func() {
+				// New rent may refer to the customer while it is removed
+				restPr.carMutex.Lock()
+				defer restPr.carMutex.Unlock()
+				var affected int64
+				affected, err = customerProcessor.RemoveCustomerFromDB(customerID)
+				if errors.Is(err, storage.ErrInUse) {
+					responseCode = http.StatusConflict
+					responseMessage = "Customer has rents and can not be removed"
+				} else if err != nil {
+					responseCode = http.StatusInternalServerError
+					responseMessage = "Failed to remove customer"
+				} else if affected == 0 {
+					responseCode = http.StatusNotFound
+					responseMessage = "Customer not found"
+				} else {
+					responseMessage = "Customer sussesfully removed"
+				}
+			}
+			removeCustomerProcessing()
+		default:
+			responseCode = http.StatusBadRequest
+			responseMessage = "This method is not allowed"
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Method responsible for planning transfers of idle cars between branches, nothing is moved
+*/
+func (restPr *RestProcessor) rebalancePlan(writer http.ResponseWriter, request *http.Request) {
+	restPr.rebalance(writer, request, false)
+}
+
+/*
+Method responsible for storing planned transfers as blackouts of moved cars
+*/
+func (restPr *RestProcessor) applyRebalancePlan(writer http.ResponseWriter, request *http.Request) {
+	restPr.rebalance(writer, request, true)
+}
+
+func (restPr *RestProcessor) rebalance(writer http.ResponseWriter, request *http.Request, apply bool) {
+	fleetProcessor := cmds.NewFleetProcessorWithRules(restPr.repos, restPr.cfg.Rebalancing)
+
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	rebalanceProcessing := func() {
+		var start time.Time
+		start, err = parseStartValue(request)
+		if violationCode, violationMessage, ok := validationResponse(err); ok {
+			responseCode = violationCode
+			responseMessage = violationMessage
+			return
+		}
+		if apply {
+			restPr.carMutex.Lock()
+			defer restPr.carMutex.Unlock()
+			responseMessage, err = fleetProcessor.ApplyRebalancing(start)
+		} else {
+			restPr.carMutex.RLock()
+			defer restPr.carMutex.RUnlock()
+			responseMessage, err = fleetProcessor.PlanRebalancing(start)
+		}
+		if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to plan fleet rebalancing"
+		}
+	}
+	rebalanceProcessing()
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Optional fromDate query parameter, missing parameter is the current hour
+*/
+func parseStartValue(request *http.Request) (time.Time, error) {
+	value := request.URL.Query().Get(domain.FromDateUrlValue)
+	if len(value) == 0 {
+		return time.Now().UTC().Truncate(time.Hour), nil
+	}
+	start, err := time.Parse(domain.TimeLayout, value)
+	if err != nil {
+		return start, validation.Violations{*validation.New(domain.FromDateUrlValue, validation.InvalidFormat, "[%s] should be in format %s", value, domain.TimeLayout)}
+	}
+	return start, nil
+}
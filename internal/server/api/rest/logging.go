@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"car-rental/internal/server/auth"
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/domain"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Logger writing one JSON line per request to stdout, nil when access log is disabled
+*/
+func newAccessLogger(logCfg config.LogConfig) *log.Logger {
+	if !logCfg.AccessLog {
+		return nil
+	}
+	logger := log.New()
+	logger.SetOutput(os.Stdout)
+	logger.SetFormatter(&log.JSONFormatter{})
+	return logger
+}
+
+/*
+Hook writing access log line with route template instead of path, so requests of one route can be grouped
+*/
+func accessLog(logger *log.Logger) domain.ResponseHTTPHookFunc {
+	return func(request *http.Request, response domain.ResponseInfo) {
+		fields := log.Fields{
+			"requestID": domain.RequestID(request),
+			"method":    request.Method,
+			"route":     routeTemplate(request),
+			"status":    response.Code,
+			"latencyMs": float64(response.Duration.Microseconds()) / 1000,
+			"bytes":     response.Bytes,
+		}
+		if principal := auth.RequestPrincipal(request); principal != nil {
+			fields["user"] = principal.User()
+		}
+		logger.WithFields(fields).Info("access")
+	}
+}
+
+func routeTemplate(request *http.Request) string {
+	if route := mux.CurrentRoute(request); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return request.URL.Path
+}
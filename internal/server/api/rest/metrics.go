@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/metrics"
+	"car-rental/internal/server/storage"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Hook counting requests and observing latency per route template, paths with IDs would give series per ID
+*/
+func metricsHook(request *http.Request, response domain.ResponseInfo) {
+	route := routeTemplate(request)
+	metrics.HTTPRequests.Inc(request.Method, route, strconv.Itoa(response.Code))
+	metrics.HTTPRequestDuration.Observe(response.Duration.Seconds(), request.Method, route)
+}
+
+/*
+Fleet gauges are read from storage on every scrape, so they are right after restart too.
+Gauges registered again replace the ones of the previous server
+*/
+func registerFleetGauges(registry *metrics.Registry, repos storage.Repositories) {
+	registry.NewGaugeFunc("car_rental_fleet_cars", "Cars which are not retired", func() (float64, error) {
+		cars, err := repos.Cars.GetCars()
+		if err != nil {
+			return 0, errors.Wrap(err, "Failed to get cars")
+		}
+		fleet := 0
+		for _, car := range cars {
+			if len(car.RetiredAt) == 0 {
+				fleet++
+			}
+		}
+		return float64(fleet), nil
+	})
+	registry.NewGaugeFunc("car_rental_rented_cars", "Cars which are picked up and not returned yet", func() (float64, error) {
+		rents, err := repos.Rents.GetRents()
+		if err != nil {
+			return 0, errors.Wrap(err, "Failed to get rents")
+		}
+		rented := make(map[int]bool)
+		for _, rent := range rents {
+			if rent.Status == domain.PickedUpRentStatus {
+				rented[rent.CarID] = true
+			}
+		}
+		return float64(len(rented)), nil
+	})
+}
+
+/*
+Method responsible for metrics in Prometheus text format
+*/
+func (restPr *RestProcessor) serveMetrics(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", metrics.ContentType)
+	if err := restPr.registry.Write(writer); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing metrics"))
+	}
+}
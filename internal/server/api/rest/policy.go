@@ -0,0 +1,209 @@
+package rest
+
+import (
+	"car-rental/internal/server/auth"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	everyone    = auth.Grants{auth.RoleAdmin: auth.ScopeAll, auth.RoleAgent: auth.ScopeAll, auth.RoleCustomer: auth.ScopeAll}
+	staff       = auth.Grants{auth.RoleAdmin: auth.ScopeAll, auth.RoleAgent: auth.ScopeAll}
+	branchStaff = auth.Grants{auth.RoleAdmin: auth.ScopeAll, auth.RoleAgent: auth.ScopeBranch}
+	adminOnly   = auth.Grants{auth.RoleAdmin: auth.ScopeAll}
+	// Customer acts on own rents, agent on rents of own branches
+	rentOwners = auth.Grants{auth.RoleAdmin: auth.ScopeAll, auth.RoleAgent: auth.ScopeBranch, auth.RoleCustomer: auth.ScopeOwn}
+	// Customer reads own profile, agent profiles of customers who rent at own branches
+	profileOwners = auth.Grants{auth.RoleAdmin: auth.ScopeAll, auth.RoleAgent: auth.ScopeBranch, auth.RoleCustomer: auth.ScopeOwn}
+)
+
+/*
+REST route together with roles allowed to call it
+*/
+type route struct {
+	path    string
+	handler func(writer http.ResponseWriter, request *http.Request)
+	policy  auth.Policy
+}
+
+/*
+Filters of the route, no filters are used when authentication is disabled
+*/
+func (restPr *RestProcessor) routeFilters(policy auth.Policy) []domain.RequestHTTPFilterFunc {
+	if restPr.authenticator == nil {
+		return nil
+	}
+	return []domain.RequestHTTPFilterFunc{restPr.authenticator.PolicyFilter(policy, restPr.checkScope)}
+}
+
+/*
+Check that rent, car or customer of the path belongs to the caller. Listing routes have no path ID,
+their handlers leave out resources of other callers. Missing resource is let through, so handler returns 404
+*/
+func (restPr *RestProcessor) checkScope(request *http.Request, principal auth.Principal, scope auth.Scope) error {
+	if rentID, err := extractPathID(request, domain.RentIDPathParam); err == nil {
+		rent, err := restPr.repos.Rents.GetRent(rentID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "Failed to check rent owner")
+		}
+		if !rentInScope(principal, scope, *rent) {
+			return errors.Wrapf(auth.ErrForbidden, "Rent %d does not belong to the caller", rentID)
+		}
+		return nil
+	}
+	if carID, err := extractPathID(request, domain.CarIDPathParam); err == nil && scope == auth.ScopeBranch {
+		car, err := restPr.repos.Cars.GetCar(carID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "Failed to check car branch")
+		}
+		if !principal.AtBranch(car.BranchIDs...) {
+			return errors.Wrapf(auth.ErrForbidden, "Car %d is not at branch of the caller", carID)
+		}
+		return nil
+	}
+	if customerID, err := extractPathID(request, domain.CustomerIDPathParam); err == nil && scope == auth.ScopeOwn {
+		if customerID != principal.CustomerID {
+			return errors.Wrapf(auth.ErrForbidden, "Customer %d is not the caller", customerID)
+		}
+	}
+	if customerID, err := extractPathID(request, domain.CustomerIDPathParam); err == nil && scope == auth.ScopeBranch {
+		_, err := restPr.repos.Customers.GetCustomer(customerID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "Failed to check customer branch")
+		}
+		customers, err := restPr.branchCustomers(principal)
+		if err != nil {
+			return err
+		}
+		if !customers[customerID] {
+			return errors.Wrapf(auth.ErrForbidden, "Customer %d has no rents at branch of the caller", customerID)
+		}
+	}
+	return nil
+}
+
+func rentInScope(principal auth.Principal, scope auth.Scope, rent domain.RentInfo) bool {
+	switch scope {
+	case auth.ScopeBranch:
+		return principal.AtBranch(rent.BranchID, rent.ReturnBranchID)
+	case auth.ScopeOwn:
+		return rent.CustomerID == principal.CustomerID
+	}
+	return true
+}
+
+/*
+Caller of the request kept by policy filter, nil when authentication is disabled
+*/
+func (restPr *RestProcessor) principal(request *http.Request) (*auth.Principal, error) {
+	if restPr.authenticator == nil {
+		return nil, nil
+	}
+	if principal := auth.RequestPrincipal(request); principal != nil {
+		return principal, nil
+	}
+	return restPr.authenticator.Authenticate(request)
+}
+
+/*
+Leave out rents of other branches for branch agent and rents of other customers for customer
+*/
+func (restPr *RestProcessor) rentsInScope(request *http.Request, rents []domain.RentInfo) ([]domain.RentInfo, error) {
+	principal, err := restPr.principal(request)
+	if err != nil || principal == nil {
+		return rents, err
+	}
+	scope, ok := rentOwners[principal.Role]
+	if !ok {
+		return nil, errors.Wrapf(auth.ErrForbidden, "Role %s can not list rents", principal.Role)
+	}
+	filtered := make([]domain.RentInfo, 0, len(rents))
+	for _, rent := range rents {
+		if rentInScope(*principal, scope, rent) {
+			filtered = append(filtered, rent)
+		}
+	}
+	return filtered, nil
+}
+
+/*
+Branch agent books and moves rents only on cars of own branches
+*/
+func (restPr *RestProcessor) checkCarScope(request *http.Request, car *domain.Car) error {
+	principal, err := restPr.principal(request)
+	if err != nil || principal == nil || car == nil || principal.Role != auth.RoleAgent {
+		return err
+	}
+	if !principal.AtBranch(car.BranchIDs...) {
+		return errors.Wrapf(auth.ErrForbidden, "Car %d is not at branch of the caller", car.CarID)
+	}
+	return nil
+}
+
+/*
+Customer belongs to branch agent when any customer rent is picked up or returned at agent branch
+*/
+func (restPr *RestProcessor) checkCustomerScope(request *http.Request, customerID int) error {
+	principal, err := restPr.principal(request)
+	if err != nil || principal == nil || principal.Role != auth.RoleAgent {
+		return err
+	}
+	customers, err := restPr.branchCustomers(*principal)
+	if err != nil {
+		return err
+	}
+	if !customers[customerID] {
+		return errors.Wrapf(auth.ErrForbidden, "Customer %d has no rents at branch of the caller", customerID)
+	}
+	return nil
+}
+
+/*
+Leave out customers who do not rent at branches of branch agent
+*/
+func (restPr *RestProcessor) customersInScope(request *http.Request, customers []domain.Customer) ([]domain.Customer, error) {
+	principal, err := restPr.principal(request)
+	if err != nil || principal == nil || principal.Role != auth.RoleAgent {
+		return customers, err
+	}
+	branchCustomers, err := restPr.branchCustomers(*principal)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]domain.Customer, 0, len(customers))
+	for _, customer := range customers {
+		if branchCustomers[customer.CustomerID] {
+			filtered = append(filtered, customer)
+		}
+	}
+	return filtered, nil
+}
+
+/*
+IDs of customers with rents picked up or returned at branches of the agent
+*/
+func (restPr *RestProcessor) branchCustomers(principal auth.Principal) (map[int]bool, error) {
+	rents, err := restPr.repos.Rents.GetRents()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to check customer branch")
+	}
+	customers := make(map[int]bool)
+	for _, rent := range rents {
+		if rent.CustomerID != 0 && principal.AtBranch(rent.BranchID, rent.ReturnBranchID) {
+			customers[rent.CustomerID] = true
+		}
+	}
+	return customers, nil
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/quotes"
+	"car-rental/internal/server/validation"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+/*
+Method responsible for pricing rent without booking it
+*/
+func (restPr *RestProcessor) createQuote(writer http.ResponseWriter, request *http.Request) {
+	carProcessor := cmds.NewCarProcessorWithRules(restPr.repos, restPr.cfg.Availability)
+	rentProcessor := cmds.NewRentProcessorWithRules(restPr.repos, restPr.cfg.Availability, restPr.cfg.Pricing, restPr.cfg.Cancellation, restPr.cfg.Licence).WithLogger(domain.Logger(request))
+
+	responseCode := http.StatusCreated
+	var responseMessage interface{}
+	var err error
+
+	var rent domain.RentInfo
+	err = parseBodyToObj(request, &rent)
+	if err != nil {
+		responseCode = http.StatusBadRequest
+	} else {
+		quoteProcessing := func() {
+			restPr.carMutex.RLock()
+			defer restPr.carMutex.RUnlock()
+			var car *domain.Car
+			car, err = findRentCar(carProcessor, rent)
+			if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to quote rent"
+				return
+			}
+			var pricedRent *domain.RentInfo
+			var violations validation.Violations
+			pricedRent, violations, err = rentProcessor.QuoteRent(rent, car)
+			if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to quote rent"
+				return
+			}
+			if len(violations) > 0 {
+				responseCode, responseMessage, _ = validationResponse(violations)
+				return
+			}
+			responseMessage, err = restPr.quotes.Issue(*pricedRent)
+			if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to quote rent"
+			}
+		}
+		quoteProcessing()
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for listing quote which is still valid
+*/
+func (restPr *RestProcessor) quoteDetails(writer http.ResponseWriter, request *http.Request) {
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+
+	responseMessage, err = restPr.quotes.Get(mux.Vars(request)[domain.QuoteIDPathParam])
+	if errors.Is(err, quotes.ErrNotFound) {
+		responseCode = http.StatusNotFound
+		responseMessage = "Quote not found"
+	} else if errors.Is(err, quotes.ErrExpired) {
+		responseCode = http.StatusGone
+		responseMessage = "Quote is expired"
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
@@ -0,0 +1,90 @@
+package rest
+
+import (
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Method responsible for moving bookings of unavailable car to equivalent cars, nothing is moved by dry run
+*/
+func (restPr *RestProcessor) carReassignments(writer http.ResponseWriter, request *http.Request) {
+	rentProcessor := cmds.NewRentProcessorWithRules(restPr.repos, restPr.cfg.Availability, restPr.cfg.Pricing, restPr.cfg.Cancellation, restPr.cfg.Licence).WithLogger(domain.Logger(request))
+
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var carID int
+	carID, err = extractPathID(request, domain.CarIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+	} else {
+		reassignProcessing := func() {
+			var dryRun bool
+			dryRun, err = parseBoolValue(request, domain.DryRunUrlValue)
+			if violationCode, violationMessage, ok := validationResponse(err); ok {
+				responseCode = violationCode
+				responseMessage = violationMessage
+				return
+			}
+			var body domain.RentTransition
+			body, err = parseCarState(request)
+			if err != nil {
+				responseCode = http.StatusBadRequest
+				return
+			}
+			restPr.carMutex.Lock()
+			defer restPr.carMutex.Unlock()
+			if dryRun {
+				responseMessage, err = rentProcessor.PlanReassignment(carID, body.Reason)
+			} else {
+				responseMessage, err = rentProcessor.ReassignRents(carID, body.Reason)
+			}
+			if errors.Is(err, storage.ErrNotFound) {
+				responseCode = http.StatusNotFound
+				responseMessage = "Car not found"
+			} else if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to reassign rents"
+			}
+		}
+		reassignProcessing()
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for listing cars a rent was moved between
+*/
+func (restPr *RestProcessor) rentReassignments(writer http.ResponseWriter, request *http.Request) {
+	rentProcessor := cmds.NewRentProcessor(restPr.repos).WithLogger(domain.Logger(request))
+
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var rentID int
+	rentID, err = extractPathID(request, domain.RentIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+	} else {
+		responseMessage, err = rentProcessor.GetRentReassignmentsFromDB(rentID)
+		if errors.Is(err, storage.ErrNotFound) {
+			responseCode = http.StatusNotFound
+			responseMessage = "Rent not found"
+		} else if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to get rent reassignments"
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
@@ -1,21 +1,26 @@
 package rest
 
 import (
+	"bytes"
+	"car-rental/internal/server/auth"
 	"car-rental/internal/server/cmds"
 	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 
+	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 )
 
 /*
 Method responsible for rents listing and rent info creation
 */
 func (restPr *RestProcessor) rents(writer http.ResponseWriter, request *http.Request) {
-	carProcessor := cmds.NewCarProcessor(restPr.dbStruct)
-	rentProcessor := cmds.NewRentProcessor(restPr.dbStruct)
+	carProcessor := cmds.NewCarProcessorWithRules(restPr.repos, restPr.cfg.Availability)
+	rentProcessor := cmds.NewRentProcessorWithRules(restPr.repos, restPr.cfg.Availability, restPr.cfg.Pricing, restPr.cfg.Cancellation, restPr.cfg.Licence).WithLogger(domain.Logger(request))
 
 	responseCode := http.StatusOK
 	var responseMessage interface{}
@@ -23,12 +28,21 @@ func (restPr *RestProcessor) rents(writer http.ResponseWriter, request *http.Req
 
 	switch request.Method {
 	case http.MethodGet:
-		responseMessage, err = rentProcessor.GetRentsFromDB()
+		var rents []domain.RentInfo
+		rents, err = rentProcessor.GetRentsFromDB()
+		if err == nil {
+			rents, err = restPr.rentsInScope(request, rents)
+		}
+		if errors.Is(err, auth.ErrForbidden) {
+			responseCode = http.StatusForbidden
+			responseMessage = http.StatusText(http.StatusForbidden)
+		} else if err == nil {
+			responseMessage = rents
+		}
 	case http.MethodPost:
 		var rent domain.RentInfo
 		err = parseBodyToObj(request, &rent)
 		if err != nil {
-			log.Error(err)
 			responseCode = http.StatusBadRequest
 			break
 		}
@@ -36,16 +50,27 @@ func (restPr *RestProcessor) rents(writer http.ResponseWriter, request *http.Req
 			restPr.carMutex.Lock()
 			defer restPr.carMutex.Unlock()
 			var car *domain.Car
-			car, err = carProcessor.GetCarFromDB(rent.CarID)
+			car, err = findRentCar(carProcessor, rent)
 			if err != nil {
-				log.Error(err)
-				responseCode = http.StatusBadRequest
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to insert rent info"
+				return
+			}
+			if err = restPr.checkCarScope(request, car); err != nil {
+				responseCode = http.StatusForbidden
+				responseMessage = http.StatusText(http.StatusForbidden)
 				return
 			}
 			var id int64
-			id, err = rentProcessor.InsertRentInDB(rent, *car)
-			if err != nil {
-				log.Error(err)
+			id, err = rentProcessor.InsertRentInDB(rent, car)
+			var notAvailableErr *cmds.CarNotAvailableError
+			if violationCode, violationMessage, ok := validationResponse(err); ok {
+				responseCode = violationCode
+				responseMessage = violationMessage
+			} else if errors.As(err, &notAvailableErr) {
+				responseCode = http.StatusConflict
+				responseMessage = rentConflict("Car is not available in such dates", notAvailableErr)
+			} else if err != nil {
 				responseCode = http.StatusConflict
 				responseMessage = "Failed to insert rent info"
 			} else {
@@ -57,15 +82,16 @@ func (restPr *RestProcessor) rents(writer http.ResponseWriter, request *http.Req
 	}
 
 	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
-		log.Error(errors.Wrap(err, "Error occurred during writing response"))
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
 	}
 }
 
 /*
-Method responsible for rent listing and rent deletion
+Method responsible for rent listing, rent modification and rent cancellation
 */
 func (restPr *RestProcessor) rentDetails(writer http.ResponseWriter, request *http.Request) {
-	rentProcessor := cmds.NewRentProcessor(restPr.dbStruct)
+	carProcessor := cmds.NewCarProcessorWithRules(restPr.repos, restPr.cfg.Availability)
+	rentProcessor := cmds.NewRentProcessorWithRules(restPr.repos, restPr.cfg.Availability, restPr.cfg.Pricing, restPr.cfg.Cancellation, restPr.cfg.Licence).WithLogger(domain.Logger(request))
 
 	responseCode := http.StatusOK
 	var responseMessage interface{}
@@ -74,7 +100,6 @@ func (restPr *RestProcessor) rentDetails(writer http.ResponseWriter, request *ht
 	skipProcessing := false
 	rentID, err = extractPathID(request, domain.RentIDPathParam)
 	if err != nil {
-		log.Error(err)
 		responseCode = http.StatusNotFound
 		skipProcessing = true
 	}
@@ -83,15 +108,93 @@ func (restPr *RestProcessor) rentDetails(writer http.ResponseWriter, request *ht
 		case http.MethodGet:
 			responseMessage, err = rentProcessor.GetRentFromDB(rentID)
 
+		case http.MethodPut, http.MethodPatch:
+			updateRentProcessing := func() {
+				restPr.carMutex.Lock()
+				defer restPr.carMutex.Unlock()
+				var rent domain.RentInfo
+				if request.Method == http.MethodPatch {
+					var storedRent *domain.RentInfo
+					storedRent, err = rentProcessor.GetRentFromDB(rentID)
+					if errors.Is(err, storage.ErrNotFound) {
+						responseCode = http.StatusNotFound
+						responseMessage = "Rent not found"
+						return
+					}
+					if err != nil {
+						responseCode = http.StatusInternalServerError
+						responseMessage = "Failed to update rent"
+						return
+					}
+					rent, err = parseRentPatch(request, *storedRent)
+				} else {
+					err = parseBodyToObj(request, &rent)
+				}
+				if err != nil {
+					responseCode = http.StatusBadRequest
+					return
+				}
+				var car *domain.Car
+				car, err = findRentCar(carProcessor, rent)
+				if err != nil {
+					responseCode = http.StatusInternalServerError
+					responseMessage = "Failed to update rent"
+					return
+				}
+				if err = restPr.checkCarScope(request, car); err != nil {
+					responseCode = http.StatusForbidden
+					responseMessage = http.StatusText(http.StatusForbidden)
+					return
+				}
+				var affected int64
+				affected, err = rentProcessor.UpdateRentInDB(rent, rentID, car)
+				var notAvailableErr *cmds.CarNotAvailableError
+				var statusErr *cmds.RentStatusError
+				if violationCode, violationMessage, ok := validationResponse(err); ok {
+					responseCode = violationCode
+					responseMessage = violationMessage
+				} else if errors.As(err, &notAvailableErr) {
+					responseCode = http.StatusConflict
+					responseMessage = rentConflict("Car is not available in such dates", notAvailableErr)
+				} else if errors.As(err, &statusErr) {
+					responseCode = http.StatusConflict
+					responseMessage = statusErr.Error()
+				} else if err != nil {
+					responseCode = http.StatusInternalServerError
+					responseMessage = "Failed to update rent"
+				} else if affected == 0 {
+					responseCode = http.StatusNotFound
+					responseMessage = "Rent not found"
+				} else {
+					responseMessage, err = rentProcessor.GetRentFromDB(rentID)
+				}
+			}
+			updateRentProcessing()
+
 		case http.MethodDelete:
-			_, err = rentProcessor.RemoveRentFromDB(rentID)
-			if err != nil {
-				log.Error(err)
-				responseCode = http.StatusInternalServerError
-				responseMessage = "Failed to remove rent"
-			} else {
-				responseMessage = "Rent sussesfully removed"
+			cancelRentProcessing := func() {
+				var carState domain.RentTransition
+				carState, err = parseCarState(request)
+				if err != nil {
+					responseCode = http.StatusBadRequest
+					return
+				}
+				restPr.carMutex.Lock()
+				defer restPr.carMutex.Unlock()
+				responseMessage, err = rentProcessor.CancelRent(rentID, carState.Reason)
+				var statusErr *cmds.RentStatusError
+				if errors.As(err, &statusErr) {
+					responseCode = http.StatusConflict
+					responseMessage = statusErr.Error()
+				} else if errors.Is(err, storage.ErrNotFound) {
+					responseCode = http.StatusNotFound
+					responseMessage = "Rent not found"
+				} else if err != nil {
+					responseCode = http.StatusInternalServerError
+					responseMessage = "Failed to cancel rent"
+				}
 			}
+			cancelRentProcessing()
 
 		default:
 			responseCode = http.StatusBadRequest
@@ -100,6 +203,149 @@ func (restPr *RestProcessor) rentDetails(writer http.ResponseWriter, request *ht
 	}
 
 	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
-		log.Error(errors.Wrap(err, "Error occurred during writing response"))
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for rent status transitions, car state is recorded together with the transition
+*/
+func (restPr *RestProcessor) rentTransitions(writer http.ResponseWriter, request *http.Request) {
+	rentProcessor := cmds.NewRentProcessorWithRules(restPr.repos, restPr.cfg.Availability, restPr.cfg.Pricing, restPr.cfg.Cancellation, restPr.cfg.Licence).WithLogger(domain.Logger(request))
+
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var rentID int
+	rentID, err = extractPathID(request, domain.RentIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+	} else {
+		transitRentProcessing := func() {
+			var carState domain.RentTransition
+			carState, err = parseCarState(request)
+			if err != nil {
+				responseCode = http.StatusBadRequest
+				return
+			}
+			restPr.carMutex.Lock()
+			defer restPr.carMutex.Unlock()
+			responseMessage, err = rentProcessor.TransitRent(rentID, mux.Vars(request)[domain.RentActionPathParam], carState)
+			var statusErr *cmds.RentStatusError
+			if violationCode, violationMessage, ok := validationResponse(err); ok {
+				responseCode = violationCode
+				responseMessage = violationMessage
+			} else if errors.As(err, &statusErr) {
+				responseCode = http.StatusConflict
+				responseMessage = statusErr.Error()
+			} else if errors.Is(err, storage.ErrNotFound) {
+				responseCode = http.StatusNotFound
+				responseMessage = "Rent not found"
+			} else if err != nil {
+				responseCode = http.StatusInternalServerError
+				responseMessage = "Failed to change rent status"
+			}
+		}
+		transitRentProcessing()
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+/*
+Method responsible for rent status history listing
+*/
+func (restPr *RestProcessor) rentHistory(writer http.ResponseWriter, request *http.Request) {
+	rentProcessor := cmds.NewRentProcessor(restPr.repos).WithLogger(domain.Logger(request))
+
+	responseCode := http.StatusOK
+	var responseMessage interface{}
+	var err error
+	var rentID int
+	rentID, err = extractPathID(request, domain.RentIDPathParam)
+	if err != nil {
+		responseCode = http.StatusNotFound
+	} else {
+		responseMessage, err = rentProcessor.GetRentTransitionsFromDB(rentID)
+		if errors.Is(err, storage.ErrNotFound) {
+			responseCode = http.StatusNotFound
+			responseMessage = "Rent not found"
+		} else if err != nil {
+			responseCode = http.StatusInternalServerError
+			responseMessage = "Failed to get rent history"
+		}
+	}
+
+	if _, err := domain.WriteResponse(writer, responseCode, responseMessage, err); err != nil {
+		domain.Logger(request).Error(errors.Wrap(err, "Error occurred during writing response"))
+	}
+}
+
+func rentConflict(message string, notAvailableErr *cmds.CarNotAvailableError) domain.RentConflict {
+	return domain.RentConflict{
+		Message:                message,
+		ConflictingRentIDs:     notAvailableErr.ConflictingRentIDs,
+		ConflictingBlackoutIDs: notAvailableErr.ConflictingBlackoutIDs,
+	}
+}
+
+/*
+Car state of transition is optional, so empty body is accepted
+*/
+func parseCarState(request *http.Request) (domain.RentTransition, error) {
+	var carState domain.RentTransition
+	requestBody, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return carState, errors.Wrap(err, "Incorrect body in request")
+	}
+	domain.Logger(request).Debugf("Request body [%s]", string(requestBody))
+	if len(bytes.TrimSpace(requestBody)) == 0 {
+		return carState, nil
+	}
+	if err := json.Unmarshal(requestBody, &carState); err != nil {
+		return carState, errors.Wrap(err, "Incorrect format of body")
+	}
+	return carState, nil
+}
+
+/*
+Find car of rent, nil is returned when car does not exist
+*/
+func findRentCar(carProcessor *cmds.CarProcessor, rent domain.RentInfo) (*domain.Car, error) {
+	if rent.CarID == 0 {
+		return nil, nil
+	}
+	car, err := carProcessor.GetCarFromDB(rent.CarID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	return car, err
+}
+
+/*
+Apply fields provided in PATCH body to stored rent.
+Branch is found again when only one of location and branch ID is changed
+*/
+func parseRentPatch(request *http.Request, rent domain.RentInfo) (domain.RentInfo, error) {
+	requestBody, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return rent, errors.Wrap(err, "Incorrect body in request")
+	}
+	domain.Logger(request).Debugf("Request body [%s]", string(requestBody))
+	var patch domain.RentInfo
+	if err := json.Unmarshal(requestBody, &patch); err != nil {
+		return rent, errors.Wrap(err, "Incorrect format of body")
+	}
+	if err := json.Unmarshal(requestBody, &rent); err != nil {
+		return rent, errors.Wrap(err, "Incorrect format of body")
+	}
+	if len(patch.Location) > 0 && patch.BranchID == 0 {
+		rent.BranchID = 0
+	}
+	if patch.BranchID != 0 && len(patch.Location) == 0 {
+		rent.Location = ""
 	}
+	return rent, nil
 }
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	bearerPrefix = "Bearer "
+
+	APIKeyMethod = "api_key"
+	TokenMethod  = "token"
+)
+
+var (
+	// Request has no credentials or they are not valid
+	ErrUnauthenticated = errors.New("Request is not authenticated")
+	// Credentials are valid, but they do not allow the request
+	ErrForbidden = errors.New("Request is not allowed")
+)
+
+type (
+	/*
+		Caller of the request. Back-office integration is named by its API key, customer is known by ID
+	*/
+	Principal struct {
+		Method     string
+		Name       string
+		Role       Role
+		BranchIDs  []int
+		CustomerID int
+	}
+
+	/*
+		Checks API key from X-API-Key header or customer token from Authorization header
+	*/
+	Authenticator struct {
+		keys   KeyStore
+		signer *Signer
+	}
+)
+
+func NewAuthenticator(keys KeyStore, signer *Signer) *Authenticator {
+	return &Authenticator{keys: keys, signer: signer}
+}
+
+/*
+Find caller of the request, ErrUnauthenticated is returned when credentials are missing or not valid
+*/
+func (authenticator *Authenticator) Authenticate(request *http.Request) (*Principal, error) {
+	if key := request.Header.Get(APIKeyHeader); len(key) > 0 {
+		apiKey, err := authenticator.keys.Find(key)
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{Method: APIKeyMethod, Name: apiKey.Name, Role: apiKey.Role, BranchIDs: apiKey.BranchIDs}, nil
+	}
+	authorization := request.Header.Get("Authorization")
+	if strings.HasPrefix(authorization, bearerPrefix) {
+		customerID, err := authenticator.signer.Verify(strings.TrimPrefix(authorization, bearerPrefix))
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{Method: TokenMethod, Role: RoleCustomer, CustomerID: customerID}, nil
+	}
+	return nil, errors.Wrapf(ErrUnauthenticated, "Provide %s header or bearer token", APIKeyHeader)
+}
+
+/*
+Request filter which lets through requests of every authenticated caller
+*/
+func (authenticator *Authenticator) Filter(request *http.Request) (int, error) {
+	_, err := authenticator.Authenticate(request)
+	return filterResult(err)
+}
+
+/*
+Signer of customer tokens
+*/
+func (authenticator *Authenticator) Signer() *Signer {
+	return authenticator.signer
+}
+
+func filterResult(err error) (int, error) {
+	if errors.Is(err, ErrUnauthenticated) {
+		return http.StatusUnauthorized, err
+	}
+	if errors.Is(err, ErrForbidden) {
+		return http.StatusForbidden, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+/*
+Caller name used in logs, API key name or customer ID
+*/
+func (principal Principal) User() string {
+	if principal.Method == TokenMethod {
+		return "customer:" + strconv.Itoa(principal.CustomerID)
+	}
+	return principal.Name
+}
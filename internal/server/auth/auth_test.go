@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var issuedAt = time.Date(2022, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func testSigner(secret string, now time.Time) *Signer {
+	signer := NewSigner([]byte(secret), time.Hour)
+	signer.now = func() time.Time { return now }
+	return signer
+}
+
+func TestSigner(test *testing.T) {
+	signer := testSigner("secret", issuedAt)
+	token, expiresAt, err := signer.Issue(7)
+	require.NoError(test, err)
+	assert.Equal(test, issuedAt.Add(time.Hour), expiresAt)
+	customerID, err := signer.Verify(token)
+	require.NoError(test, err)
+	assert.Equal(test, 7, customerID)
+
+	segments := strings.Split(token, ".")
+	otherToken, _, err := testSigner("secret", issuedAt).Issue(8)
+	require.NoError(test, err)
+	rejected := map[string]string{
+		"other secret":   testSigner("other", issuedAt).mustIssue(test, 7),
+		"expired":        token,
+		"swapped claims": segments[0] + "." + strings.Split(otherToken, ".")[1] + "." + segments[2],
+		"no signature":   segments[0] + "." + segments[1],
+		"not a token":    "token",
+	}
+	for name, rejectedToken := range rejected {
+		verifier := signer
+		if name == "expired" {
+			verifier = testSigner("secret", expiresAt)
+		}
+		_, err := verifier.Verify(rejectedToken)
+		assert.True(test, errors.Is(err, ErrUnauthenticated), name)
+	}
+
+	_, err = signer.Verify(signer.mustIssue(test, 0))
+	assert.True(test, errors.Is(err, ErrUnauthenticated), "Subject should be customer")
+}
+
+func (signer *Signer) mustIssue(test *testing.T, customerID int) string {
+	token, _, err := signer.Issue(customerID)
+	require.NoError(test, err)
+	return token
+}
+
+func TestLocalKeyStore(test *testing.T) {
+	_, err := NewLocalKeyStore([]APIKey{{Name: "backoffice", KeyHash: "abc"}})
+	assert.Error(test, err, "Hash should be SHA-256")
+	_, err = NewLocalKeyStore([]APIKey{{Name: "backoffice", KeyHash: HashKey("one")}, {Name: "backoffice", KeyHash: HashKey("two")}})
+	assert.Error(test, err, "Names should be unique")
+	_, err = NewLocalKeyStore([]APIKey{{Name: "haifa", KeyHash: HashKey("one"), Role: RoleAgent}})
+	assert.Error(test, err, "Agent should have branches")
+	_, err = NewLocalKeyStore([]APIKey{{Name: "customer", KeyHash: HashKey("one"), Role: RoleCustomer}})
+	assert.Error(test, err, "Customer is known by token")
+
+	store, err := NewLocalKeyStore([]APIKey{{Name: "backoffice", KeyHash: strings.ToUpper(HashKey("one"))}, {Name: "reports", KeyHash: HashKey("two")}})
+	require.NoError(test, err)
+	key, err := store.Find("two")
+	require.NoError(test, err)
+	assert.Equal(test, "reports", key.Name)
+	assert.Equal(test, RoleAdmin, key.Role, "Key without role belongs to admin")
+	_, err = store.Find("three")
+	assert.True(test, errors.Is(err, ErrUnauthenticated))
+}
+
+func TestFilters(test *testing.T) {
+	store, err := NewLocalKeyStore([]APIKey{{Name: "backoffice", KeyHash: HashKey("one")}, {Name: "haifa", KeyHash: HashKey("agent"), Role: RoleAgent, BranchIDs: []int{1}}})
+	require.NoError(test, err)
+	signer := testSigner("secret", issuedAt)
+	authenticator := NewAuthenticator(store, signer)
+	request := func(header string, value string) *http.Request {
+		request, err := http.NewRequest(http.MethodGet, "/api/cars", nil)
+		require.NoError(test, err)
+		if len(header) > 0 {
+			request.Header.Set(header, value)
+		}
+		return request
+	}
+	bearer := "Bearer " + signer.mustIssue(test, 3)
+
+	principal, err := authenticator.Authenticate(request("Authorization", bearer))
+	require.NoError(test, err)
+	assert.Equal(test, Principal{Method: TokenMethod, Role: RoleCustomer, CustomerID: 3}, *principal)
+	principal, err = authenticator.Authenticate(request(APIKeyHeader, "one"))
+	require.NoError(test, err)
+	assert.Equal(test, Principal{Method: APIKeyMethod, Name: "backoffice", Role: RoleAdmin}, *principal)
+
+	// Agent is let through only for cars of branch 1, customer is not allowed at all
+	policy := Policy{http.MethodGet: Grants{RoleAdmin: ScopeAll, RoleAgent: ScopeBranch}}
+	policyFilter := authenticator.PolicyFilter(policy, func(request *http.Request, principal Principal, scope Scope) error {
+		if scope == ScopeBranch && !principal.AtBranch(2) {
+			return errors.Wrap(ErrForbidden, "Car is at branch 2")
+		}
+		return nil
+	})
+	expected := []struct {
+		header, value  string
+		filter, policy int
+	}{
+		{"", "", http.StatusUnauthorized, http.StatusUnauthorized},
+		{APIKeyHeader, "two", http.StatusUnauthorized, http.StatusUnauthorized},
+		{APIKeyHeader, "one", http.StatusOK, http.StatusOK},
+		{APIKeyHeader, "agent", http.StatusOK, http.StatusForbidden},
+		{"Authorization", bearer, http.StatusOK, http.StatusForbidden},
+	}
+	for _, expect := range expected {
+		code, _ := authenticator.Filter(request(expect.header, expect.value))
+		assert.Equal(test, expect.filter, code, "%s %s", expect.header, expect.value)
+		code, _ = policyFilter(request(expect.header, expect.value))
+		assert.Equal(test, expect.policy, code, "%s %s", expect.header, expect.value)
+	}
+}
+
+func TestPolicy(test *testing.T) {
+	policy := Policy{
+		http.MethodGet:    Grants{RoleAdmin: ScopeAll, RoleAgent: ScopeBranch, RoleCustomer: ScopeOwn},
+		http.MethodDelete: Grants{RoleAdmin: ScopeAll},
+	}
+	assert.Equal(test, []string{http.MethodDelete, http.MethodGet}, policy.Methods())
+	expected := []struct {
+		role   Role
+		method string
+		scope  Scope
+		err    error
+	}{
+		{RoleAdmin, http.MethodDelete, ScopeAll, nil},
+		{RoleAgent, http.MethodGet, ScopeBranch, nil},
+		{RoleAgent, http.MethodDelete, ScopeAll, ErrForbidden},
+		{RoleCustomer, http.MethodGet, ScopeOwn, nil},
+		{RoleCustomer, http.MethodPost, ScopeAll, ErrForbidden},
+	}
+	for _, expect := range expected {
+		scope, err := policy.Authorize(Principal{Role: expect.role}, expect.method)
+		if expect.err != nil {
+			assert.True(test, errors.Is(err, expect.err), "%s %s", expect.role, expect.method)
+			continue
+		}
+		require.NoError(test, err)
+		assert.Equal(test, expect.scope, scope, "%s %s", expect.role, expect.method)
+	}
+
+	agent := Principal{Role: RoleAgent, BranchIDs: []int{1, 3}}
+	assert.True(test, agent.AtBranch(2, 3))
+	assert.False(test, agent.AtBranch(0, 2))
+}
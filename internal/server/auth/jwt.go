@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	tokenIssuer = "car-rental"
+	// Only HMAC SHA-256 signed tokens are issued and accepted
+	signingAlgorithm = "HS256"
+)
+
+type (
+	/*
+		Registered JWT claims used by the service, subject is customer ID
+	*/
+	Claims struct {
+		Issuer    string `json:"iss"`
+		Subject   string `json:"sub"`
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+	}
+
+	/*
+		Issues and verifies customer tokens signed with shared secret
+	*/
+	Signer struct {
+		secret []byte
+		ttl    time.Duration
+		now    func() time.Time
+	}
+
+	tokenHeader struct {
+		Algorithm string `json:"alg"`
+		Type      string `json:"typ"`
+	}
+)
+
+func NewSigner(secret []byte, ttl time.Duration) *Signer {
+	return &Signer{secret: secret, ttl: ttl, now: time.Now}
+}
+
+/*
+Issue token of the customer, returns token and its expiration time
+*/
+func (signer *Signer) Issue(customerID int) (string, time.Time, error) {
+	issuedAt := signer.now().UTC().Truncate(time.Second)
+	expiresAt := issuedAt.Add(signer.ttl)
+	header, err := encodeSegment(tokenHeader{Algorithm: signingAlgorithm, Type: "JWT"})
+	if err != nil {
+		return "", expiresAt, err
+	}
+	claims, err := encodeSegment(Claims{Issuer: tokenIssuer, Subject: strconv.Itoa(customerID), IssuedAt: issuedAt.Unix(), ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return "", expiresAt, err
+	}
+	signed := header + "." + claims
+	return signed + "." + signer.sign(signed), expiresAt, nil
+}
+
+/*
+Verify signature, algorithm, issuer and expiration of the token and return customer ID it was issued for
+*/
+func (signer *Signer) Verify(token string) (int, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return 0, errors.Wrap(ErrUnauthenticated, "Token should have three segments")
+	}
+	if !hmac.Equal([]byte(segments[2]), []byte(signer.sign(segments[0]+"."+segments[1]))) {
+		return 0, errors.Wrap(ErrUnauthenticated, "Token signature is not valid")
+	}
+	var header tokenHeader
+	if err := decodeSegment(segments[0], &header); err != nil || header.Algorithm != signingAlgorithm {
+		return 0, errors.Wrap(ErrUnauthenticated, "Token should be signed with "+signingAlgorithm)
+	}
+	var claims Claims
+	if err := decodeSegment(segments[1], &claims); err != nil {
+		return 0, errors.Wrap(ErrUnauthenticated, "Token claims are not valid")
+	}
+	if claims.Issuer != tokenIssuer {
+		return 0, errors.Wrapf(ErrUnauthenticated, "Token is issued by [%s]", claims.Issuer)
+	}
+	if !signer.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return 0, errors.Wrap(ErrUnauthenticated, "Token is expired")
+	}
+	customerID, err := strconv.Atoi(claims.Subject)
+	if err != nil || customerID <= 0 {
+		return 0, errors.Wrapf(ErrUnauthenticated, "Token subject [%s] is not a customer", claims.Subject)
+	}
+	return customerID, nil
+}
+
+func (signer *Signer) sign(signed string) string {
+	mac := hmac.New(sha256.New, signer.secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeSegment(value interface{}) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to encode token")
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.Wrap(err, "Token segment is not base64url")
+	}
+	return json.Unmarshal(decoded, value)
+}
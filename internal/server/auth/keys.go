@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	/*
+		API key of back-office integration, only SHA-256 hash of the key is stored.
+		Key without role belongs to admin, branch agent key lists branches of the agent
+	*/
+	APIKey struct {
+		Name      string `json:"name"`
+		KeyHash   string `json:"keyHash"`
+		Role      Role   `json:"role,omitempty"`
+		BranchIDs []int  `json:"branchIDs,omitempty"`
+	}
+
+	/*
+		Finds API key by its plain value, returns ErrUnauthenticated when key is not known
+	*/
+	KeyStore interface {
+		Find(key string) (*APIKey, error)
+	}
+
+	/*
+		Key store kept in memory and loaded from local file
+	*/
+	LocalKeyStore struct {
+		keys []APIKey
+	}
+)
+
+func NewLocalKeyStore(keys []APIKey) (*LocalKeyStore, error) {
+	names := make(map[string]bool)
+	for index, key := range keys {
+		if len(strings.TrimSpace(key.Name)) == 0 {
+			return nil, fmt.Errorf("API key %d should have name", index+1)
+		}
+		if names[key.Name] {
+			return nil, fmt.Errorf("API key [%s] is defined twice", key.Name)
+		}
+		names[key.Name] = true
+		if decoded, err := hex.DecodeString(key.KeyHash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("Hash of API key [%s] should be hex encoded SHA-256", key.Name)
+		}
+		keys[index].KeyHash = strings.ToLower(key.KeyHash)
+		switch key.Role {
+		case "":
+			keys[index].Role = RoleAdmin
+		case RoleAdmin:
+		case RoleAgent:
+			if len(key.BranchIDs) == 0 {
+				return nil, fmt.Errorf("Branch agent key [%s] should list branches", key.Name)
+			}
+		default:
+			return nil, fmt.Errorf("Role of API key [%s] should be %s or %s", key.Name, RoleAdmin, RoleAgent)
+		}
+	}
+	return &LocalKeyStore{keys: keys}, nil
+}
+
+/*
+Loads key store from JSON file with list of keys like [{"name": "backoffice", "keyHash": "<sha256 of the key>"}]
+*/
+func LoadKeyStore(path string) (*LocalKeyStore, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read API keys")
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(content, &keys); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse API keys")
+	}
+	return NewLocalKeyStore(keys)
+}
+
+/*
+Every stored key is compared in constant time, so time of the check does not tell which key is close
+*/
+func (store *LocalKeyStore) Find(key string) (*APIKey, error) {
+	hash := []byte(HashKey(key))
+	var found *APIKey
+	for index := range store.keys {
+		if subtle.ConstantTimeCompare(hash, []byte(store.keys[index].KeyHash)) == 1 {
+			found = &store.keys[index]
+		}
+	}
+	if found == nil {
+		return nil, errors.Wrap(ErrUnauthenticated, "API key is not known")
+	}
+	return found, nil
+}
+
+/*
+Hex encoded SHA-256 hash of API key as it is kept in key store
+*/
+func HashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"car-rental/internal/server/domain"
+	"net/http"
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Manages the whole fleet
+	RoleAdmin Role = "admin"
+	// Acts on cars and rents of own branches
+	RoleAgent Role = "agent"
+	// Reads and cancels own rents
+	RoleCustomer Role = "customer"
+)
+
+const (
+	// Every resource of the route
+	ScopeAll Scope = iota
+	// Cars and rents of branches of the caller
+	ScopeBranch
+	// Rents and profile of the customer
+	ScopeOwn
+)
+
+type (
+	Role string
+
+	principalKey struct{}
+
+	// Which resources of the route role may act on
+	Scope int
+
+	/*
+		Roles allowed to call route with some method together with their scope
+	*/
+	Grants map[Role]Scope
+
+	/*
+		Grants per HTTP method of one route, methods which are not listed are not routed
+	*/
+	Policy map[string]Grants
+)
+
+/*
+Scope of the caller for the method, ErrForbidden is returned when role of the caller is not granted
+*/
+func (policy Policy) Authorize(principal Principal, method string) (Scope, error) {
+	scope, ok := policy[method][principal.Role]
+	if !ok {
+		return scope, errors.Wrapf(ErrForbidden, "Role %s can not %s this resource", principal.Role, method)
+	}
+	return scope, nil
+}
+
+/*
+Methods of the route in stable order
+*/
+func (policy Policy) Methods() []string {
+	methods := make([]string, 0, len(policy))
+	for method := range policy {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+/*
+Request filter which checks role of the caller against the policy. Branch and ownership scopes are checked by check,
+which loads the requested resource. Allowed caller is kept in request scope for the handler
+*/
+func (authenticator *Authenticator) PolicyFilter(policy Policy, check func(request *http.Request, principal Principal, scope Scope) error) func(request *http.Request) (int, error) {
+	return func(request *http.Request) (int, error) {
+		principal, err := authenticator.Authenticate(request)
+		if err != nil {
+			return filterResult(err)
+		}
+		scope, err := policy.Authorize(*principal, request.Method)
+		if err == nil && scope != ScopeAll {
+			err = check(request, *principal, scope)
+		}
+		if err == nil {
+			domain.SetRequestValue(request, principalKey{}, principal)
+			domain.AddLogFields(request, log.Fields{"user": principal.User(), "role": principal.Role})
+		}
+		return filterResult(err)
+	}
+}
+
+/*
+True when caller works at any of the branches
+*/
+func (principal Principal) AtBranch(branchIDs ...int) bool {
+	for _, branchID := range branchIDs {
+		for _, ownBranchID := range principal.BranchIDs {
+			if branchID != 0 && branchID == ownBranchID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+Caller allowed by policy filter, nil when request was not filtered
+*/
+func RequestPrincipal(request *http.Request) *Principal {
+	principal, _ := domain.RequestValue(request, principalKey{}).(*Principal)
+	return principal
+}
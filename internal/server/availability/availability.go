@@ -0,0 +1,124 @@
+package availability
+
+import (
+	"time"
+)
+
+type (
+	/*
+		Half-open time interval [From, To)
+	*/
+	Interval struct {
+		From time.Time
+		To   time.Time
+	}
+
+	/*
+		Already existing booking of a car
+	*/
+	Booking struct {
+		RentID int
+		Interval
+	}
+
+	/*
+		Rules applied between two consecutive rentals of the same car
+	*/
+	Rules struct {
+		// Time needed to prepare the car for the next customer
+		CleaningBuffer time.Duration
+		// Allows a car to be picked up on the same calendar day it was returned
+		AllowSameDayTurnaround bool
+	}
+
+	/*
+		Booked interval conflicts with requested one when it overlaps Overlap, ends in ReturnDay or starts in PickupDay.
+		Days are set only when same day turnaround is forbidden
+	*/
+	Window struct {
+		Overlap   Interval
+		ReturnDay *Interval
+		PickupDay *Interval
+	}
+)
+
+var DefaultRules = Rules{CleaningBuffer: 0, AllowSameDayTurnaround: true}
+
+/*
+Checks that interval starts before it ends
+*/
+func (interval Interval) IsValid() bool {
+	return interval.From.Before(interval.To)
+}
+
+/*
+Checks if two half-open intervals share at least one moment
+*/
+func (interval Interval) Overlaps(other Interval) bool {
+	return interval.From.Before(other.To) && other.From.Before(interval.To)
+}
+
+/*
+Checks if requested interval conflicts with already existing booking according to the rules
+*/
+func (rules Rules) Conflicts(requested Interval, booked Interval) bool {
+	return rules.Window(requested).Conflicts(booked)
+}
+
+/*
+Window of requested interval, cleaning buffer widens requested interval instead of every booking,
+so storage can compare stored bookings with fixed bounds
+*/
+func (rules Rules) Window(requested Interval) Window {
+	window := Window{Overlap: Interval{From: requested.From.Add(-rules.CleaningBuffer), To: requested.To.Add(rules.CleaningBuffer)}}
+	if !rules.AllowSameDayTurnaround {
+		pickupDay := day(requested.From)
+		returnDay := day(requested.To)
+		// Booking returned on pickup day blocks the car, as does booking picked up on return day
+		window.ReturnDay = &pickupDay
+		window.PickupDay = &returnDay
+	}
+	return window
+}
+
+/*
+Checks if booked interval conflicts with requested interval of the window
+*/
+func (window Window) Conflicts(booked Interval) bool {
+	if booked.Overlaps(window.Overlap) {
+		return true
+	}
+	if window.ReturnDay != nil && window.ReturnDay.Contains(booked.To) {
+		return true
+	}
+	return window.PickupDay != nil && window.PickupDay.Contains(booked.From)
+}
+
+/*
+Checks if moment is in half-open interval
+*/
+func (interval Interval) Contains(moment time.Time) bool {
+	return !moment.Before(interval.From) && moment.Before(interval.To)
+}
+
+/*
+Returns rent IDs of all bookings which conflict with requested interval
+*/
+func (rules Rules) FindConflicts(requested Interval, bookings []Booking) []int {
+	var conflicts []int
+	for _, booking := range bookings {
+		if rules.Conflicts(requested, booking.Interval) {
+			conflicts = append(conflicts, booking.RentID)
+		}
+	}
+	return conflicts
+}
+
+/*
+UTC calendar day of the moment
+*/
+func day(moment time.Time) Interval {
+	year, month, date := moment.UTC().Date()
+	start := time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+	return Interval{From: start, To: start.AddDate(0, 0, 1)}
+}
@@ -0,0 +1,78 @@
+package availability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func interval(from string, to string) Interval {
+	fromTime, _ := time.Parse(time.RFC3339, from)
+	toTime, _ := time.Parse(time.RFC3339, to)
+	return Interval{From: fromTime, To: toTime}
+}
+
+/*
+Test that only overlapping bookings are reported and back to back rentals are allowed
+*/
+func TestFindConflictsHalfOpen(test *testing.T) {
+	bookings := []Booking{
+		{RentID: 1, Interval: interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z")},
+		{RentID: 2, Interval: interval("2022-01-18T10:00:00Z", "2022-01-19T10:00:00Z")},
+	}
+	testCases := []struct {
+		name      string
+		requested Interval
+		expected  []int
+	}{
+		{"same dates", interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z"), []int{1}},
+		{"inside booking", interval("2022-01-15T12:00:00Z", "2022-01-15T13:00:00Z"), []int{1}},
+		{"covers booking", interval("2022-01-14T10:00:00Z", "2022-01-17T10:00:00Z"), []int{1}},
+		{"covers both bookings", interval("2022-01-14T10:00:00Z", "2022-01-20T10:00:00Z"), []int{1, 2}},
+		{"starts when previous ends", interval("2022-01-16T10:00:00Z", "2022-01-17T10:00:00Z"), nil},
+		{"ends when next starts", interval("2022-01-17T10:00:00Z", "2022-01-18T10:00:00Z"), nil},
+		{"between bookings", interval("2022-01-16T11:00:00Z", "2022-01-17T11:00:00Z"), nil},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(test, testCase.expected, DefaultRules.FindConflicts(testCase.requested, bookings), testCase.name)
+	}
+}
+
+/*
+Test that cleaning buffer is kept on both sides of existing booking
+*/
+func TestFindConflictsCleaningBuffer(test *testing.T) {
+	rules := Rules{CleaningBuffer: 2 * time.Hour, AllowSameDayTurnaround: true}
+	bookings := []Booking{{RentID: 7, Interval: interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z")}}
+
+	assert.Equal(test, []int{7}, rules.FindConflicts(interval("2022-01-16T11:00:00Z", "2022-01-17T10:00:00Z"), bookings))
+	assert.Equal(test, []int{7}, rules.FindConflicts(interval("2022-01-14T10:00:00Z", "2022-01-15T09:00:00Z"), bookings))
+	assert.Nil(test, rules.FindConflicts(interval("2022-01-16T12:00:00Z", "2022-01-17T10:00:00Z"), bookings))
+	assert.Nil(test, rules.FindConflicts(interval("2022-01-14T10:00:00Z", "2022-01-15T08:00:00Z"), bookings))
+}
+
+/*
+Test that car returned today can not be picked up today when same day turnaround is forbidden
+*/
+func TestFindConflictsSameDayTurnaround(test *testing.T) {
+	rules := Rules{AllowSameDayTurnaround: false}
+	bookings := []Booking{{RentID: 3, Interval: interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z")}}
+
+	assert.Equal(test, []int{3}, rules.FindConflicts(interval("2022-01-16T18:00:00Z", "2022-01-17T10:00:00Z"), bookings))
+	assert.Equal(test, []int{3}, rules.FindConflicts(interval("2022-01-14T10:00:00Z", "2022-01-15T08:00:00Z"), bookings))
+	assert.Nil(test, rules.FindConflicts(interval("2022-01-17T00:00:00Z", "2022-01-18T10:00:00Z"), bookings))
+	assert.Nil(test, DefaultRules.FindConflicts(interval("2022-01-16T18:00:00Z", "2022-01-17T10:00:00Z"), bookings))
+}
+
+/*
+Test that window bounds are widened by cleaning buffer and cover whole UTC days of pickup and return
+*/
+func TestWindow(test *testing.T) {
+	rules := Rules{CleaningBuffer: 2 * time.Hour, AllowSameDayTurnaround: false}
+	window := rules.Window(interval("2022-01-16T18:00:00Z", "2022-01-17T10:00:00Z"))
+	assert.Equal(test, interval("2022-01-16T16:00:00Z", "2022-01-17T12:00:00Z"), window.Overlap)
+	assert.Equal(test, interval("2022-01-16T00:00:00Z", "2022-01-17T00:00:00Z"), *window.ReturnDay)
+	assert.Equal(test, interval("2022-01-17T00:00:00Z", "2022-01-18T00:00:00Z"), *window.PickupDay)
+	assert.Nil(test, DefaultRules.Window(interval("2022-01-16T18:00:00Z", "2022-01-17T10:00:00Z")).ReturnDay)
+}
@@ -0,0 +1,110 @@
+package cancellation
+
+import (
+	"car-rental/internal/server/domain"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rates are kept in basis points, 1% = 100
+const fullPercent int64 = 10000
+
+type (
+	/*
+		Fee charged when rent is cancelled at least Notice before pickup, rate is in basis points of rent total
+	*/
+	Tier struct {
+		Notice  time.Duration
+		FeeRate int64
+	}
+
+	/*
+		Rules used to charge cancelled rent
+	*/
+	Rules struct {
+		// Tier with the longest notice which is not longer than time left before pickup applies
+		Tiers []Tier
+		// Fee rate once pickup time has passed
+		LateFeeRate int64
+	}
+)
+
+/*
+Free until 48h before pickup, 50% after that and no refund after pickup
+*/
+var DefaultRules = Rules{
+	Tiers: []Tier{
+		{Notice: 48 * time.Hour, FeeRate: 0},
+		{Notice: 0, FeeRate: 5000},
+	},
+	LateFeeRate: fullPercent,
+}
+
+/*
+Fee and refund of paid total when rent starting at pickup is cancelled at cancelledAt
+*/
+func (rules Rules) Charge(total int64, pickup time.Time, cancelledAt time.Time) domain.Cancellation {
+	cancellation := domain.Cancellation{CancelledAt: cancelledAt.UTC().Format(domain.TimeLayout), Total: total}
+	feeRate := rules.LateFeeRate
+	cancellation.Policy = fmt.Sprintf("%s%% fee when cancelled after pickup time", formatPercent(feeRate))
+	left := pickup.Sub(cancelledAt)
+	for _, tier := range rules.sortedTiers() {
+		if left >= tier.Notice {
+			feeRate = tier.FeeRate
+			cancellation.Policy = fmt.Sprintf("%s%% fee when cancelled at least %s before pickup", formatPercent(feeRate), formatNotice(tier.Notice))
+			break
+		}
+	}
+	cancellation.Fee = (total*feeRate + fullPercent/2) / fullPercent
+	cancellation.Refund = total - cancellation.Fee
+	return cancellation
+}
+
+/*
+Check that rates are percentages and every notice is used once
+*/
+func (rules Rules) Validate() error {
+	if rules.LateFeeRate < 0 || rules.LateFeeRate > fullPercent {
+		return fmt.Errorf("Late cancellation fee should be between 0 and 100%%")
+	}
+	notices := make(map[time.Duration]bool)
+	for _, tier := range rules.Tiers {
+		if tier.Notice < 0 {
+			return fmt.Errorf("Cancellation notice %s should not be negative", tier.Notice)
+		}
+		if notices[tier.Notice] {
+			return fmt.Errorf("Cancellation notice %s is defined twice", tier.Notice)
+		}
+		notices[tier.Notice] = true
+		if tier.FeeRate < 0 || tier.FeeRate > fullPercent {
+			return fmt.Errorf("Cancellation fee for notice %s should be between 0 and 100%%", tier.Notice)
+		}
+	}
+	return nil
+}
+
+/*
+Tiers ordered from the longest notice
+*/
+func (rules Rules) sortedTiers() []Tier {
+	tiers := append([]Tier{}, rules.Tiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Notice > tiers[j].Notice })
+	return tiers
+}
+
+func formatNotice(notice time.Duration) string {
+	if notice%time.Hour == 0 {
+		return fmt.Sprintf("%dh", notice/time.Hour)
+	}
+	return notice.String()
+}
+
+func formatPercent(basisPoints int64) string {
+	if basisPoints%100 == 0 {
+		return strconv.FormatInt(basisPoints/100, 10)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%02d", basisPoints/100, basisPoints%100), "0")
+}
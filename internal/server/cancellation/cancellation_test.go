@@ -0,0 +1,47 @@
+package cancellation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharge(test *testing.T) {
+	pickup := time.Date(2022, 1, 15, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name           string
+		cancelledAt    time.Time
+		expectedFee    int64
+		expectedPolicy string
+	}{
+		{"week before pickup", pickup.Add(-7 * 24 * time.Hour), 0, "0% fee when cancelled at least 48h before pickup"},
+		{"exactly 48h before pickup", pickup.Add(-48 * time.Hour), 0, "0% fee when cancelled at least 48h before pickup"},
+		{"day before pickup", pickup.Add(-24 * time.Hour), 5725, "50% fee when cancelled at least 0h before pickup"},
+		{"after pickup time", pickup.Add(time.Minute), 11450, "100% fee when cancelled after pickup time"},
+	}
+	for _, testCase := range testCases {
+		cancellation := DefaultRules.Charge(11450, pickup, testCase.cancelledAt)
+		assert.Equal(test, testCase.expectedFee, cancellation.Fee, testCase.name)
+		assert.Equal(test, 11450-testCase.expectedFee, cancellation.Refund, testCase.name)
+		assert.Equal(test, testCase.expectedPolicy, cancellation.Policy, testCase.name)
+	}
+}
+
+func TestChargeUsesLongestFittingNotice(test *testing.T) {
+	rules := Rules{
+		Tiers:       []Tier{{Notice: 24 * time.Hour, FeeRate: 2550}, {Notice: 7 * 24 * time.Hour, FeeRate: 0}},
+		LateFeeRate: 8000,
+	}
+	pickup := time.Date(2022, 1, 15, 10, 0, 0, 0, time.UTC)
+	assert.Equal(test, int64(0), rules.Charge(1000, pickup, pickup.Add(-8*24*time.Hour)).Fee)
+	assert.Equal(test, int64(255), rules.Charge(1000, pickup, pickup.Add(-2*24*time.Hour)).Fee)
+	assert.Equal(test, int64(800), rules.Charge(1000, pickup, pickup.Add(-time.Hour)).Fee, "Late fee applies when no tier fits")
+}
+
+func TestValidate(test *testing.T) {
+	assert.NoError(test, DefaultRules.Validate())
+	assert.Error(test, Rules{Tiers: []Tier{{Notice: time.Hour}, {Notice: time.Hour, FeeRate: 100}}}.Validate())
+	assert.Error(test, Rules{Tiers: []Tier{{Notice: -time.Hour}}}.Validate())
+	assert.Error(test, Rules{LateFeeRate: 10100}.Validate())
+}
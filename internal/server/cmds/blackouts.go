@@ -0,0 +1,175 @@
+package cmds
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/db/query"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/fleet"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Reasons to take car out of service
+*/
+var blackoutReasons = []string{domain.ServiceBlackoutReason, domain.DamageBlackoutReason, domain.InspectionBlackoutReason}
+
+type BlackoutProcessor struct {
+	blackouts storage.BlackoutRepository
+	cars      storage.CarRepository
+	rents     storage.RentRepository
+	rules     availability.Rules
+	logger    *log.Entry
+}
+
+func NewBlackoutProcessor(repos storage.Repositories) *BlackoutProcessor {
+	return NewBlackoutProcessorWithRules(repos, availability.DefaultRules)
+}
+
+func NewBlackoutProcessorWithRules(repos storage.Repositories, rules availability.Rules) *BlackoutProcessor {
+	return &BlackoutProcessor{blackouts: repos.Blackouts, cars: repos.Cars, rents: repos.Rents, rules: rules, logger: log.NewEntry(log.StandardLogger())}
+}
+
+/*
+Log with given logger, e.g. the one of the request which is processed
+*/
+func (blackoutPr *BlackoutProcessor) WithLogger(logger *log.Entry) *BlackoutProcessor {
+	blackoutPr.logger = logger
+	return blackoutPr
+}
+
+/*
+Insert blackout of the car into DB, blackout may not overlap rents of the car.
+Returns storage.ErrNotFound when car does not exist
+*/
+func (blackoutPr *BlackoutProcessor) InsertBlackoutInDB(blackout domain.Blackout, carID int) (int64, error) {
+	if _, err := blackoutPr.cars.GetCar(carID); err != nil {
+		return 0, err
+	}
+	blackout.CarID = carID
+	blackout, err := blackoutPr.checkBlackout(blackout)
+	if err != nil {
+		return 0, err
+	}
+	return blackoutPr.blackouts.InsertBlackout(blackout)
+}
+
+/*
+Get blackouts of the car ordered by from date, storage.ErrNotFound is returned when car does not exist
+*/
+func (blackoutPr *BlackoutProcessor) GetCarBlackoutsFromDB(carID int) ([]domain.Blackout, error) {
+	if _, err := blackoutPr.cars.GetCar(carID); err != nil {
+		return nil, err
+	}
+	return blackoutPr.blackouts.GetCarBlackouts(carID)
+}
+
+/*
+Get blackout of the car, blackout of another car is not found
+*/
+func (blackoutPr *BlackoutProcessor) GetBlackoutFromDB(carID int, blackoutID int) (*domain.Blackout, error) {
+	blackout, err := blackoutPr.blackouts.GetBlackout(blackoutID)
+	if err != nil {
+		return nil, err
+	}
+	if blackout.CarID != carID {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Blackout %d of car %d", blackoutID, carID)
+	}
+	return blackout, nil
+}
+
+/*
+Replace dates, reason and description of blackout, changed blackout may not overlap rents of the car.
+Transfer can not be changed, it can only be removed
+*/
+func (blackoutPr *BlackoutProcessor) UpdateBlackoutInDB(blackout domain.Blackout, carID int, blackoutID int) (int64, error) {
+	stored, err := blackoutPr.GetBlackoutFromDB(carID, blackoutID)
+	if err != nil {
+		return 0, err
+	}
+	if fleet.IsTransfer(*stored) {
+		return 0, validation.Violations{*validation.New("reason", validation.NotAllowed, "Transfer can not be changed, remove it instead")}
+	}
+	blackout.CarID = carID
+	blackout, err = blackoutPr.checkBlackout(blackout)
+	if err != nil {
+		return 0, err
+	}
+	return blackoutPr.blackouts.UpdateBlackout(blackout, blackoutID)
+}
+
+/*
+Remove blackout of the car from DB. Transfer is not removed while rents which are not cancelled start after it,
+they pick the car up where transfer leaves it
+*/
+func (blackoutPr *BlackoutProcessor) RemoveBlackoutFromDB(carID int, blackoutID int) (int64, error) {
+	stored, err := blackoutPr.GetBlackoutFromDB(carID, blackoutID)
+	if err != nil {
+		return 0, err
+	}
+	if fleet.IsTransfer(*stored) {
+		carRents, err := blackoutPr.rents.GetCarRents(carID)
+		if err != nil {
+			return 0, errors.Wrap(err, "Failed to get car rents")
+		}
+		var later []domain.RentInfo
+		for _, rent := range carRents {
+			if rent.Status != domain.CancelledRentStatus && rent.FromDate >= stored.ToDate {
+				later = append(later, rent)
+			}
+		}
+		if len(later) > 0 {
+			return 0, &CarInUseError{CarID: carID, Rents: later}
+		}
+	}
+	return blackoutPr.blackouts.RemoveBlackout(blackoutID)
+}
+
+/*
+Check every blackout field, then check that blackout does not take the car from existing rents.
+Returns blackout with dates in UTC
+*/
+func (blackoutPr *BlackoutProcessor) checkBlackout(blackout domain.Blackout) (domain.Blackout, error) {
+	var violations validation.Violations
+	var violation *validation.Violation
+	dates, err := query.ParseDateRange(domain.FromDateUrlValue, blackout.FromDate, domain.ToDateUrlValue, blackout.ToDate)
+	if errors.As(err, &violation) {
+		violations = append(violations, *violation)
+	} else if err == nil {
+		blackout.FromDate = dates.From.Format(domain.TimeLayout)
+		blackout.ToDate = dates.To.Format(domain.TimeLayout)
+	}
+	if len(blackout.ToLocation) > 0 || blackout.ToBranchID != 0 {
+		violations = append(violations, *validation.New("toLocation", validation.NotAllowed, "Transfers are planned by fleet rebalancing"))
+	}
+	if len(blackout.Reason) == 0 {
+		violations = append(violations, *validation.New("reason", validation.Required, "Blackout reason should be provided"))
+	} else if !isBlackoutReason(blackout.Reason) {
+		violations = append(violations, *validation.New("reason", validation.NotAllowed, "[%s] should be one of %s", blackout.Reason, strings.Join(blackoutReasons, ", ")))
+	}
+	if len(violations) > 0 {
+		return blackout, violations
+	}
+	carRents, err := blackoutPr.rents.GetCarRents(blackout.CarID)
+	if err != nil {
+		return blackout, errors.Wrap(err, "Failed to get car rents")
+	}
+	requested := availability.Interval{From: dates.From, To: dates.To}
+	if conflicts := blackoutPr.rules.FindConflicts(requested, rentBookings(blackoutPr.logger, carRents, 0)); len(conflicts) > 0 {
+		return blackout, &CarNotAvailableError{CarID: blackout.CarID, ConflictingRentIDs: conflicts}
+	}
+	return blackout, nil
+}
+
+func isBlackoutReason(reason string) bool {
+	for _, allowed := range blackoutReasons {
+		if reason == allowed {
+			return true
+		}
+	}
+	return false
+}
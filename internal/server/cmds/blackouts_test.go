@@ -0,0 +1,68 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Test that blackout holds the car the same way as rent does
+*/
+func TestBlackoutHoldsCarLikeRent(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	otherCar := insertTestCar(test, repos)
+	blackoutProcessor := NewBlackoutProcessor(repos)
+	rentProcessor := NewRentProcessor(repos)
+
+	_, err := blackoutProcessor.InsertBlackoutInDB(domain.Blackout{FromDate: "2022-01-15T08:00:00Z", ToDate: "2022-01-14T08:00:00Z", Reason: "holiday"}, car.CarID)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"fromDate:out_of_range", "reason:not_allowed"}, violationCodes(violations))
+	_, err = blackoutProcessor.InsertBlackoutInDB(domain.Blackout{FromDate: "2022-01-15T08:00:00Z", ToDate: "2022-01-16T08:00:00Z", Reason: domain.ServiceBlackoutReason}, 1000)
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+
+	blackoutID, err := blackoutProcessor.InsertBlackoutInDB(domain.Blackout{
+		FromDate: "2022-01-15T08:00:00Z",
+		ToDate:   "2022-01-15T18:00:00Z",
+		Reason:   domain.InspectionBlackoutReason,
+	}, car.CarID)
+	require.NoError(test, err)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	_, err = rentProcessor.InsertRentInDB(rent, &car)
+	var notAvailableErr *CarNotAvailableError
+	require.True(test, errors.As(err, &notAvailableErr))
+	assert.Empty(test, notAvailableErr.ConflictingRentIDs)
+	assert.Equal(test, []int{int(blackoutID)}, notAvailableErr.ConflictingBlackoutIDs)
+	_, violations, err = rentProcessor.QuoteRent(rent, &car)
+	require.NoError(test, err)
+	assert.Equal(test, []string{"fromDate:not_available"}, violationCodes(violations))
+
+	rent.CarID = otherCar.CarID
+	rentID, err := rentProcessor.InsertRentInDB(rent, &otherCar)
+	require.NoError(test, err, "Blackout holds only its car")
+	_, err = blackoutProcessor.InsertBlackoutInDB(domain.Blackout{
+		FromDate: "2022-01-16T08:00:00Z",
+		ToDate:   "2022-01-17T08:00:00Z",
+		Reason:   domain.DamageBlackoutReason,
+	}, otherCar.CarID)
+	require.True(test, errors.As(err, &notAvailableErr), "Blackout does not take the car from rent")
+	assert.Equal(test, []int{int(rentID)}, notAvailableErr.ConflictingRentIDs)
+
+	_, err = blackoutProcessor.GetBlackoutFromDB(otherCar.CarID, int(blackoutID))
+	assert.True(test, errors.Is(err, storage.ErrNotFound), "Blackout of another car is not found")
+	affected, err := blackoutProcessor.RemoveBlackoutFromDB(car.CarID, int(blackoutID))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	rent.CarID = car.CarID
+	_, err = rentProcessor.InsertRentInDB(rent, &car)
+	assert.NoError(test, err)
+}
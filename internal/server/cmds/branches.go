@@ -0,0 +1,123 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/pkg/errors"
+)
+
+type BranchProcessor struct {
+	branches storage.BranchRepository
+}
+
+func NewBranchProcessor(repos storage.Repositories) *BranchProcessor {
+	return &BranchProcessor{branches: repos.Branches}
+}
+
+/*
+Insert branch into DB
+*/
+func (branchPr *BranchProcessor) InsertBranchInDB(branch domain.Branch) (int64, error) {
+	if err := branchPr.validateBranch(branch, 0); err != nil {
+		return 0, err
+	}
+	return branchPr.branches.InsertBranch(branch)
+}
+
+/*
+Get branches from DB
+*/
+func (branchPr *BranchProcessor) GetBranchesFromDB() ([]domain.Branch, error) {
+	return branchPr.branches.GetBranches()
+}
+
+/*
+Get branch from DB upon branch ID
+*/
+func (branchPr *BranchProcessor) GetBranchFromDB(branchID int) (*domain.Branch, error) {
+	return branchPr.branches.GetBranch(branchID)
+}
+
+/*
+Update branch in DB
+*/
+func (branchPr *BranchProcessor) UpdateBranchInDB(branch domain.Branch, branchID int) (int64, error) {
+	if err := branchPr.validateBranch(branch, branchID); err != nil {
+		return 0, err
+	}
+	return branchPr.branches.UpdateBranch(branch, branchID)
+}
+
+/*
+Remove branch from DB, branch which has rents is not removed
+*/
+func (branchPr *BranchProcessor) RemoveBranchFromDB(branchID int) (int64, error) {
+	return branchPr.branches.RemoveBranch(branchID)
+}
+
+/*
+Check branch fields, name should not be used by other branch
+*/
+func (branchPr *BranchProcessor) validateBranch(branch domain.Branch, branchID int) error {
+	if len(strings.TrimSpace(branch.Name)) == 0 {
+		return validation.New("name", validation.Required, "Branch name should be provided")
+	}
+	existing, err := branchPr.branches.GetBranchByName(branch.Name)
+	if err == nil && existing.BranchID != branchID {
+		return validation.New("name", validation.Duplicate, "Branch with name [%s] already exists", branch.Name)
+	}
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return errors.Wrap(err, "Failed to check branch name")
+	}
+	if branch.Latitude < -90 || branch.Latitude > 90 {
+		return validation.New("latitude", validation.OutOfRange, "[%v] should be between -90 and 90", branch.Latitude)
+	}
+	if branch.Longitude < -180 || branch.Longitude > 180 {
+		return validation.New("longitude", validation.OutOfRange, "[%v] should be between -180 and 180", branch.Longitude)
+	}
+	if len(branch.Timezone) == 0 {
+		return validation.New("timezone", validation.Required, "Branch timezone should be provided")
+	}
+	if _, err := time.LoadLocation(branch.Timezone); err != nil {
+		return validation.New("timezone", validation.NotAllowed, "[%s] is not a known timezone", branch.Timezone)
+	}
+	for _, hours := range branch.OpeningHours {
+		if err := validateOpeningHours(hours); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Check that day is a week day name and branch opens before it closes
+*/
+func validateOpeningHours(hours domain.OpeningHours) error {
+	knownDay := false
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if day.String() == hours.Day {
+			knownDay = true
+			break
+		}
+	}
+	if !knownDay {
+		return validation.New("openingHours", validation.NotAllowed, "[%s] is not a week day", hours.Day)
+	}
+	open, err := time.Parse(domain.OpeningHoursLayout, hours.Open)
+	if err != nil {
+		return validation.New("openingHours", validation.InvalidFormat, "[%s] should be in format %s", hours.Open, domain.OpeningHoursLayout)
+	}
+	close, err := time.Parse(domain.OpeningHoursLayout, hours.Close)
+	if err != nil {
+		return validation.New("openingHours", validation.InvalidFormat, "[%s] should be in format %s", hours.Close, domain.OpeningHoursLayout)
+	}
+	if !open.Before(close) {
+		return validation.New("openingHours", validation.OutOfRange, "%s opening time should be less than closing time", hours.Day)
+	}
+	return nil
+}
@@ -1,163 +1,127 @@
 package cmds
 
 import (
-	"car-rental/internal/server/db"
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/db/query"
 	"car-rental/internal/server/domain"
-	"database/sql"
-	"fmt"
+	"car-rental/internal/server/fleet"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
+	"sort"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/pkg/errors"
 )
 
 type CarProcessor struct {
-	dbStruct *db.DBStruct
+	cars      storage.CarRepository
+	rents     storage.RentRepository
+	blackouts storage.BlackoutRepository
+	branches  storage.BranchRepository
+	rules     availability.Rules
+}
+
+func NewCarProcessor(repos storage.Repositories) *CarProcessor {
+	return NewCarProcessorWithRules(repos, availability.DefaultRules)
 }
 
-func NewCarProcessor(dbStruct *db.DBStruct) *CarProcessor {
-	return &CarProcessor{dbStruct: dbStruct}
+/*
+Car search leaves out cars which can not be booked at requested dates by availability rules
+*/
+func NewCarProcessorWithRules(repos storage.Repositories, rules availability.Rules) *CarProcessor {
+	return &CarProcessor{cars: repos.Cars, rents: repos.Rents, blackouts: repos.Blackouts, branches: repos.Branches, rules: rules}
 }
 
 /*
 Insert car into DB
 */
 func (carPr *CarProcessor) InsertCarInDB(car domain.Car) (int64, error) {
-	tx, err := carPr.dbStruct.BeginTransaction()
+	car, err := carPr.validateCar(car)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to start a transaction")
+		return 0, err
 	}
-	statement, err := tx.Prepare(db.InsertIntoCarTable)
+	return carPr.cars.InsertCar(car)
+}
+
+/*
+Get cars from DB
+*/
+func (carPr *CarProcessor) GetCarsFromDB() ([]domain.Car, error) {
+	return carPr.cars.GetCars()
+}
+
+/*
+Get filtered cars from DB. When dates are provided car is found in location where it will be at from date
+*/
+func (carPr *CarProcessor) GetCarsFromDBWithParams(values map[string][]string) ([]domain.CombinedRentInfo, error) {
+	filter, err := carPr.extractURLValues(values)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to prepare stmt")
+		return nil, err
 	}
-	res, err := statement.Exec(car.CarCompanyName,
-		car.Doors,
-		car.BigLuggage,
-		car.SmallLuggage,
-		car.AdultPlaces,
-		car.AirConditioner,
-		car.MinimumAge,
-		strings.Join(car.AvailableLocations, ","),
-		car.CarGroup,
-		car.Description,
-		car.Price)
-	if err != nil {
-		return 0, errors.Wrap(err, "Failed to execute a prepared statement")
+	filter.Rules = &carPr.rules
+	if filter.Dates == nil || len(filter.Locations) == 0 {
+		return carPr.cars.SearchCars(filter)
 	}
-	id, err := res.LastInsertId()
+	locations := filter.Locations
+	filter.Locations = nil
+	found, err := carPr.cars.SearchCars(filter)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to execute a extract last id")
-	}
-	if err := tx.Commit(); err != nil {
-		return 0, errors.Wrap(err, "Failed to commit a transaction")
+		return nil, err
 	}
-
-	return id, nil
+	return carPr.filterCarsByPosition(found, *filter.Dates, locations)
 }
 
 /*
-Get cars from DB
+Get position of the car after every rent and transfer, storage.ErrNotFound is returned when car does not exist
 */
-func (carPr *CarProcessor) GetCarsFromDB() ([]domain.Car, error) {
-	rows, err := carPr.dbStruct.Query(db.SelectCars)
+func (carPr *CarProcessor) GetCarPositionsFromDB(carID int) ([]domain.CarPosition, error) {
+	car, err := carPr.cars.GetCar(carID)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to execute a sql query")
+		return nil, err
 	}
-
-	var result []domain.Car
-	for rows.Next() {
-		var receivedRow domain.Car
-		var locations string
-		err = rows.Scan(&receivedRow.CarID, &receivedRow.CarCompanyName,
-			&receivedRow.Doors,
-			&receivedRow.BigLuggage,
-			&receivedRow.SmallLuggage,
-			&receivedRow.AdultPlaces,
-			&receivedRow.AirConditioner,
-			&receivedRow.MinimumAge,
-			&locations,
-			&receivedRow.CarGroup,
-			&receivedRow.Description,
-			&receivedRow.Price)
-		if err != nil {
-			log.Error(err)
-			continue
-		}
-		receivedRow.AvailableLocations = strings.Split(locations, ",")
-
-		result = append(result, receivedRow)
+	carRents, err := carPr.rents.GetCarRents(carID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get car rents")
 	}
-	return result, nil
+	blackouts, err := carPr.blackouts.GetCarBlackouts(carID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get car blackouts")
+	}
+	return fleet.NewTimeline(*car, carRents, blackouts).Positions(), nil
 }
 
 /*
-Get filtered cars from DB
+Keep cars which can be picked up in one of locations at from date and returned there for the next rent
 */
-func (carPr *CarProcessor) GetCarsFromDBWithParams(values map[string][]string) ([]domain.CombinedRentInfo, error) {
-	searchParams := carPr.extractURLValues(values)
-	query := db.SelectCarsRents
-
-	if len(searchParams.DefaultFilter) > 0 {
-		query += " WHERE " + searchParams.DefaultFilter
+func (carPr *CarProcessor) filterCarsByPosition(found []domain.CombinedRentInfo, dates query.DateRange, locations []string) ([]domain.CombinedRentInfo, error) {
+	rents, err := carPr.rents.GetRents()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get rents")
 	}
-	if len(searchParams.DefaultFilter) == 0 && len(searchParams.DateFilter) > 0 {
-		query += " WHERE " + searchParams.DateFilter
-	} else if len(searchParams.DefaultFilter) > 0 && len(searchParams.DateFilter) > 0 {
-		query += " and " + searchParams.DateFilter
+	carRents := make(map[int][]domain.RentInfo)
+	for _, rent := range rents {
+		carRents[rent.CarID] = append(carRents[rent.CarID], rent)
 	}
-	log.Debugln(query)
-	rows, err := carPr.dbStruct.Query(query)
+	blackouts, err := carPr.blackouts.GetBlackouts()
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to execute a sql query")
+		return nil, errors.Wrap(err, "Failed to get blackouts")
+	}
+	carBlackouts := make(map[int][]domain.Blackout)
+	for _, blackout := range blackouts {
+		carBlackouts[blackout.CarID] = append(carBlackouts[blackout.CarID], blackout)
+	}
+	requested := make(map[string]bool)
+	for _, location := range locations {
+		requested[location] = true
 	}
-
 	var result []domain.CombinedRentInfo
-	for rows.Next() {
-		var receivedRow domain.CombinedRentInfo
-		var locations string
-		var rentID sql.NullInt32
-		var fromDate sql.NullString
-		var toDate sql.NullString
-		var location sql.NullString
-		var extras sql.NullString
-		var discounts sql.NullString
-		var carDetails sql.NullString
-		err = rows.Scan(&receivedRow.CarID,
-			&receivedRow.CarCompanyName,
-			&receivedRow.Doors,
-			&receivedRow.BigLuggage,
-			&receivedRow.SmallLuggage,
-			&receivedRow.AdultPlaces,
-			&receivedRow.AirConditioner,
-			&receivedRow.MinimumAge,
-			&locations,
-			&receivedRow.CarGroup,
-			&receivedRow.Description,
-			&receivedRow.Price,
-			&rentID,
-			&fromDate,
-			&toDate,
-			&location,
-			&extras,
-			&discounts,
-			&carDetails,
-		)
-		if err != nil {
-			log.Error(err)
-			continue
+	for _, info := range found {
+		for _, location := range fleet.NewTimeline(info.Car, carRents[info.CarID], carBlackouts[info.CarID]).Locations(dates.From, dates.To) {
+			if requested[location] {
+				result = append(result, info)
+				break
+			}
 		}
-
-		receivedRow.RentID = rentID.Int32
-		receivedRow.FromDate = fromDate.String
-		receivedRow.ToDate = toDate.String
-		receivedRow.Location = location.String
-		receivedRow.AvailableExtras = strings.Split(extras.String, ",")
-		receivedRow.Discounts = strings.Split(discounts.String, ",")
-		receivedRow.AvailableLocations = strings.Split(locations, ",")
-		receivedRow.CarDetails = carDetails.String
-		result = append(result, receivedRow)
 	}
 	return result, nil
 }
@@ -166,140 +130,160 @@ func (carPr *CarProcessor) GetCarsFromDBWithParams(values map[string][]string) (
 Get car from DB upon car ID
 */
 func (carPr *CarProcessor) GetCarFromDB(carID int) (*domain.Car, error) {
-	stmt, err := carPr.dbStruct.Prepare(fmt.Sprintf("%s WHERE car_id=?", db.SelectCars))
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to prepare an sql query")
-	}
-	rows := stmt.QueryRow(carID)
-	var receivedRow domain.Car
-	var locations string
-	err = rows.Scan(&receivedRow.CarID, &receivedRow.CarCompanyName,
-		&receivedRow.Doors,
-		&receivedRow.BigLuggage,
-		&receivedRow.SmallLuggage,
-		&receivedRow.AdultPlaces,
-		&receivedRow.AirConditioner,
-		&receivedRow.MinimumAge,
-		&locations,
-		&receivedRow.CarGroup,
-		&receivedRow.Description, &receivedRow.Price)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to query car")
-	}
-	receivedRow.AvailableLocations = strings.Split(locations, ",")
-	return &receivedRow, nil
+	return carPr.cars.GetCar(carID)
 }
 
 /*
 Update car in DB
 */
 func (carPr *CarProcessor) UpdateCarInDB(car domain.Car, carID int) (int64, error) {
-	stmt, err := carPr.dbStruct.Prepare(db.UpdateCar)
+	car, err := carPr.validateCar(car)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to prepare an sql query")
+		return 0, err
 	}
+	return carPr.cars.UpdateCar(car, carID)
+}
 
-	res, err := stmt.Exec(car.CarCompanyName,
-		car.Doors,
-		car.BigLuggage,
-		car.SmallLuggage,
-		car.AdultPlaces,
-		car.AirConditioner,
-		car.MinimumAge,
-		strings.Join(car.AvailableLocations, ","),
-		car.CarGroup,
-		car.Description,
-		car.Price,
-		carID)
-	if err != nil {
-		return 0, errors.Wrap(err, "Failed to execute car update")
+/*
+Check every car field and link car to its branches, all found violations are returned together
+*/
+func (carPr *CarProcessor) validateCar(car domain.Car) (domain.Car, error) {
+	var violations validation.Violations
+	if len(strings.TrimSpace(car.CarCompanyName)) == 0 {
+		violations = append(violations, *validation.New("carCompanyName", validation.Required, "Car company name should be provided"))
 	}
-
-	affect, err := res.RowsAffected()
+	counters := []struct {
+		field string
+		value int
+	}{
+		{"doors", car.Doors},
+		{"bigLuggage", car.BigLuggage},
+		{"smallLuggage", car.SmallLuggage},
+		{"adultPlaces", car.AdultPlaces},
+		{"minimumAge", car.MinimumAge},
+		{"price", car.Price},
+		{"carGroup", car.CarGroup},
+	}
+	for _, counter := range counters {
+		if counter.value < 0 {
+			violations = append(violations, *validation.New(counter.field, validation.OutOfRange, "[%d] should not be negative", counter.value))
+		}
+	}
+	car, branchViolations, err := carPr.linkCarBranches(car)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+		return car, err
 	}
-
-	return affect, nil
+	violations = append(violations, branchViolations...)
+	if len(violations) > 0 {
+		return car, violations
+	}
+	return car, nil
 }
 
 /*
-Remove car from DB
+Link car to branches provided by ID or by name in available locations, every branch should exist.
+Names of linked branches are added to available locations and their IDs to branch IDs
 */
-func (carPr *CarProcessor) RemoveCarFromDB(carID int) (int64, error) {
-	stmt, err := carPr.dbStruct.Prepare(db.RemoveCar)
-	if err != nil {
-		return 0, errors.Wrap(err, "Failed to prepare an sql query")
+func (carPr *CarProcessor) linkCarBranches(car domain.Car) (domain.Car, validation.Violations, error) {
+	var violations validation.Violations
+	linked := make(map[int]bool)
+	var branchIDs []int
+	var locations []string
+	link := func(branch *domain.Branch) {
+		if linked[branch.BranchID] {
+			return
+		}
+		linked[branch.BranchID] = true
+		branchIDs = append(branchIDs, branch.BranchID)
+		locations = append(locations, branch.Name)
 	}
-
-	res, err := stmt.Exec(carID)
-	if err != nil {
-		return 0, errors.Wrap(err, "Failed to execute car delete")
+	for _, name := range car.AvailableLocations {
+		branch, err := carPr.branches.GetBranchByName(name)
+		if errors.Is(err, storage.ErrNotFound) {
+			violations = append(violations, *validation.New("availableLocations", validation.NotFound, "Branch [%s] does not exist", name))
+			continue
+		}
+		if err != nil {
+			return car, nil, errors.Wrap(err, "Failed to find car branch")
+		}
+		link(branch)
 	}
-
-	affect, err := res.RowsAffected()
-	if err != nil {
-		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	for _, branchID := range car.BranchIDs {
+		branch, err := carPr.branches.GetBranch(branchID)
+		if errors.Is(err, storage.ErrNotFound) {
+			violations = append(violations, *validation.New("branchIDs", validation.NotFound, "Branch [%d] does not exist", branchID))
+			continue
+		}
+		if err != nil {
+			return car, nil, errors.Wrap(err, "Failed to find car branch")
+		}
+		link(branch)
 	}
-
-	return affect, nil
+	sort.Ints(branchIDs)
+	car.BranchIDs = branchIDs
+	car.AvailableLocations = locations
+	return car, violations, nil
 }
 
 /*
 Create filter from URL values
 */
-func (carPr *CarProcessor) extractURLValues(values map[string][]string) domain.SearchParams {
-	fromExists := ""
-	toExists := ""
-	if from, ok := values[domain.FromDateUrlValue]; ok {
-		if len(from) == 1 && len(from[0]) > 0 {
-			fromExists = from[0]
-		}
+func (carPr *CarProcessor) extractURLValues(values map[string][]string) (storage.CarFilter, error) {
+	var filter storage.CarFilter
+	from, err := query.SingleValue(values, domain.FromDateUrlValue)
+	if err != nil {
+		return filter, err
 	}
-	if to, ok := values[domain.ToDateUrlValue]; ok {
-		if len(to) == 1 && len(to[0]) > 0 {
-			if len(fromExists) > 0 {
-				toExists = to[0]
-			}
+	to, err := query.SingleValue(values, domain.ToDateUrlValue)
+	if err != nil {
+		return filter, err
+	}
+	if len(from) > 0 || len(to) > 0 {
+		dates, err := query.ParseDateRange(domain.FromDateUrlValue, from, domain.ToDateUrlValue, to)
+		if err != nil {
+			return filter, err
 		}
+		filter.Dates = &dates
 	}
-	searchParams := buildFromToFilter(fromExists, toExists, false)
-	addedFilter := false
-	if location, ok := values[domain.LocationUrlValue]; ok {
-		if len(location) == 1 && len(location[0]) > 0 {
-			splittedLocations := strings.Split(location[0], ",")
-			addedFilter = true
-			searchParams.DefaultFilter += "("
-			for index, loc := range splittedLocations {
-				searchParams.DefaultFilter += "locations like '%" + loc + "%'"
-				if index < len(splittedLocations)-1 {
-					searchParams.DefaultFilter += " or "
-				}
-			}
-			searchParams.DefaultFilter += ")"
+	location, err := query.SingleValue(values, domain.LocationUrlValue)
+	if err != nil {
+		return filter, err
+	}
+	if len(location) > 0 {
+		branches, err := carPr.branches.GetBranches()
+		if err != nil {
+			return filter, errors.Wrap(err, "Failed to get branches")
+		}
+		var branchNames []string
+		for _, branch := range branches {
+			branchNames = append(branchNames, branch.Name)
+		}
+		filter.Locations, err = query.ParseEnumList(domain.LocationUrlValue, location, branchNames)
+		if err != nil {
+			return filter, err
 		}
 	}
-	if age, ok := values[domain.AgeGroupUrlValue]; ok {
-		if len(age) == 1 && len(age[0]) > 0 {
-			if addedFilter {
-				searchParams.DefaultFilter += " and "
-			}
-			addedFilter = true
-			splittedAge := strings.Split(age[0], "-")
-			if len(splittedAge) == 1 {
-				searchParams.DefaultFilter += "min_age>=" + splittedAge[0]
-			} else if len(splittedAge) == 2 {
-				searchParams.DefaultFilter += " min_age between " + splittedAge[0] + " and " + splittedAge[1]
-			}
+	age, err := query.SingleValue(values, domain.AgeGroupUrlValue)
+	if err != nil {
+		return filter, err
+	}
+	if len(age) > 0 {
+		ageRange, err := query.ParseIntRange(domain.AgeGroupUrlValue, age)
+		if err != nil {
+			return filter, err
 		}
+		filter.Age = &ageRange
 	}
-	if car, ok := values[domain.CarGroupUrlValue]; ok {
-		if len(car) == 1 && len(car[0]) > 0 {
-			if addedFilter {
-				searchParams.DefaultFilter += " and "
-			}
-			searchParams.DefaultFilter += " car_group=" + car[0]
+	car, err := query.SingleValue(values, domain.CarGroupUrlValue)
+	if err != nil {
+		return filter, err
+	}
+	if len(car) > 0 {
+		carGroup, err := query.ParseInt(domain.CarGroupUrlValue, car)
+		if err != nil {
+			return filter, err
 		}
+		filter.CarGroup = &carGroup
 	}
-	return searchParams
+	return filter, nil
 }
@@ -0,0 +1,154 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	emailPattern   = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	// Licence categories like B, C1 or CE
+	categoryPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*$`)
+)
+
+type CustomerProcessor struct {
+	customers storage.CustomerRepository
+}
+
+func NewCustomerProcessor(repos storage.Repositories) *CustomerProcessor {
+	return &CustomerProcessor{customers: repos.Customers}
+}
+
+/*
+Insert customer into DB
+*/
+func (customerPr *CustomerProcessor) InsertCustomerInDB(customer domain.Customer) (int64, error) {
+	if err := customerPr.validateCustomer(customer, 0); err != nil {
+		return 0, err
+	}
+	return customerPr.customers.InsertCustomer(customer)
+}
+
+/*
+Get customers from DB
+*/
+func (customerPr *CustomerProcessor) GetCustomersFromDB() ([]domain.Customer, error) {
+	return customerPr.customers.GetCustomers()
+}
+
+/*
+Get customer from DB upon customer ID
+*/
+func (customerPr *CustomerProcessor) GetCustomerFromDB(customerID int) (*domain.Customer, error) {
+	return customerPr.customers.GetCustomer(customerID)
+}
+
+/*
+Update customer in DB
+*/
+func (customerPr *CustomerProcessor) UpdateCustomerInDB(customer domain.Customer, customerID int) (int64, error) {
+	if err := customerPr.validateCustomer(customer, customerID); err != nil {
+		return 0, err
+	}
+	return customerPr.customers.UpdateCustomer(customer, customerID)
+}
+
+/*
+Remove customer from DB, customer who has rents is not removed
+*/
+func (customerPr *CustomerProcessor) RemoveCustomerFromDB(customerID int) (int64, error) {
+	return customerPr.customers.RemoveCustomer(customerID)
+}
+
+/*
+Check every customer field, driver licence should not belong to other customer. All found violations are returned together
+*/
+func (customerPr *CustomerProcessor) validateCustomer(customer domain.Customer, customerID int) error {
+	var violations validation.Violations
+	if len(strings.TrimSpace(customer.Name)) == 0 {
+		violations = append(violations, *validation.New("name", validation.Required, "Customer name should be provided"))
+	}
+	if len(customer.Email) == 0 {
+		violations = append(violations, *validation.New("email", validation.Required, "Customer email should be provided"))
+	} else if !emailPattern.MatchString(customer.Email) {
+		violations = append(violations, *validation.New("email", validation.InvalidFormat, "[%s] is not an email address", customer.Email))
+	}
+	now := time.Now().UTC()
+	dateOfBirth, violation := parseDate("dateOfBirth", customer.DateOfBirth)
+	if violation != nil {
+		violations = append(violations, *violation)
+	} else if !dateOfBirth.Before(now) {
+		violations = append(violations, *validation.New("dateOfBirth", validation.OutOfRange, "[%s] should be in the past", customer.DateOfBirth))
+	}
+	if len(strings.TrimSpace(customer.LicenceNumber)) == 0 {
+		violations = append(violations, *validation.New("licenceNumber", validation.Required, "Driver licence number should be provided"))
+	}
+	if len(customer.LicenceCountry) == 0 {
+		violations = append(violations, *validation.New("licenceCountry", validation.Required, "Driver licence issuing country should be provided"))
+	} else if !countryPattern.MatchString(customer.LicenceCountry) {
+		violations = append(violations, *validation.New("licenceCountry", validation.InvalidFormat, "[%s] should be two letter country code", customer.LicenceCountry))
+	}
+	if _, violation := parseDate("licenceExpiry", customer.LicenceExpiry); violation != nil {
+		violations = append(violations, *violation)
+	}
+	if issued, violation := parseDate("licenceIssued", customer.LicenceIssued); violation != nil {
+		violations = append(violations, *violation)
+	} else if issued.After(now) || issued.Before(dateOfBirth) {
+		violations = append(violations, *validation.New("licenceIssued", validation.OutOfRange, "[%s] should be between date of birth and today", customer.LicenceIssued))
+	}
+	if len(customer.LicenceCategories) == 0 {
+		violations = append(violations, *validation.New("licenceCategories", validation.Required, "Driver licence categories should be provided"))
+	}
+	for _, category := range customer.LicenceCategories {
+		if !categoryPattern.MatchString(category) {
+			violations = append(violations, *validation.New("licenceCategories", validation.InvalidFormat, "[%s] is not a licence category", category))
+		}
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+	existing, err := customerPr.customers.GetCustomerByLicence(customer.LicenceCountry, customer.LicenceNumber)
+	if err == nil && existing.CustomerID != customerID {
+		return validation.New("licenceNumber", validation.Duplicate, "Customer with licence [%s %s] already exists", customer.LicenceCountry, customer.LicenceNumber)
+	}
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return errors.Wrap(err, "Failed to check driver licence")
+	}
+	return nil
+}
+
+/*
+Required date in format 2006-01-02
+*/
+func parseDate(field string, value string) (time.Time, *validation.Violation) {
+	if len(value) == 0 {
+		return time.Time{}, validation.New(field, validation.Required, "%s should be provided", field)
+	}
+	parsed, err := time.Parse(domain.DateLayout, value)
+	if err != nil {
+		return parsed, validation.New(field, validation.InvalidFormat, "[%s] should be in format %s", value, domain.DateLayout)
+	}
+	return parsed, nil
+}
+
+/*
+Full years of customer at the moment, date of birth is expected to be valid
+*/
+func customerAge(customer domain.Customer, at time.Time) int {
+	dateOfBirth, err := time.Parse(domain.DateLayout, customer.DateOfBirth)
+	if err != nil {
+		return 0
+	}
+	age := at.Year() - dateOfBirth.Year()
+	if at.Month() < dateOfBirth.Month() || (at.Month() == dateOfBirth.Month() && at.Day() < dateOfBirth.Day()) {
+		age--
+	}
+	return age
+}
@@ -0,0 +1,72 @@
+package cmds
+
+import (
+	"car-rental/internal/server/storage/memory"
+	"car-rental/internal/server/validation"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertCustomerReportsEveryViolation(test *testing.T) {
+	customerProcessor := NewCustomerProcessor(memory.NewRepositories())
+	customer := rentTestCustomer
+	customer.Name = " "
+	customer.Email = "dana"
+	customer.DateOfBirth = "2999-01-01"
+	customer.LicenceCountry = "Israel"
+	customer.LicenceExpiry = "20.06.2030"
+	customer.LicenceIssued = "1970-01-01"
+	customer.LicenceCategories = []string{"B", "c1"}
+	_, err := customerProcessor.InsertCustomerInDB(customer)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"name:required", "email:invalid_format", "dateOfBirth:out_of_range", "licenceCountry:invalid_format", "licenceExpiry:invalid_format", "licenceIssued:out_of_range", "licenceCategories:invalid_format"}, violationCodes(violations))
+}
+
+func TestCustomerLicenceIsUnique(test *testing.T) {
+	customerProcessor := NewCustomerProcessor(memory.NewRepositories())
+	id, err := customerProcessor.InsertCustomerInDB(rentTestCustomer)
+	require.NoError(test, err)
+
+	other := rentTestCustomer
+	other.Name = "Other Driver"
+	_, err = customerProcessor.InsertCustomerInDB(other)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"licenceNumber:duplicate"}, violationCodes(violations))
+
+	other.LicenceCountry = "DE"
+	otherID, err := customerProcessor.InsertCustomerInDB(other)
+	require.NoError(test, err)
+
+	changed := rentTestCustomer
+	changed.Email = "levi@example.com"
+	affected, err := customerProcessor.UpdateCustomerInDB(changed, int(id))
+	require.NoError(test, err, "Customer keeps own licence")
+	assert.Equal(test, int64(1), affected)
+	_, err = customerProcessor.UpdateCustomerInDB(changed, int(otherID))
+	_, ok = validation.From(err)
+	assert.True(test, ok, "Customer can not take licence of other customer")
+}
+
+func TestCustomerAge(test *testing.T) {
+	customer := rentTestCustomer
+	customer.DateOfBirth = "2000-02-29"
+	testCases := []struct {
+		at       string
+		expected int
+	}{
+		{"2018-02-28T10:00:00Z", 17},
+		{"2018-03-01T10:00:00Z", 18},
+		{"2020-02-29T00:00:00Z", 20},
+		{"2021-12-31T23:00:00Z", 21},
+	}
+	for _, testCase := range testCases {
+		at, err := time.Parse(time.RFC3339, testCase.at)
+		require.NoError(test, err)
+		assert.Equal(test, testCase.expected, customerAge(customer, at), testCase.at)
+	}
+}
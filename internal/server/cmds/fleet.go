@@ -0,0 +1,85 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/rebalancing"
+	"car-rental/internal/server/storage"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type FleetProcessor struct {
+	cars      storage.CarRepository
+	rents     storage.RentRepository
+	blackouts storage.BlackoutRepository
+	branches  storage.BranchRepository
+	rules     rebalancing.Rules
+}
+
+func NewFleetProcessor(repos storage.Repositories) *FleetProcessor {
+	return NewFleetProcessorWithRules(repos, rebalancing.DefaultRules)
+}
+
+func NewFleetProcessorWithRules(repos storage.Repositories, rules rebalancing.Rules) *FleetProcessor {
+	return &FleetProcessor{cars: repos.Cars, rents: repos.Rents, blackouts: repos.Blackouts, branches: repos.Branches, rules: rules}
+}
+
+/*
+Plan transfers of idle cars to branches where they are booked, nothing is moved
+*/
+func (fleetPr *FleetProcessor) PlanRebalancing(start time.Time) (*domain.RebalancePlan, error) {
+	snapshot, err := fleetPr.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	plan := fleetPr.rules.Plan(*snapshot, start)
+	return &plan, nil
+}
+
+/*
+Plan transfers of idle cars and store every transfer as blackout which leaves the car at destination branch
+*/
+func (fleetPr *FleetProcessor) ApplyRebalancing(start time.Time) (*domain.RebalancePlan, error) {
+	plan, err := fleetPr.PlanRebalancing(start)
+	if err != nil {
+		return nil, err
+	}
+	for index, transfer := range plan.Transfers {
+		id, err := fleetPr.blackouts.InsertBlackout(domain.Blackout{
+			CarID:       transfer.CarID,
+			FromDate:    transfer.FromDate,
+			ToDate:      transfer.ToDate,
+			Reason:      domain.TransferBlackoutReason,
+			Description: "Transfer from " + transfer.FromLocation,
+			ToLocation:  transfer.ToLocation,
+			ToBranchID:  transfer.ToBranchID,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to store transfer of car %d", transfer.CarID)
+		}
+		plan.Transfers[index].BlackoutID = int(id)
+	}
+	plan.Applied = true
+	return plan, nil
+}
+
+func (fleetPr *FleetProcessor) snapshot() (*rebalancing.Snapshot, error) {
+	cars, err := fleetPr.cars.GetCars()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get cars")
+	}
+	rents, err := fleetPr.rents.GetRents()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get rents")
+	}
+	blackouts, err := fleetPr.blackouts.GetBlackouts()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get blackouts")
+	}
+	branches, err := fleetPr.branches.GetBranches()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get branches")
+	}
+	return &rebalancing.Snapshot{Cars: cars, Rents: rents, Blackouts: blackouts, Branches: branches}, nil
+}
@@ -0,0 +1,87 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Test that applied transfer moves idle car to branch where its group is booked and rents pick it up there
+*/
+func TestApplyRebalancingMovesCar(test *testing.T) {
+	repos := newTestRepositories(test)
+	firstCar := insertTestCar(test, repos)
+	secondCar := insertTestCar(test, repos)
+	bookedCar := rentTestCar
+	bookedCar.AvailableLocations = []string{"Tel Aviv"}
+	bookedID, err := repos.Cars.InsertCar(bookedCar)
+	require.NoError(test, err)
+	bookedCar.CarID = int(bookedID)
+	fleetProcessor := NewFleetProcessor(repos)
+	rentProcessor := NewRentProcessor(repos)
+	blackoutProcessor := NewBlackoutProcessor(repos)
+	telAviv, err := repos.Branches.GetBranchByName("Tel Aviv")
+	require.NoError(test, err)
+
+	booked := rentTestRent
+	booked.CarID = bookedCar.CarID
+	booked.Location = "Tel Aviv"
+	booked.FromDate = "2022-01-12T10:00:00Z"
+	booked.ToDate = "2022-01-13T10:00:00Z"
+	_, err = rentProcessor.InsertRentInDB(booked, &bookedCar)
+	require.NoError(test, err)
+
+	start := time.Date(2022, 1, 10, 10, 0, 0, 0, time.UTC)
+	plan, err := fleetProcessor.PlanRebalancing(start)
+	require.NoError(test, err)
+	require.Len(test, plan.Transfers, 2)
+	blackouts, err := repos.Blackouts.GetBlackouts()
+	require.NoError(test, err)
+	assert.Empty(test, blackouts, "Plan does not move cars")
+
+	applied, err := fleetProcessor.ApplyRebalancing(start)
+	require.NoError(test, err)
+	assert.True(test, applied.Applied)
+	require.Len(test, applied.Transfers, 2)
+	first := applied.Transfers[0]
+	assert.Equal(test, firstCar.CarID, first.CarID)
+	assert.Equal(test, "Haifa", first.FromLocation)
+	assert.Equal(test, telAviv.BranchID, first.ToBranchID)
+	transfer, err := blackoutProcessor.GetBlackoutFromDB(firstCar.CarID, first.BlackoutID)
+	require.NoError(test, err)
+	assert.Equal(test, domain.TransferBlackoutReason, transfer.Reason)
+	assert.Equal(test, "Tel Aviv", transfer.ToLocation)
+	assert.Equal(test, "2022-01-10T14:00:00Z", transfer.ToDate)
+
+	rent := rentTestRent
+	rent.CarID = firstCar.CarID
+	_, err = rentProcessor.InsertRentInDB(rent, &firstCar)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"location:mismatch"}, violationCodes(violations), "Car is in Tel Aviv now")
+	rent.Location = "Tel Aviv"
+	_, err = rentProcessor.InsertRentInDB(rent, &firstCar)
+	require.NoError(test, err)
+
+	_, err = blackoutProcessor.UpdateBlackoutInDB(domain.Blackout{FromDate: transfer.FromDate, ToDate: transfer.ToDate, Reason: domain.ServiceBlackoutReason}, firstCar.CarID, first.BlackoutID)
+	violations, ok = validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"reason:not_allowed"}, violationCodes(violations))
+	_, err = blackoutProcessor.RemoveBlackoutFromDB(firstCar.CarID, first.BlackoutID)
+	var inUseErr *CarInUseError
+	assert.True(test, errors.As(err, &inUseErr), "Rent picks the car up where transfer leaves it")
+	affected, err := blackoutProcessor.RemoveBlackoutFromDB(secondCar.CarID, applied.Transfers[1].BlackoutID)
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+
+	_, err = blackoutProcessor.InsertBlackoutInDB(domain.Blackout{FromDate: "2022-01-20T10:00:00Z", ToDate: "2022-01-20T14:00:00Z", Reason: domain.TransferBlackoutReason, ToLocation: "Haifa"}, secondCar.CarID)
+	violations, ok = validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"toLocation:not_allowed", "reason:not_allowed"}, violationCodes(violations), "Transfers are only planned")
+}
@@ -0,0 +1,206 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/metrics"
+	"car-rental/internal/server/validation"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Actions changing rent status
+*/
+const (
+	PickupRentAction = "pickup"
+	ReturnRentAction = "return"
+	CloseRentAction  = "close"
+	CancelRentAction = "cancel"
+	// Not a transition, rent dates and car are changed while status is kept
+	ModifyRentAction = "modify"
+)
+
+type rentTransitionRule struct {
+	from string
+	to   string
+	// Odometer and fuel level are required when car changes hands
+	carStateRequired bool
+}
+
+/*
+Rent lifecycle: reserved -> picked_up -> returned -> closed, reserved rent can be cancelled
+*/
+var rentTransitionRules = map[string]rentTransitionRule{
+	PickupRentAction: {from: domain.ReservedRentStatus, to: domain.PickedUpRentStatus, carStateRequired: true},
+	ReturnRentAction: {from: domain.PickedUpRentStatus, to: domain.ReturnedRentStatus, carStateRequired: true},
+	CloseRentAction:  {from: domain.ReturnedRentStatus, to: domain.ClosedRentStatus},
+	CancelRentAction: {from: domain.ReservedRentStatus, to: domain.CancelledRentStatus},
+}
+
+/*
+Rent statuses which still allow rent modification
+*/
+var modifiableRentStatuses = map[string]bool{
+	domain.ReservedRentStatus: true,
+	domain.PickedUpRentStatus: true,
+}
+
+/*
+Returned when action is not allowed in current status of the rent
+*/
+type RentStatusError struct {
+	RentID int
+	Status string
+	Action string
+}
+
+func (err *RentStatusError) Error() string {
+	return fmt.Sprintf("Rent %d is %s, action [%s] is not allowed", err.RentID, err.Status, err.Action)
+}
+
+/*
+Move rent to the next status of the action and record car state provided in transition.
+Returns recorded transition, storage.ErrNotFound when rent does not exist
+*/
+func (rentPr *RentProcessor) TransitRent(rentID int, action string, carState domain.RentTransition) (*domain.RentTransition, error) {
+	rule, ok := rentTransitionRules[action]
+	if !ok {
+		return nil, errors.Errorf("Unknown rent action [%s]", action)
+	}
+	rent, err := rentPr.rents.GetRent(rentID)
+	if err != nil {
+		return nil, err
+	}
+	if rent.Status != rule.from {
+		return nil, &RentStatusError{RentID: rentID, Status: rent.Status, Action: action}
+	}
+	violations := checkCarState(carState, rule.carStateRequired)
+	if action == ReturnRentAction && carState.Odometer != nil {
+		pickupOdometer, err := rentPr.lastOdometer(rentID)
+		if err != nil {
+			return nil, err
+		}
+		if pickupOdometer != nil && *carState.Odometer < *pickupOdometer {
+			violations = append(violations, *validation.New("odometer", validation.Mismatch, "Odometer should not be less than %d recorded at pickup", *pickupOdometer))
+		}
+	}
+	if len(violations) > 0 {
+		return nil, violations
+	}
+
+	now := time.Now().UTC()
+	var charged *domain.Cancellation
+	if action == CancelRentAction {
+		charged, err = rentPr.chargeCancellation(*rent, carState.Reason, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	transition := domain.RentTransition{
+		RentID:     rentID,
+		FromStatus: rule.from,
+		ToStatus:   rule.to,
+		Odometer:   carState.Odometer,
+		FuelLevel:  carState.FuelLevel,
+		Reason:     carState.Reason,
+		CreatedAt:  now.Format(domain.TimeLayout),
+	}
+	id, err := rentPr.rents.TransitRent(transition, charged)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to change rent status")
+	}
+	if id == 0 {
+		// Rent was changed or removed after it was read
+		rent, err := rentPr.rents.GetRent(rentID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &RentStatusError{RentID: rentID, Status: rent.Status, Action: action}
+	}
+	transition.TransitionID = int(id)
+	if rule.to == domain.CancelledRentStatus {
+		metrics.RentsCancelled.Inc()
+	}
+	rentPr.logger.WithFields(log.Fields{"rentID": rentID, "fromStatus": rule.from, "toStatus": rule.to}).Info("Rent status is changed")
+	return &transition, nil
+}
+
+/*
+Cancel reserved rent, rent is kept with the reason and fee charged by cancellation policy.
+Returns storage.ErrNotFound when rent does not exist
+*/
+func (rentPr *RentProcessor) CancelRent(rentID int, reason string) (*domain.Cancellation, error) {
+	if _, err := rentPr.TransitRent(rentID, CancelRentAction, domain.RentTransition{Reason: reason}); err != nil {
+		return nil, err
+	}
+	rent, err := rentPr.rents.GetRent(rentID)
+	if err != nil {
+		return nil, err
+	}
+	return rent.Cancellation, nil
+}
+
+/*
+Get status history of rent, storage.ErrNotFound is returned when rent does not exist
+*/
+func (rentPr *RentProcessor) GetRentTransitionsFromDB(rentID int) ([]domain.RentTransition, error) {
+	if _, err := rentPr.rents.GetRent(rentID); err != nil {
+		return nil, err
+	}
+	return rentPr.rents.GetRentTransitions(rentID)
+}
+
+/*
+Fee is part of rent total, rent priced before quotes were stored is cancelled for free
+*/
+func (rentPr *RentProcessor) chargeCancellation(rent domain.RentInfo, reason string, cancelledAt time.Time) (*domain.Cancellation, error) {
+	pickup, err := time.Parse(domain.TimeLayout, rent.FromDate)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Rent %d has broken from date", rent.RentID)
+	}
+	var total int64
+	if rent.Quote != nil {
+		total = rent.Quote.Total
+	}
+	charged := rentPr.cancellation.Charge(total, pickup, cancelledAt)
+	charged.Reason = reason
+	return &charged, nil
+}
+
+/*
+Odometer recorded by the latest transition which has it, nil when it was never recorded
+*/
+func (rentPr *RentProcessor) lastOdometer(rentID int) (*int, error) {
+	transitions, err := rentPr.rents.GetRentTransitions(rentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get rent transitions")
+	}
+	var odometer *int
+	for _, transition := range transitions {
+		if transition.Odometer != nil {
+			odometer = transition.Odometer
+		}
+	}
+	return odometer, nil
+}
+
+/*
+Odometer is a non negative number of kilometers, fuel level is percent of full tank
+*/
+func checkCarState(carState domain.RentTransition, required bool) validation.Violations {
+	var violations validation.Violations
+	if carState.Odometer == nil && required {
+		violations = append(violations, *validation.New("odometer", validation.Required, "Odometer should be provided"))
+	} else if carState.Odometer != nil && *carState.Odometer < 0 {
+		violations = append(violations, *validation.New("odometer", validation.OutOfRange, "Odometer [%d] should not be negative", *carState.Odometer))
+	}
+	if carState.FuelLevel == nil && required {
+		violations = append(violations, *validation.New("fuelLevel", validation.Required, "Fuel level should be provided"))
+	} else if carState.FuelLevel != nil && (*carState.FuelLevel < 0 || *carState.FuelLevel > 100) {
+		violations = append(violations, *validation.New("fuelLevel", validation.OutOfRange, "Fuel level [%d] should be between 0 and 100", *carState.FuelLevel))
+	}
+	return violations
+}
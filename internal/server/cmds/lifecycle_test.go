@@ -0,0 +1,174 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func carState(odometer int, fuelLevel int) domain.RentTransition {
+	return domain.RentTransition{Odometer: &odometer, FuelLevel: &fuelLevel}
+}
+
+func TestTransitRentFollowsLifecycle(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+
+	_, err = rentProcessor.TransitRent(int(rentID), ReturnRentAction, carState(1000, 50))
+	var statusErr *RentStatusError
+	require.True(test, errors.As(err, &statusErr), "Rent can not be returned before pickup")
+	assert.Equal(test, domain.ReservedRentStatus, statusErr.Status)
+
+	_, err = rentProcessor.TransitRent(int(rentID), PickupRentAction, domain.RentTransition{})
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"odometer:required", "fuelLevel:required"}, violationCodes(violations))
+	_, err = rentProcessor.TransitRent(int(rentID), PickupRentAction, carState(-1, 101))
+	violations, ok = validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"odometer:out_of_range", "fuelLevel:out_of_range"}, violationCodes(violations))
+
+	pickup, err := rentProcessor.TransitRent(int(rentID), PickupRentAction, carState(1000, 100))
+	require.NoError(test, err)
+	assert.Equal(test, domain.ReservedRentStatus, pickup.FromStatus)
+	assert.Equal(test, domain.PickedUpRentStatus, pickup.ToStatus)
+	assert.NotEmpty(test, pickup.CreatedAt)
+
+	_, err = rentProcessor.TransitRent(int(rentID), CancelRentAction, domain.RentTransition{})
+	assert.True(test, errors.As(err, &statusErr), "Picked up rent can not be cancelled")
+
+	_, err = rentProcessor.TransitRent(int(rentID), ReturnRentAction, carState(900, 40))
+	violations, ok = validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"odometer:mismatch"}, violationCodes(violations))
+	_, err = rentProcessor.TransitRent(int(rentID), ReturnRentAction, carState(1350, 40))
+	require.NoError(test, err)
+
+	rent.ToDate = "2022-01-17T10:00:00Z"
+	_, err = rentProcessor.UpdateRentInDB(rent, int(rentID), &car)
+	require.True(test, errors.As(err, &statusErr), "Returned rent can not be modified")
+	assert.Equal(test, ModifyRentAction, statusErr.Action)
+
+	_, err = rentProcessor.TransitRent(int(rentID), CloseRentAction, domain.RentTransition{})
+	require.NoError(test, err)
+	transitions, err := rentProcessor.GetRentTransitionsFromDB(int(rentID))
+	require.NoError(test, err)
+	var statuses []string
+	for _, transition := range transitions {
+		statuses = append(statuses, transition.ToStatus)
+	}
+	assert.Equal(test, []string{domain.PickedUpRentStatus, domain.ReturnedRentStatus, domain.ClosedRentStatus}, statuses)
+
+	_, err = rentProcessor.TransitRent(1000, PickupRentAction, carState(1000, 100))
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+}
+
+/*
+Rent repository which transits the rent right before it is updated
+*/
+type transitBeforeUpdate struct {
+	storage.RentRepository
+	transition domain.RentTransition
+}
+
+func (rents *transitBeforeUpdate) UpdateRent(rent domain.RentInfo, rentID int, check storage.RentCheck) (int64, error) {
+	if _, err := rents.RentRepository.TransitRent(rents.transition, nil); err != nil {
+		return 0, err
+	}
+	return rents.RentRepository.UpdateRent(rent, rentID, check)
+}
+
+/*
+Test that rent cancelled after its status was checked is not modified
+*/
+func TestUpdateRentRejectsRentTransitedBeforeWrite(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rentID, err := NewRentProcessor(repos).InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	repos.Rents = &transitBeforeUpdate{
+		RentRepository: repos.Rents,
+		transition: domain.RentTransition{
+			RentID:     int(rentID),
+			FromStatus: domain.ReservedRentStatus,
+			ToStatus:   domain.CancelledRentStatus,
+			CreatedAt:  time.Now().UTC().Format(domain.TimeLayout),
+		},
+	}
+	rentProcessor := NewRentProcessor(repos)
+
+	updated := rent
+	updated.ToDate = "2022-01-17T10:00:00Z"
+	_, err = rentProcessor.UpdateRentInDB(updated, int(rentID), &car)
+	var statusErr *RentStatusError
+	require.True(test, errors.As(err, &statusErr), "Cancelled rent can not be modified")
+	assert.Equal(test, domain.CancelledRentStatus, statusErr.Status)
+	stored, err := rentProcessor.GetRentFromDB(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, rent.ToDate, stored.ToDate)
+}
+
+/*
+Test that cancelled rent does not hold the car
+*/
+func TestCancelledRentReleasesCar(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+
+	_, err = rentProcessor.TransitRent(int(rentID), CancelRentAction, domain.RentTransition{Reason: "Flight cancelled"})
+	require.NoError(test, err)
+	_, err = rentProcessor.InsertRentInDB(rent, &car)
+	assert.NoError(test, err)
+}
+
+func TestCancelRentChargesPolicyFee(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rent.FromDate = time.Now().Add(72 * time.Hour).UTC().Format(domain.TimeLayout)
+	rent.ToDate = time.Now().Add(96 * time.Hour).UTC().Format(domain.TimeLayout)
+	earlyRentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	rent.FromDate = time.Now().Add(24 * time.Hour).UTC().Format(domain.TimeLayout)
+	rent.ToDate = time.Now().Add(48 * time.Hour).UTC().Format(domain.TimeLayout)
+	lateRentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+
+	cancellation, err := rentProcessor.CancelRent(int(earlyRentID), "Plans changed")
+	require.NoError(test, err)
+	assert.Equal(test, int64(0), cancellation.Fee)
+	assert.Equal(test, cancellation.Total, cancellation.Refund)
+	assert.Equal(test, "Plans changed", cancellation.Reason)
+
+	cancellation, err = rentProcessor.CancelRent(int(lateRentID), "")
+	require.NoError(test, err)
+	assert.Equal(test, cancellation.Total/2, cancellation.Fee, "Half of total is kept less than 48h before pickup")
+	stored, err := rentProcessor.GetRentFromDB(int(lateRentID))
+	require.NoError(test, err)
+	assert.Equal(test, domain.CancelledRentStatus, stored.Status)
+	assert.Equal(test, cancellation, stored.Cancellation)
+
+	_, err = rentProcessor.CancelRent(int(lateRentID), "")
+	var statusErr *RentStatusError
+	assert.True(test, errors.As(err, &statusErr), "Rent is cancelled once")
+}
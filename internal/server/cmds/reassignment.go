@@ -0,0 +1,264 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Plan moving reserved rents of the car to equivalent cars without moving anything.
+Returns storage.ErrNotFound when car does not exist
+*/
+func (rentPr *RentProcessor) PlanReassignment(carID int, reason string) (*domain.ReassignmentPlan, error) {
+	car, err := rentPr.cars.GetCar(carID)
+	if err != nil {
+		return nil, err
+	}
+	plan, _, err := rentPr.planReassignment(*car, reason, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	plan.DryRun = true
+	return plan, nil
+}
+
+/*
+Move reserved rents of unavailable car to equivalent cars, rents which can not be moved are left on the car
+and returned as unassigned. Every move is recorded with the rent.
+Returns storage.ErrNotFound when car does not exist
+*/
+func (rentPr *RentProcessor) ReassignRents(carID int, reason string) (*domain.ReassignmentPlan, error) {
+	car, err := rentPr.cars.GetCar(carID)
+	if err != nil {
+		return nil, err
+	}
+	return rentPr.reassignRents(*car, reason)
+}
+
+/*
+Get reassignments of rent, storage.ErrNotFound is returned when rent does not exist
+*/
+func (rentPr *RentProcessor) GetRentReassignmentsFromDB(rentID int) ([]domain.Reassignment, error) {
+	if _, err := rentPr.rents.GetRent(rentID); err != nil {
+		return nil, err
+	}
+	return rentPr.rents.GetRentReassignments(rentID)
+}
+
+func (rentPr *RentProcessor) reassignRents(car domain.Car, reason string) (*domain.ReassignmentPlan, error) {
+	plan, cars, err := rentPr.planReassignment(car, reason, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	return rentPr.applyReassignment(*plan, cars)
+}
+
+/*
+Move rents as planned, rent changed after plan was made is left on the car and returned as unassigned
+*/
+func (rentPr *RentProcessor) applyReassignment(plan domain.ReassignmentPlan, cars map[int]domain.Car) (*domain.ReassignmentPlan, error) {
+	planned := plan.Reassigned
+	plan.Reassigned = []domain.Reassignment{}
+	for _, reassignment := range planned {
+		moved, err := rentPr.moveRent(reassignment, cars[reassignment.ToCarID])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to reassign rent %d", reassignment.RentID)
+		}
+		if moved == nil {
+			rent, err := rentPr.rents.GetRent(reassignment.RentID)
+			if err != nil {
+				return nil, err
+			}
+			plan.Unassigned = append(plan.Unassigned, *rent)
+			continue
+		}
+		plan.Reassigned = append(plan.Reassigned, *moved)
+	}
+	return &plan, nil
+}
+
+/*
+Find equivalent car for every booking of the car which is not finished, bookings planned earlier are taken into account.
+Picked up rents can not be moved and are returned as unassigned. Planned cars are returned by ID
+*/
+func (rentPr *RentProcessor) planReassignment(car domain.Car, reason string, now time.Time) (*domain.ReassignmentPlan, map[int]domain.Car, error) {
+	bookings, err := rentPr.activeBookings(car.CarID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan := domain.ReassignmentPlan{CarID: car.CarID, Reassigned: []domain.Reassignment{}, Unassigned: []domain.RentInfo{}}
+	plannedCars := make(map[int]domain.Car)
+	if len(bookings) == 0 {
+		return &plan, plannedCars, nil
+	}
+	cars, err := rentPr.cars.GetCars()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to get cars")
+	}
+	plannedRents := make(map[int][]domain.RentInfo)
+	for _, booking := range bookings {
+		if booking.Status != domain.ReservedRentStatus {
+			plan.Unassigned = append(plan.Unassigned, booking)
+			continue
+		}
+		candidate, kind, err := rentPr.findEquivalentCar(car, booking, cars, plannedRents)
+		if err != nil {
+			return nil, nil, err
+		}
+		if candidate == nil {
+			plan.Unassigned = append(plan.Unassigned, booking)
+			continue
+		}
+		plannedRents[candidate.CarID] = append(plannedRents[candidate.CarID], booking)
+		plannedCars[candidate.CarID] = *candidate
+		plan.Reassigned = append(plan.Reassigned, domain.Reassignment{
+			RentID:    booking.RentID,
+			FromCarID: car.CarID,
+			ToCarID:   candidate.CarID,
+			Kind:      kind,
+			Reason:    reason,
+		})
+	}
+	return &plan, plannedCars, nil
+}
+
+/*
+Like-for-like cars are preferred to upgrades, cars of the same kind are taken in order of their IDs
+*/
+func (rentPr *RentProcessor) findEquivalentCar(car domain.Car, booking domain.RentInfo, cars []domain.Car, plannedRents map[int][]domain.RentInfo) (*domain.Car, string, error) {
+	type match struct {
+		car  domain.Car
+		kind string
+	}
+	customer, err := rentPr.bookingCustomer(booking)
+	if err != nil {
+		return nil, "", err
+	}
+	var matches []match
+	for _, candidate := range cars {
+		if kind, ok := equivalentCar(car, candidate, booking, customer); ok {
+			matches = append(matches, match{car: candidate, kind: kind})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].kind == domain.LikeForLikeReassignment && matches[j].kind != domain.LikeForLikeReassignment
+	})
+	for _, candidate := range matches {
+		carRents, err := rentPr.rents.GetCarRents(candidate.car.CarID)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Failed to get car rents")
+		}
+		blackouts, err := rentPr.blackouts.GetCarBlackouts(candidate.car.CarID)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "Failed to get car blackouts")
+		}
+		candidateRents := append(carRents, plannedRents[candidate.car.CarID]...)
+		notAvailable, err := rentPr.carAvailability(booking, candidate.car.CarID, candidateRents, blackouts)
+		if err != nil {
+			return nil, "", err
+		}
+		if notAvailable != nil {
+			continue
+		}
+		requested, err := parseRentInterval(booking.FromDate, booking.ToDate)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(checkCarPosition(booking, candidate.car, candidateRents, blackouts, &requested)) == 0 {
+			return &candidate.car, candidate.kind, nil
+		}
+	}
+	return nil, "", nil
+}
+
+/*
+Customer of the booking, nil for bookings stored before customers were introduced
+*/
+func (rentPr *RentProcessor) bookingCustomer(booking domain.RentInfo) (*domain.Customer, error) {
+	if booking.CustomerID == 0 {
+		return nil, nil
+	}
+	customer, err := rentPr.customers.GetCustomer(booking.CustomerID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get customer of rent %d", booking.RentID)
+	}
+	return customer, nil
+}
+
+/*
+Candidate replaces the car when it has the same group, fits the driver and has at least the same adult places
+and luggage. Driver of booking without customer is checked by its age group.
+Candidate with more room is an upgrade, its position is checked against its rents by caller
+*/
+func equivalentCar(car domain.Car, candidate domain.Car, booking domain.RentInfo, customer *domain.Customer) (string, bool) {
+	if candidate.CarID == car.CarID || candidate.CarGroup != car.CarGroup || len(checkCarProps(booking, candidate, customer)) > 0 {
+		return "", false
+	}
+	if customer == nil && checkAgeGroup(booking.AgeGroup, candidate.MinimumAge) != nil {
+		return "", false
+	}
+	if candidate.AdultPlaces < car.AdultPlaces || candidate.BigLuggage < car.BigLuggage || candidate.SmallLuggage < car.SmallLuggage {
+		return "", false
+	}
+	if candidate.AdultPlaces == car.AdultPlaces && candidate.BigLuggage == car.BigLuggage && candidate.SmallLuggage == car.SmallLuggage {
+		return domain.LikeForLikeReassignment, true
+	}
+	return domain.UpgradeReassignment, true
+}
+
+/*
+Reserved and picked up rents of the car which are not finished at the moment
+*/
+func (rentPr *RentProcessor) activeBookings(carID int, now time.Time) ([]domain.RentInfo, error) {
+	carRents, err := rentPr.rents.GetCarRents(carID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get car rents")
+	}
+	var bookings []domain.RentInfo
+	for _, rent := range carRents {
+		if !modifiableRentStatuses[rent.Status] {
+			continue
+		}
+		if to, err := time.Parse(domain.TimeLayout, rent.ToDate); err == nil && !to.After(now) {
+			continue
+		}
+		bookings = append(bookings, rent)
+	}
+	return bookings, nil
+}
+
+/*
+Move booking to another car keeping its price, availability of the new car is checked again in the same transaction.
+Returns nil when rent is not reserved on the old car anymore
+*/
+func (rentPr *RentProcessor) moveRent(reassignment domain.Reassignment, car domain.Car) (*domain.Reassignment, error) {
+	rent, err := rentPr.rents.GetRent(reassignment.RentID)
+	if err != nil {
+		return nil, err
+	}
+	blackouts, err := rentPr.blackouts.GetCarBlackouts(car.CarID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get car blackouts")
+	}
+	reassignment.CreatedAt = time.Now().UTC().Format(domain.TimeLayout)
+	id, err := rentPr.rents.ReassignRent(reassignment, carDetails(car), func(carRents []domain.RentInfo) error {
+		notAvailable, err := rentPr.carAvailability(*rent, car.CarID, carRents, blackouts)
+		if err != nil {
+			return errors.Wrap(err, "Failed to check car availability")
+		}
+		if notAvailable != nil {
+			return notAvailable
+		}
+		return nil
+	})
+	if err != nil || id == 0 {
+		return nil, err
+	}
+	reassignment.ReassignmentID = int(id)
+	rentPr.logger.WithFields(log.Fields{"rentID": reassignment.RentID, "fromCarID": reassignment.FromCarID, "toCarID": reassignment.ToCarID}).Info("Rent is reassigned")
+	return &reassignment, nil
+}
@@ -0,0 +1,74 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReassignRentsPrefersLikeForLikeCar(test *testing.T) {
+	repos := newTestRepositories(test)
+	damagedCar := insertTestCar(test, repos)
+	smallerCar := rentTestCar
+	smallerCar.AdultPlaces = 2
+	_, err := repos.Cars.InsertCar(smallerCar)
+	require.NoError(test, err)
+	biggerCar := rentTestCar
+	biggerCar.AdultPlaces = 7
+	biggerCarID, err := repos.Cars.InsertCar(biggerCar)
+	require.NoError(test, err)
+	sameCar := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+
+	var rentIDs []int
+	for _, days := range []time.Duration{3, 10} {
+		rent := rentTestRent
+		rent.CarID = damagedCar.CarID
+		rent.FromDate = time.Now().Add(days * 24 * time.Hour).UTC().Format(domain.TimeLayout)
+		rent.ToDate = time.Now().Add((days + 1) * 24 * time.Hour).UTC().Format(domain.TimeLayout)
+		rentID, err := rentProcessor.InsertRentInDB(rent, &damagedCar)
+		require.NoError(test, err)
+		rentIDs = append(rentIDs, int(rentID))
+	}
+	blocking := rentTestRent
+	blocking.CarID = sameCar.CarID
+	blocking.FromDate = time.Now().Add(3 * 24 * time.Hour).UTC().Format(domain.TimeLayout)
+	blocking.ToDate = time.Now().Add(5 * 24 * time.Hour).UTC().Format(domain.TimeLayout)
+	blockingID, err := rentProcessor.InsertRentInDB(blocking, &sameCar)
+	require.NoError(test, err)
+	_, err = rentProcessor.TransitRent(rentIDs[1], PickupRentAction, carState(1000, 100))
+	require.NoError(test, err)
+
+	dryRun, err := rentProcessor.PlanReassignment(damagedCar.CarID, "Damaged")
+	require.NoError(test, err)
+	assert.True(test, dryRun.DryRun)
+	require.Len(test, dryRun.Reassigned, 1)
+	assert.Equal(test, int(biggerCarID), dryRun.Reassigned[0].ToCarID, "Same car is busy and smaller car does not fit")
+	assert.Equal(test, domain.UpgradeReassignment, dryRun.Reassigned[0].Kind)
+	require.Len(test, dryRun.Unassigned, 1)
+	assert.Equal(test, rentIDs[1], dryRun.Unassigned[0].RentID, "Picked up rent is not moved")
+	stored, err := rentProcessor.GetRentFromDB(rentIDs[0])
+	require.NoError(test, err)
+	assert.Equal(test, damagedCar.CarID, stored.CarID, "Dry run moves nothing")
+
+	_, err = repos.Rents.RemoveRent(int(blockingID))
+	require.NoError(test, err)
+	plan, err := rentProcessor.ReassignRents(damagedCar.CarID, "Damaged")
+	require.NoError(test, err)
+	assert.False(test, plan.DryRun)
+	require.Len(test, plan.Reassigned, 1)
+	assert.Equal(test, sameCar.CarID, plan.Reassigned[0].ToCarID, "Like-for-like car is preferred to upgrade")
+	assert.Equal(test, domain.LikeForLikeReassignment, plan.Reassigned[0].Kind)
+	stored, err = rentProcessor.GetRentFromDB(rentIDs[0])
+	require.NoError(test, err)
+	assert.Equal(test, sameCar.CarID, stored.CarID)
+	assert.Equal(test, carDetails(sameCar), stored.CarDetails)
+
+	reassignments, err := rentProcessor.GetRentReassignmentsFromDB(rentIDs[0])
+	require.NoError(test, err)
+	assert.Equal(test, plan.Reassigned, reassignments)
+	assert.Equal(test, "Damaged", reassignments[0].Reason)
+}
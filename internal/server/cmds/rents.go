@@ -1,287 +1,625 @@
 package cmds
 
 import (
-	"car-rental/internal/server/db"
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/cancellation"
+	"car-rental/internal/server/db/query"
 	"car-rental/internal/server/domain"
-	"database/sql"
+	"car-rental/internal/server/fleet"
+	"car-rental/internal/server/licence"
+	"car-rental/internal/server/metrics"
+	"car-rental/internal/server/pricing"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 type RentProcessor struct {
-	dbStruct *db.DBStruct
+	rents        storage.RentRepository
+	cars         storage.CarRepository
+	blackouts    storage.BlackoutRepository
+	branches     storage.BranchRepository
+	customers    storage.CustomerRepository
+	rules        availability.Rules
+	pricing      pricing.Rules
+	cancellation cancellation.Rules
+	licence      licence.Rules
+	logger       *log.Entry
 }
 
-func NewRentProcessor(dbStruct *db.DBStruct) *RentProcessor {
-	return &RentProcessor{dbStruct: dbStruct}
+/*
+Returned when requested car already has bookings or blackouts overlapping requested dates
+*/
+type CarNotAvailableError struct {
+	CarID                  int
+	ConflictingRentIDs     []int
+	ConflictingBlackoutIDs []int
+}
+
+func (err *CarNotAvailableError) Error() string {
+	message := fmt.Sprintf("Car %d is not available in such dates. Conflicting rents: %v", err.CarID, err.ConflictingRentIDs)
+	if len(err.ConflictingBlackoutIDs) > 0 {
+		message += fmt.Sprintf(", conflicting blackouts: %v", err.ConflictingBlackoutIDs)
+	}
+	return message
+}
+
+func NewRentProcessor(repos storage.Repositories) *RentProcessor {
+	return NewRentProcessorWithRules(repos, availability.DefaultRules, pricing.DefaultRules, cancellation.DefaultRules, licence.DefaultRules)
+}
+
+func NewRentProcessorWithRules(repos storage.Repositories, rules availability.Rules, pricingRules pricing.Rules, cancellationRules cancellation.Rules, licenceRules licence.Rules) *RentProcessor {
+	return &RentProcessor{rents: repos.Rents, cars: repos.Cars, blackouts: repos.Blackouts, branches: repos.Branches, customers: repos.Customers, rules: rules, pricing: pricingRules, cancellation: cancellationRules, licence: licenceRules,
+		logger: log.NewEntry(log.StandardLogger())}
+}
+
+/*
+Log with given logger, e.g. the one of the request which is processed
+*/
+func (rentPr *RentProcessor) WithLogger(logger *log.Entry) *RentProcessor {
+	rentPr.logger = logger
+	return rentPr
 }
 
 /*
-Insert rent into DB
+Insert rent into DB, car is nil when it does not exist
 */
-func (rentPr *RentProcessor) InsertRentInDB(rent domain.RentInfo, car domain.Car) (int64, error) {
-	tx, err := rentPr.dbStruct.BeginTransaction()
+func (rentPr *RentProcessor) InsertRentInDB(rent domain.RentInfo, car *domain.Car) (int64, error) {
+	rent, violations, err := rentPr.validateRent(rent, 0, car)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to start a transaction")
+		return 0, err
+	}
+	if len(violations) > 0 {
+		return 0, violations
+	}
+	id, err := rentPr.rents.InsertRent(rent, rentPr.availabilityCheck(rent, car.CarID))
+	if errors.Is(err, storage.ErrRetired) {
+		return 0, validation.Violations{retiredCarViolation(car.CarID)}
+	}
+	var notAvailable *CarNotAvailableError
+	if errors.As(err, &notAvailable) {
+		metrics.RentConflicts.Inc()
+	}
+	if errors.Is(err, storage.ErrOverlap) {
+		metrics.RentConflicts.Inc()
+		return 0, rentPr.overlapError(rent, *car)
+	}
+	if err == nil {
+		metrics.RentsCreated.Inc()
+		rentPr.logger.WithFields(log.Fields{"rentID": id, "carID": car.CarID}).Info("Rent is booked")
+	}
+	return id, err
+}
+
+/*
+Replace rent in DB, new rent is checked and priced again and may overlap only its previous dates.
+Only reserved and picked up rents can be changed, car is nil when it does not exist
+*/
+func (rentPr *RentProcessor) UpdateRentInDB(rent domain.RentInfo, rentID int, car *domain.Car) (int64, error) {
+	stored, err := rentPr.rents.GetRent(rentID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return 0, nil
 	}
-	statement, err := tx.Prepare(db.InsertIntoRentTable)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to prepare stmt")
+		return 0, err
 	}
-	if len(rent.FromDate) == 0 || rent.CarID == 0 || len(rent.ToDate) == 0 {
-		return 0, fmt.Errorf("Rent dates and car ID should be provided")
+	if !modifiableRentStatuses[stored.Status] {
+		return 0, &RentStatusError{RentID: rentID, Status: stored.Status, Action: ModifyRentAction}
 	}
-	conditionerText := "With Air Conditioner"
-	if !car.AirConditioner {
-		conditionerText = "Without Air Conditioner"
+	rent, violations, err := rentPr.validateRent(rent, rentID, car)
+	if err != nil {
+		return 0, err
+	}
+	if len(violations) > 0 {
+		return 0, violations
+	}
+	rent.RentID = rentID
+	affected, err := rentPr.rents.UpdateRent(rent, rentID, rentPr.availabilityCheck(rent, car.CarID))
+	if errors.Is(err, storage.ErrRetired) {
+		return 0, validation.Violations{retiredCarViolation(car.CarID)}
+	}
+	var notAvailable *CarNotAvailableError
+	if errors.As(err, &notAvailable) {
+		metrics.RentConflicts.Inc()
+	}
+	if errors.Is(err, storage.ErrOverlap) {
+		metrics.RentConflicts.Inc()
+		return 0, rentPr.overlapError(rent, *car)
+	}
+	if err != nil || affected > 0 {
+		return affected, err
+	}
+	// Rent was removed or changed its status after it was read
+	return 0, rentPr.modifyStatusError(rentID)
+}
+
+/*
+Status error of rent which was not updated, nil when rent does not exist anymore
+*/
+func (rentPr *RentProcessor) modifyStatusError(rentID int) error {
+	stored, err := rentPr.rents.GetRent(rentID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
-	if !checkCarProps(rent, car) {
-		return 0, fmt.Errorf("Some of new rent props are incorrect. Please check them again!")
+	if !modifiableRentStatuses[stored.Status] {
+		return &RentStatusError{RentID: rentID, Status: stored.Status, Action: ModifyRentAction}
 	}
-	if isExists, err := rentPr.checkCarAvailability(rent, car); err != nil || isExists {
+	return nil
+}
+
+/*
+Car may be retired after it was read, storage checks it again when rent is written
+*/
+func retiredCarViolation(carID int) validation.Violation {
+	return *validation.New("carID", validation.NotAvailable, "Car [%d] is retired", carID)
+}
+
+/*
+Check which storage runs on rents and blackouts of the car in the transaction which writes rent
+*/
+func (rentPr *RentProcessor) availabilityCheck(rent domain.RentInfo, carID int) storage.RentCheck {
+	return func(carRents []domain.RentInfo, blackouts []domain.Blackout) error {
+		notAvailable, err := rentPr.carAvailability(rent, carID, carRents, blackouts)
 		if err != nil {
-			log.Error(err)
+			return errors.Wrap(err, "Failed to check car availability")
 		}
-		return 0, fmt.Errorf("Car is not available in such dates")
-	}
-	res, err := statement.Exec(rent.CarID,
-		rent.FromDate,
-		rent.ToDate,
-		rent.Location,
-		strings.Join(rent.AvailableExtras, ","),
-		strings.Join(rent.Discounts, ","),
-		fmt.Sprintf(`%s %s.Part of %d group. With %d doors, %d adult places, %d big luggage and %d small luggage places.%s. For drivers with minimal age %d`,
-			car.CarCompanyName,
-			car.Description,
-			car.CarGroup,
-			car.Doors,
-			car.AdultPlaces,
-			car.BigLuggage,
-			car.SmallLuggage,
-			conditionerText,
-			car.MinimumAge),
-	)
-	if err != nil {
-		return 0, errors.Wrap(err, "Failed to execute a prepared statement")
+		if notAvailable != nil {
+			return notAvailable
+		}
+		return nil
 	}
-	id, err := res.LastInsertId()
+}
+
+/*
+Check rent the same way as it is checked before insert without storing it.
+Returns priced rent or every reason why it can not be booked, car is nil when it does not exist
+*/
+func (rentPr *RentProcessor) QuoteRent(rent domain.RentInfo, car *domain.Car) (*domain.RentInfo, validation.Violations, error) {
+	rent, violations, err := rentPr.validateRent(rent, 0, car)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to execute a extract last id")
+		return nil, nil, err
+	}
+	if car != nil {
+		if _, err := parseRentInterval(rent.FromDate, rent.ToDate); err == nil {
+			notAvailable, err := rentPr.checkCarAvailability(rent, *car)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "Failed to check car availability")
+			}
+			if notAvailable != nil {
+				violations = append(violations, *validation.New(domain.FromDateUrlValue, validation.NotAvailable, notAvailable.Error()))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return nil, violations, nil
+	}
+	return &rent, nil, nil
+}
+
+/*
+Collect every violation of rent except availability of the car, car is nil when it does not exist.
+Rent with rentID is replaced by validated rent, so it does not decide where the car is.
+Valid rent is returned with resolved branches, price and car details
+*/
+func (rentPr *RentProcessor) validateRent(rent domain.RentInfo, rentID int, car *domain.Car) (domain.RentInfo, validation.Violations, error) {
+	// Rent ID is decided by storage, provided one must not exclude any rent from availability check
+	rent.RentID = 0
+	var violations validation.Violations
+	var violation *validation.Violation
+	if rent.CarID == 0 {
+		violations = append(violations, *validation.New("carID", validation.Required, "Car ID should be provided"))
+	} else if car == nil {
+		violations = append(violations, *validation.New("carID", validation.NotFound, "Car [%d] does not exist", rent.CarID))
+	} else if len(car.RetiredAt) > 0 {
+		violations = append(violations, retiredCarViolation(rent.CarID))
+	}
+	dates, err := query.ParseDateRange(domain.FromDateUrlValue, rent.FromDate, domain.ToDateUrlValue, rent.ToDate)
+	datesAreValid := err == nil
+	if errors.As(err, &violation) {
+		violations = append(violations, *violation)
+	} else if datesAreValid {
+		// Dates are stored in one format, storage compares them as strings
+		rent.FromDate = dates.From.Format(domain.TimeLayout)
+		rent.ToDate = dates.To.Format(domain.TimeLayout)
+	}
+	customer, err := rentPr.findCustomer(rent.CustomerID)
+	if errors.As(err, &violation) {
+		violations = append(violations, *violation)
+	} else if err != nil {
+		return rent, nil, err
+	} else if datesAreValid {
+		// Age group of the rent is the driver age at pickup, client value is not trusted
+		rent.AgeGroup = strconv.Itoa(customerAge(*customer, dates.From))
+	}
+	branch, err := rentPr.findRentBranch(rent)
+	if errors.As(err, &violation) {
+		violations = append(violations, *violation)
+	} else if err != nil {
+		return rent, nil, err
+	} else {
+		rent.BranchID = branch.BranchID
+		rent.Location = branch.Name
+	}
+	returnBranch, err := rentPr.findReturnBranch(rent, branch)
+	if errors.As(err, &violation) {
+		violations = append(violations, *violation)
+	} else if err != nil {
+		return rent, nil, err
+	} else if returnBranch != nil {
+		rent.ReturnBranchID = returnBranch.BranchID
+		rent.ReturnLocation = returnBranch.Name
 	}
-	if err := tx.Commit(); err != nil {
-		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	if car == nil {
+		return rent, violations, nil
 	}
 
-	return id, nil
+	var requested *availability.Interval
+	if datesAreValid {
+		requested = &availability.Interval{From: dates.From, To: dates.To}
+	}
+	// Unknown branches are already reported
+	if branch != nil && returnBranch != nil {
+		carRents, err := rentPr.otherCarRents(car.CarID, rentID)
+		if err != nil {
+			return rent, nil, err
+		}
+		blackouts, err := rentPr.blackouts.GetCarBlackouts(car.CarID)
+		if err != nil {
+			return rent, nil, errors.Wrap(err, "Failed to get car blackouts")
+		}
+		violations = append(violations, checkCarPosition(rent, *car, carRents, blackouts, requested)...)
+	}
+	violations = append(violations, checkCarProps(rent, *car, customer)...)
+	if customer != nil && requested != nil {
+		violations = append(violations, rentPr.licence.Check(*customer, car.CarGroup, requested.From, requested.To)...)
+	}
+	if requested != nil {
+		quote, err := rentPr.pricing.Quote(car.Price, *requested, rent.AvailableExtras, rent.Discounts, fleet.IsOneWay(rent))
+		if errors.As(err, &violation) {
+			violations = append(violations, *violation)
+		} else if err != nil {
+			return rent, nil, err
+		} else {
+			rent.Quote = &quote
+		}
+	}
+	rent.CarDetails = carDetails(*car)
+	return rent, violations, nil
 }
 
 /*
 Get rents from DB
 */
 func (rentPr *RentProcessor) GetRentsFromDB() ([]domain.RentInfo, error) {
-	rows, err := rentPr.dbStruct.Query(db.SelectRents)
+	return rentPr.rents.GetRents()
+}
+
+/*
+Get rent from DB
+*/
+func (rentPr *RentProcessor) GetRentFromDB(rentID int) (*domain.RentInfo, error) {
+	return rentPr.rents.GetRent(rentID)
+}
+
+/*
+Find branch of rent by branch ID or by location name, branch ID and location should point to the same branch
+*/
+func (rentPr *RentProcessor) findRentBranch(rent domain.RentInfo) (*domain.Branch, error) {
+	if rent.BranchID == 0 && len(rent.Location) == 0 {
+		return nil, validation.New("location", validation.Required, "Rent location should be provided")
+	}
+	return rentPr.findBranch(rent.BranchID, rent.Location, "branchID", "location")
+}
+
+/*
+Find branch by ID or by name, when both are provided they should point to the same branch.
+Violations are reported for provided fields
+*/
+func (rentPr *RentProcessor) findBranch(branchID int, name string, branchIDField string, nameField string) (*domain.Branch, error) {
+	if branchID == 0 {
+		branch, err := rentPr.branches.GetBranchByName(name)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, validation.New(nameField, validation.NotFound, "Branch [%s] does not exist", name)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to find rent branch")
+		}
+		return branch, nil
+	}
+	branch, err := rentPr.branches.GetBranch(branchID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, validation.New(branchIDField, validation.NotFound, "Branch [%d] does not exist", branchID)
+	}
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to execute a sql query")
+		return nil, errors.Wrap(err, "Failed to find rent branch")
+	}
+	if len(name) > 0 && name != branch.Name {
+		return nil, validation.New(nameField, validation.Mismatch, "[%s] is not a name of branch %d", name, branchID)
+	}
+	return branch, nil
+}
+
+/*
+Drop-off branch is found by return branch ID or by return location name, rent without them is returned to pickup branch.
+Nil is returned when pickup branch is unknown and drop-off branch is not provided
+*/
+func (rentPr *RentProcessor) findReturnBranch(rent domain.RentInfo, branch *domain.Branch) (*domain.Branch, error) {
+	if rent.ReturnBranchID == 0 && len(rent.ReturnLocation) == 0 {
+		return branch, nil
 	}
+	return rentPr.findBranch(rent.ReturnBranchID, rent.ReturnLocation, "returnBranchID", "returnLocation")
+}
 
+/*
+Rents of the car without the rent with skipped ID
+*/
+func (rentPr *RentProcessor) otherCarRents(carID int, skippedRentID int) ([]domain.RentInfo, error) {
+	carRents, err := rentPr.rents.GetCarRents(carID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get car rents")
+	}
 	var result []domain.RentInfo
-	for rows.Next() {
-		var receivedRow domain.RentInfo
-		var extras string
-		var discounts string
-
-		err = rows.Scan(
-			&receivedRow.RentID,
-			&receivedRow.CarID,
-			&receivedRow.FromDate,
-			&receivedRow.ToDate,
-			&receivedRow.Location,
-			&extras,
-			&discounts,
-			&receivedRow.CarDetails,
-		)
-		if err != nil {
-			log.Error(err)
+	for _, carRent := range carRents {
+		if skippedRentID != 0 && carRent.RentID == skippedRentID {
 			continue
 		}
-		receivedRow.AvailableExtras = strings.Split(extras, ",")
-		receivedRow.Discounts = strings.Split(discounts, ",")
-		result = append(result, receivedRow)
+		result = append(result, carRent)
 	}
 	return result, nil
 }
 
 /*
-Get rent from DB
+Car is picked up where previous rent or transfer left it and should be returned where next rent picks it up.
+Car which was not moved before is picked up at any of its branches. Requested dates are nil when they are not valid
 */
-func (rentPr *RentProcessor) GetRentFromDB(rentID int) (*domain.RentInfo, error) {
-	stmt, err := rentPr.dbStruct.Prepare(fmt.Sprintf("%s WHERE rent_id=?", db.SelectRents))
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to prepare an sql query")
-	}
-	row := stmt.QueryRow(rentID)
-	var receivedRow domain.RentInfo
-	var extras string
-	var discounts string
-
-	err = row.Scan(
-		&receivedRow.RentID,
-		&receivedRow.CarID,
-		&receivedRow.FromDate,
-		&receivedRow.ToDate,
-		&receivedRow.Location,
-		&extras,
-		&discounts,
-		&receivedRow.CarDetails,
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to query rent")
+func checkCarPosition(rent domain.RentInfo, car domain.Car, carRents []domain.RentInfo, blackouts []domain.Blackout, requested *availability.Interval) validation.Violations {
+	var violations validation.Violations
+	timeline := fleet.NewTimeline(car, carRents, blackouts)
+	var position *domain.CarPosition
+	if requested != nil {
+		position = timeline.PositionAt(requested.From)
+	}
+	if position == nil {
+		locationExists := false
+		for _, branchID := range car.BranchIDs {
+			if branchID == rent.BranchID {
+				locationExists = true
+				break
+			}
+		}
+		for _, loc := range car.AvailableLocations {
+			if loc == rent.Location {
+				locationExists = true
+				break
+			}
+		}
+		if !locationExists {
+			violations = append(violations, *validation.New("location", validation.Mismatch, "Please provide correct location for this car"))
+		}
+	} else if position.Location != rent.Location {
+		violations = append(violations, *validation.New("location", validation.Mismatch, "Car is at branch [%s] since %s", position.Location, position.Since))
+	}
+	if requested == nil {
+		return violations
 	}
-	receivedRow.AvailableExtras = strings.Split(extras, ",")
-	receivedRow.Discounts = strings.Split(discounts, ",")
+	// Transfer takes the car wherever it is
+	if next := timeline.NextMove(requested.To); next != nil && len(next.Location) > 0 && next.Location != fleet.ReturnLocation(rent) {
+		violations = append(violations, *validation.New("returnLocation", validation.Mismatch, "Car should be returned to branch [%s], rent %d picks it up there", next.Location, next.RentID))
+	}
+	return violations
+}
 
-	return &receivedRow, nil
+/*
+Check if car information is correct in provided rent data, every found mismatch is returned.
+Driver should have car minimum age at pickup date, age is not checked when customer or dates are unknown.
+Location is checked against position of the car by checkCarPosition
+*/
+func checkCarProps(rent domain.RentInfo, car domain.Car, customer *domain.Customer) validation.Violations {
+	var violations validation.Violations
+	if rent.CarGroup != car.CarGroup {
+		violations = append(violations, *validation.New("carGroup", validation.Mismatch, "Please provide correct car group"))
+	}
+	if from, err := time.Parse(domain.TimeLayout, rent.FromDate); err == nil && customer != nil {
+		if age := customerAge(*customer, from); age < car.MinimumAge {
+			violations = append(violations, *validation.New("customerID", validation.Mismatch, "Driver is %d years old at pickup, car requires at least %d", age, car.MinimumAge))
+		}
+	}
+	return violations
 }
 
 /*
-Remove rent from DB
+Find customer who rents the car, unknown or missing customer is returned as violation
 */
-func (rentPr *RentProcessor) RemoveRentFromDB(rentID int) (int64, error) {
-	stmt, err := rentPr.dbStruct.Prepare(db.RemoveRent)
+func (rentPr *RentProcessor) findCustomer(customerID int) (*domain.Customer, error) {
+	if customerID == 0 {
+		return nil, validation.New("customerID", validation.Required, "Customer ID should be provided")
+	}
+	customer, err := rentPr.customers.GetCustomer(customerID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, validation.New("customerID", validation.NotFound, "Customer [%d] does not exist", customerID)
+	}
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to prepare an sql query")
+		return nil, errors.Wrap(err, "Failed to get customer")
 	}
+	return customer, nil
+}
 
-	res, err := stmt.Exec(rentID)
+/*
+Age group of rents stored before customers were introduced is either minimal driver age or interval "min-max"
+which should contain car minimum age
+*/
+func checkAgeGroup(ageGroup string, minimumAge int) *validation.Violation {
+	if len(ageGroup) == 0 {
+		return validation.New("ageGroup", validation.Required, "Rent age group should be provided")
+	}
+	incorrectFormat := validation.New("ageGroup", validation.InvalidFormat, "[%s] should be in format min or min-max", ageGroup)
+	splittedAge := strings.Split(ageGroup, "-")
+	if len(splittedAge) > 2 {
+		return incorrectFormat
+	}
+	parsedMin, err := strconv.Atoi(splittedAge[0])
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to execute rent delete")
+		return incorrectFormat
+	}
+	fits := parsedMin >= minimumAge
+	if len(splittedAge) > 1 {
+		parsedMax, err := strconv.Atoi(splittedAge[1])
+		if err != nil {
+			return incorrectFormat
+		}
+		fits = parsedMin < parsedMax && minimumAge > parsedMin && minimumAge < parsedMax
+	}
+	if !fits {
+		return validation.New("ageGroup", validation.Mismatch, "Please provide correct age interval")
 	}
+	return nil
+}
 
-	affect, err := res.RowsAffected()
+/*
+Find rents and blackouts of the car which conflict with requested rent dates, nil is returned when car is available
+*/
+func (rentPr *RentProcessor) checkCarAvailability(rent domain.RentInfo, car domain.Car) (*CarNotAvailableError, error) {
+	carRents, err := rentPr.rents.GetCarRents(car.CarID)
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+		return nil, err
 	}
-
-	return affect, nil
+	blackouts, err := rentPr.blackouts.GetCarBlackouts(car.CarID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get car blackouts")
+	}
+	return rentPr.carAvailability(rent, car.CarID, carRents, blackouts)
 }
 
 /*
-Check if car information is correct in provided rent data
+Check requested rent against provided rents and blackouts of the car, nil is returned when car is available
 */
-func checkCarProps(rent domain.RentInfo, car domain.Car) bool {
-	locationExists := false
-	groupIsOK := false
-	ageIsOK := false
-	for _, loc := range car.AvailableLocations {
-		if loc == rent.Location {
-			locationExists = true
-			break
-		}
+func (rentPr *RentProcessor) carAvailability(rent domain.RentInfo, carID int, carRents []domain.RentInfo, blackouts []domain.Blackout) (*CarNotAvailableError, error) {
+	conflicts, err := rentPr.findConflicts(rent, carRents)
+	if err != nil {
+		return nil, err
 	}
-	if len(rent.AgeGroup) > 0 {
-		failedConversation := false
-		splittedAge := strings.Split(rent.AgeGroup, "-")
-		parsedMin, err := strconv.Atoi(splittedAge[0])
-		if err != nil {
-			log.Error(err)
-			failedConversation = true
-		}
-		if !failedConversation {
-			if len(splittedAge) > 1 {
-				parsedMax, err := strconv.Atoi(splittedAge[1])
-				if err != nil {
-					log.Error(err)
-					failedConversation = true
-				}
-				if parsedMin < parsedMax && car.MinimumAge > parsedMin && car.MinimumAge < parsedMax {
-					ageIsOK = true
-				} else {
-					log.Error("Please provide correct age interval")
-				}
-			} else {
-				if parsedMin > car.MinimumAge {
-					ageIsOK = true
-				}
-			}
-		}
+	blackoutConflicts, err := rentPr.findBlackoutConflicts(rent, blackouts)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) == 0 && len(blackoutConflicts) == 0 {
+		return nil, nil
+	}
+	return &CarNotAvailableError{CarID: carID, ConflictingRentIDs: conflicts, ConflictingBlackoutIDs: blackoutConflicts}, nil
+}
 
+/*
+Storage rejected overlapping rent, conflicts are found again to be reported
+*/
+func (rentPr *RentProcessor) overlapError(rent domain.RentInfo, car domain.Car) error {
+	notAvailable, err := rentPr.checkCarAvailability(rent, car)
+	if err != nil {
+		return errors.Wrap(err, "Failed to check car availability")
 	}
-	if rent.CarGroup == car.CarGroup {
-		groupIsOK = true
-	} else {
-		log.Error("Please provide correct car group")
+	if notAvailable == nil {
+		return &CarNotAvailableError{CarID: car.CarID}
 	}
+	return notAvailable
+}
 
-	if !locationExists {
-		log.Error("Please provide correct location for this car")
+/*
+Blackouts are checked by the same rules as rents, so cleaning buffer applies around them too
+*/
+func (rentPr *RentProcessor) findBlackoutConflicts(rent domain.RentInfo, blackouts []domain.Blackout) ([]int, error) {
+	requested, err := parseRentInterval(rent.FromDate, rent.ToDate)
+	if err != nil {
+		return nil, err
 	}
-	if !ageIsOK {
-		log.Error("Please provide correct age interval")
+	var bookings []availability.Booking
+	for _, blackout := range blackouts {
+		blocked, err := parseRentInterval(blackout.FromDate, blackout.ToDate)
+		if err != nil {
+			rentPr.logger.Error(errors.Wrapf(err, "Blackout %d has broken dates", blackout.BlackoutID))
+			continue
+		}
+		bookings = append(bookings, availability.Booking{RentID: blackout.BlackoutID, Interval: blocked})
 	}
-	return locationExists && groupIsOK && ageIsOK
+	return rentPr.rules.FindConflicts(requested, bookings), nil
 }
 
 /*
-Check if car still available
+Find rents conflicting with requested rent dates, stored version of the requested rent and cancelled rents are skipped
 */
-func (rentPr *RentProcessor) checkCarAvailability(rent domain.RentInfo, car domain.Car) (bool, error) {
-	searchParams := buildFromToFilter(rent.FromDate, rent.ToDate, true)
-	query := db.SelectCarsRents + " WHERE " + searchParams.DateFilter
-	log.Debugln(query)
-	rows, err := rentPr.dbStruct.Query(query)
+func (rentPr *RentProcessor) findConflicts(rent domain.RentInfo, carRents []domain.RentInfo) ([]int, error) {
+	requested, err := parseRentInterval(rent.FromDate, rent.ToDate)
 	if err != nil {
-		return false, errors.Wrap(err, "Failed to execute a sql query")
-	}
-
-	var result []domain.CombinedRentInfo
-	for rows.Next() {
-		var receivedRow domain.CombinedRentInfo
-		var locations string
-		var rentID sql.NullInt32
-		var fromDate sql.NullString
-		var toDate sql.NullString
-		var location sql.NullString
-		var extras sql.NullString
-		var discounts sql.NullString
-		var carDetails sql.NullString
-		err = rows.Scan(&receivedRow.CarID,
-			&receivedRow.CarCompanyName,
-			&receivedRow.Doors,
-			&receivedRow.BigLuggage,
-			&receivedRow.SmallLuggage,
-			&receivedRow.AdultPlaces,
-			&receivedRow.AirConditioner,
-			&receivedRow.MinimumAge,
-			&locations,
-			&receivedRow.CarGroup,
-			&receivedRow.Description,
-			&receivedRow.Price,
-			&rentID,
-			&fromDate,
-			&toDate,
-			&location,
-			&extras,
-			&discounts,
-			&carDetails,
-		)
+		return nil, err
+	}
+	return rentPr.rules.FindConflicts(requested, rentBookings(rentPr.logger, carRents, rent.RentID)), nil
+}
+
+/*
+Rents which hold the car, rent with skipped ID and cancelled rents are left out
+*/
+func rentBookings(logger *log.Entry, carRents []domain.RentInfo, skippedRentID int) []availability.Booking {
+	var bookings []availability.Booking
+	for _, carRent := range carRents {
+		if skippedRentID != 0 && carRent.RentID == skippedRentID {
+			continue
+		}
+		if carRent.Status == domain.CancelledRentStatus {
+			continue
+		}
+		booked, err := parseRentInterval(carRent.FromDate, carRent.ToDate)
 		if err != nil {
-			log.Error(err)
+			logger.Error(errors.Wrapf(err, "Rent %d has broken dates", carRent.RentID))
 			continue
 		}
+		bookings = append(bookings, availability.Booking{RentID: carRent.RentID, Interval: booked})
+	}
+	return bookings
+}
 
-		receivedRow.RentID = rentID.Int32
-		receivedRow.FromDate = fromDate.String
-		receivedRow.ToDate = toDate.String
-		receivedRow.Location = location.String
-		receivedRow.AvailableExtras = strings.Split(extras.String, ",")
-		receivedRow.Discounts = strings.Split(discounts.String, ",")
-		receivedRow.AvailableLocations = strings.Split(locations, ",")
-		receivedRow.CarDetails = carDetails.String
-		result = append(result, receivedRow)
-	}
-	return len(result) != 0, nil
+/*
+Parse rent dates into half-open interval
+*/
+func parseRentInterval(from string, to string) (availability.Interval, error) {
+	var interval availability.Interval
+	var err error
+	interval.From, err = time.Parse(domain.TimeLayout, from)
+	if err != nil {
+		return interval, errors.Wrap(err, "Incorrect from date")
+	}
+	interval.To, err = time.Parse(domain.TimeLayout, to)
+	if err != nil {
+		return interval, errors.Wrap(err, "Incorrect to date")
+	}
+	if !interval.IsValid() {
+		return interval, fmt.Errorf("Please provide correct dates, from must be less than to")
+	}
+	return interval, nil
+}
+
+/*
+Human readable description of a car stored together with the rent
+*/
+func carDetails(car domain.Car) string {
+	conditionerText := "With Air Conditioner"
+	if !car.AirConditioner {
+		conditionerText = "Without Air Conditioner"
+	}
+	return fmt.Sprintf(`%s %s.Part of %d group. With %d doors, %d adult places, %d big luggage and %d small luggage places.%s. For drivers with minimal age %d`,
+		car.CarCompanyName,
+		car.Description,
+		car.CarGroup,
+		car.Doors,
+		car.AdultPlaces,
+		car.BigLuggage,
+		car.SmallLuggage,
+		conditionerText,
+		car.MinimumAge)
 }
@@ -0,0 +1,441 @@
+package cmds
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/cancellation"
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/db"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/licence"
+	"car-rental/internal/server/pricing"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/storage/memory"
+	"car-rental/internal/server/storage/sqlite"
+	"car-rental/internal/server/validation"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	rentTestCar = domain.Car{
+		CarCompanyName:     "Kia",
+		Doors:              5,
+		AdultPlaces:        4,
+		MinimumAge:         30,
+		Price:              120,
+		AvailableLocations: []string{"Haifa"},
+		CarGroup:           2,
+		Description:        "Brand new car",
+	}
+	rentTestCustomer = domain.Customer{
+		Name:              "Dana Levi",
+		Email:             "dana@example.com",
+		DateOfBirth:       "1981-06-20",
+		LicenceNumber:     "1234567",
+		LicenceCountry:    "IL",
+		LicenceExpiry:     "2030-06-20",
+		LicenceIssued:     "2000-02-01",
+		LicenceCategories: []string{"B"},
+	}
+	testBranches = []domain.Branch{
+		{Name: "Jerusalem", Latitude: 31.7683, Longitude: 35.2137, Timezone: "Asia/Jerusalem"},
+		{Name: "Tel Aviv", Latitude: 32.0853, Longitude: 34.7818, Timezone: "Asia/Jerusalem"},
+		{Name: "Haifa", Latitude: 32.7940, Longitude: 34.9896, Timezone: "Asia/Jerusalem"},
+		{Name: "Holon", Latitude: 32.0158, Longitude: 34.7874, Timezone: "Asia/Jerusalem"},
+	}
+	rentTestRent = domain.RentInfo{
+		CustomerID: 1,
+		FromDate:   "2022-01-15T10:00:00Z",
+		ToDate:     "2022-01-16T10:00:00Z",
+		Location:   "Haifa",
+		CarGroup:   2,
+	}
+)
+
+/*
+Memory repositories with test branches and customer of rentTestRent
+*/
+func newTestRepositories(test *testing.T) storage.Repositories {
+	return withTestData(test, memory.NewRepositories())
+}
+
+/*
+SQLite repositories with test data, storage compares dates as strings
+*/
+func newSQLiteTestRepositories(test *testing.T) storage.Repositories {
+	dbStruct, err := db.NewDBStruct(config.DBConfig{DSN: fmt.Sprintf("file:%s?mode=memory&cache=shared&_fk=true", test.Name())})
+	require.NoError(test, err)
+	test.Cleanup(func() { dbStruct.Close() })
+	return withTestData(test, sqlite.NewRepositories(dbStruct))
+}
+
+func withTestData(test *testing.T, repos storage.Repositories) storage.Repositories {
+	for _, branch := range testBranches {
+		_, err := repos.Branches.InsertBranch(branch)
+		require.NoError(test, err)
+	}
+	id, err := repos.Customers.InsertCustomer(rentTestCustomer)
+	require.NoError(test, err)
+	require.Equal(test, int64(rentTestRent.CustomerID), id)
+	return repos
+}
+
+func violationCodes(violations validation.Violations) []string {
+	var codes []string
+	for _, violation := range violations {
+		codes = append(codes, violation.Field+":"+violation.Code)
+	}
+	return codes
+}
+
+func insertTestCar(test *testing.T, repos storage.Repositories) domain.Car {
+	id, err := repos.Cars.InsertCar(rentTestCar)
+	require.NoError(test, err)
+	car, err := repos.Cars.GetCar(int(id))
+	require.NoError(test, err)
+	return *car
+}
+
+/*
+Test that rent of one car does not block same dates of another car
+*/
+func TestInsertRentChecksOnlyRequestedCar(test *testing.T) {
+	repos := newTestRepositories(test)
+	firstCar := insertTestCar(test, repos)
+	secondCar := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+
+	rent := rentTestRent
+	rent.CarID = firstCar.CarID
+	firstRentID, err := rentProcessor.InsertRentInDB(rent, &firstCar)
+	require.NoError(test, err)
+
+	rent.CarID = secondCar.CarID
+	_, err = rentProcessor.InsertRentInDB(rent, &secondCar)
+	require.NoError(test, err)
+
+	rent.CarID = firstCar.CarID
+	_, err = rentProcessor.InsertRentInDB(rent, &firstCar)
+	var notAvailableErr *CarNotAvailableError
+	require.True(test, errors.As(err, &notAvailableErr))
+	assert.Equal(test, []int{int(firstRentID)}, notAvailableErr.ConflictingRentIDs)
+}
+
+func TestInsertRentChecksCarProps(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rent.Location = "Holon"
+	rent.CarGroup = 3
+	rent.CustomerID = 0
+	_, err := rentProcessor.InsertRentInDB(rent, &car)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"customerID:required", "location:mismatch", "carGroup:mismatch"}, violationCodes(violations))
+
+	rent.CustomerID = 100
+	_, err = rentProcessor.InsertRentInDB(rent, &car)
+	violations, ok = validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"customerID:not_found", "location:mismatch", "carGroup:mismatch"}, violationCodes(violations))
+
+	rents, err := repos.Rents.GetRents()
+	require.NoError(test, err)
+	assert.Empty(test, rents)
+}
+
+/*
+Test that driver age is counted from date of birth at pickup, the customer turns 41 on 2022-06-20
+*/
+func TestInsertRentChecksDriverAge(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	car.MinimumAge = 41
+	rentProcessor := NewRentProcessor(repos)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rent.AgeGroup = "70"
+	rent.FromDate = "2022-06-19T10:00:00Z"
+	rent.ToDate = "2022-06-21T10:00:00Z"
+	_, err := rentProcessor.InsertRentInDB(rent, &car)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"customerID:mismatch"}, violationCodes(violations))
+	assert.Equal(test, "Driver is 40 years old at pickup, car requires at least 41", violations[0].Message)
+
+	rent.FromDate = "2022-06-20T10:00:00Z"
+	rentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, rentTestRent.CustomerID, received.CustomerID)
+	assert.Equal(test, "41", received.AgeGroup, "Age group is driver age at pickup")
+}
+
+/*
+Test that licence rules are checked together with other rent rules
+*/
+func TestInsertRentChecksLicence(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	vanRules := licence.Rules{Default: licence.GroupRules{
+		Groups:  map[int]licence.Requirement{car.CarGroup: {Categories: []string{"C", "C1"}, MinYearsHeld: 25}},
+		Default: licence.DefaultRequirement,
+	}}
+	rentProcessor := NewRentProcessorWithRules(repos, availability.DefaultRules, pricing.DefaultRules, cancellation.DefaultRules, vanRules)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rent.ToDate = "2030-06-22T10:00:00Z"
+	_, err := rentProcessor.InsertRentInDB(rent, &car)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"customerID:mismatch", "customerID:mismatch", "customerID:mismatch"}, violationCodes(violations))
+	assert.Equal(test, "Driver licence expires on 2030-06-20 before return", violations[0].Message)
+	assert.Equal(test, "Driver licence categories [B] do not include any of [C C1] required for car group 2", violations[1].Message)
+	assert.Equal(test, "Driver licence is held 21 years at pickup, car group 2 requires at least 25", violations[2].Message)
+
+	rent.ToDate = rentTestRent.ToDate
+	_, err = NewRentProcessor(repos).InsertRentInDB(rent, &car)
+	require.NoError(test, err, "Default rules accept category B held one year")
+}
+
+func TestInsertRentResolvesBranch(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	haifa, err := repos.Branches.GetBranchByName("Haifa")
+	require.NoError(test, err)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, haifa.BranchID, received.BranchID)
+
+	incorrectRents := []struct {
+		violation string
+		location  string
+		branchID  int
+	}{
+		{violation: "location:not_found", location: "Eilat"},
+		{violation: "branchID:not_found", branchID: 1000},
+		{violation: "location:mismatch", location: "Holon", branchID: haifa.BranchID},
+	}
+	for _, incorrect := range incorrectRents {
+		rent := rentTestRent
+		rent.CarID = car.CarID
+		rent.FromDate = "2022-02-15T10:00:00Z"
+		rent.ToDate = "2022-02-16T10:00:00Z"
+		rent.Location = incorrect.location
+		rent.BranchID = incorrect.branchID
+		_, err := rentProcessor.InsertRentInDB(rent, &car)
+		violations, ok := validation.From(err)
+		require.True(test, ok, "Rent %+v should not be inserted", incorrect)
+		assert.Equal(test, []string{incorrect.violation}, violationCodes(violations))
+	}
+}
+
+func TestInsertRentStoresQuote(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rent.AvailableExtras = []string{"GPS"}
+	rent.Discounts = []string{"10%"}
+	rentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	require.NotNil(test, received.Quote)
+	assert.Equal(test, 1, received.Quote.Days)
+	assert.Equal(test, int64(13000), received.Quote.Subtotal)
+	assert.Equal(test, int64(1300), received.Quote.Discount)
+	assert.Equal(test, int64(13689), received.Quote.Total)
+
+	rent.FromDate = "2022-02-15T10:00:00Z"
+	rent.ToDate = "2022-02-16T10:00:00Z"
+	rent.Discounts = []string{"Days"}
+	_, err = rentProcessor.InsertRentInDB(rent, &car)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"discounts:invalid_format"}, violationCodes(violations))
+}
+
+func TestQuoteRentReportsEveryViolation(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	quoted, violations, err := rentProcessor.QuoteRent(rent, &car)
+	require.NoError(test, err)
+	assert.Empty(test, violations)
+	require.NotNil(test, quoted.Quote)
+	assert.Equal(test, int64(12000), quoted.Quote.Subtotal)
+
+	_, err = rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	rent.CarGroup = 3
+	rent.AvailableExtras = []string{"Jetpack"}
+	car.MinimumAge = 50
+	quoted, violations, err = rentProcessor.QuoteRent(rent, &car)
+	require.NoError(test, err)
+	assert.Nil(test, quoted)
+	assert.Equal(test, []string{"carGroup:mismatch", "customerID:mismatch", "availableExtras:not_allowed", "fromDate:not_available"}, violationCodes(violations))
+
+	_, violations, err = rentProcessor.QuoteRent(domain.RentInfo{CarID: 100, Location: "Nowhere"}, nil)
+	require.NoError(test, err)
+	assert.Len(test, violations, 4, "Missing car, missing dates, missing customer and unknown branch are reported together")
+
+	rents, err := repos.Rents.GetRents()
+	require.NoError(test, err)
+	assert.Len(test, rents, 1, "Quotes are not stored as rents")
+}
+
+func TestUpdateRentRechecksRent(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	nextRent := rent
+	nextRent.FromDate = "2022-01-17T10:00:00Z"
+	nextRent.ToDate = "2022-01-18T10:00:00Z"
+	nextRentID, err := rentProcessor.InsertRentInDB(nextRent, &car)
+	require.NoError(test, err)
+
+	rent.ToDate = "2022-01-17T10:00:00Z"
+	affected, err := rentProcessor.UpdateRentInDB(rent, int(rentID), &car)
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, 2, received.Quote.Days, "Rent is priced again")
+
+	rent.ToDate = "2022-01-17T12:00:00Z"
+	_, err = rentProcessor.UpdateRentInDB(rent, int(rentID), &car)
+	var notAvailableErr *CarNotAvailableError
+	require.True(test, errors.As(err, &notAvailableErr))
+	assert.Equal(test, []int{int(nextRentID)}, notAvailableErr.ConflictingRentIDs)
+
+	rent.ToDate = "2022-01-16T10:00:00Z"
+	rent.CarGroup = 3
+	_, err = rentProcessor.UpdateRentInDB(rent, int(rentID), &car)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"carGroup:mismatch"}, violationCodes(violations))
+	received, err = repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, "2022-01-17T10:00:00Z", received.ToDate, "Rejected update is not applied")
+}
+
+/*
+Test that one-way rent is charged with the fee and later rents and searches find the car at drop-off branch
+*/
+func TestOneWayRentMovesCar(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	carProcessor := NewCarProcessor(repos)
+	jerusalem, err := repos.Branches.GetBranchByName("Jerusalem")
+	require.NoError(test, err)
+
+	oneWay := rentTestRent
+	oneWay.CarID = car.CarID
+	oneWay.ReturnLocation = "Jerusalem"
+	rentID, err := rentProcessor.InsertRentInDB(oneWay, &car)
+	require.NoError(test, err)
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, jerusalem.BranchID, received.ReturnBranchID)
+	assert.Equal(test, domain.OneWayPriceItem, received.Quote.Items[1].Kind)
+
+	later := rentTestRent
+	later.CarID = car.CarID
+	later.FromDate = "2022-01-20T10:00:00Z"
+	later.ToDate = "2022-01-21T10:00:00Z"
+	_, err = rentProcessor.InsertRentInDB(later, &car)
+	violations, ok := validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"location:mismatch"}, violationCodes(violations))
+
+	later.Location = "Jerusalem"
+	_, err = rentProcessor.InsertRentInDB(later, &car)
+	require.NoError(test, err)
+
+	search := map[string][]string{
+		domain.FromDateUrlValue: {"2022-01-17T10:00:00Z"},
+		domain.ToDateUrlValue:   {"2022-01-18T10:00:00Z"},
+		domain.LocationUrlValue: {"Jerusalem"},
+	}
+	found, err := carProcessor.GetCarsFromDBWithParams(search)
+	require.NoError(test, err)
+	require.NotEmpty(test, found)
+	for _, info := range found {
+		assert.Equal(test, car.CarID, info.CarID)
+	}
+	search[domain.LocationUrlValue] = []string{"Haifa"}
+	found, err = carProcessor.GetCarsFromDBWithParams(search)
+	require.NoError(test, err)
+	assert.Empty(test, found)
+
+	// Car should be back in Jerusalem for the later rent
+	stranding := rentTestRent
+	stranding.CarID = car.CarID
+	stranding.FromDate = "2022-01-17T10:00:00Z"
+	stranding.ToDate = "2022-01-18T10:00:00Z"
+	stranding.Location = "Jerusalem"
+	stranding.ReturnLocation = "Haifa"
+	_, err = rentProcessor.InsertRentInDB(stranding, &car)
+	violations, ok = validation.From(err)
+	require.True(test, ok)
+	assert.Equal(test, []string{"returnLocation:mismatch"}, violationCodes(violations))
+
+	positions, err := carProcessor.GetCarPositionsFromDB(car.CarID)
+	require.NoError(test, err)
+	require.Len(test, positions, 2)
+	assert.Equal(test, "Jerusalem", positions[0].Location)
+}
+
+/*
+Test that rent dates with offset are stored in UTC, so search compares them with UTC dates
+*/
+func TestRentDatesAreStoredInUTC(test *testing.T) {
+	repos := newSQLiteTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rent.FromDate = "2022-01-15T12:00:00+02:00"
+	rent.ToDate = "2022-01-16T12:00:00.500+02:00"
+	rentID, err := rentProcessor.InsertRentInDB(rent, &car)
+	require.NoError(test, err)
+	stored, err := rentProcessor.GetRentFromDB(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, "2022-01-15T10:00:00Z", stored.FromDate)
+	assert.Equal(test, "2022-01-16T10:00:00Z", stored.ToDate)
+
+	found, err := NewCarProcessor(repos).GetCarsFromDBWithParams(map[string][]string{
+		domain.FromDateUrlValue: {"2022-01-14T11:00:00Z"},
+		domain.ToDateUrlValue:   {"2022-01-15T11:00:00Z"},
+	})
+	require.NoError(test, err)
+	assert.Empty(test, found, "Car is booked from 10:00 UTC")
+}
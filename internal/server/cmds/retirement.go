@@ -0,0 +1,87 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Recorded with bookings moved from retired car
+const retirementReason = "Car retired"
+
+/*
+Returned when car has bookings which are not finished yet
+*/
+type CarInUseError struct {
+	CarID int
+	Rents []domain.RentInfo
+}
+
+func (err *CarInUseError) Error() string {
+	var rentIDs []int
+	for _, rent := range err.Rents {
+		rentIDs = append(rentIDs, rent.RentID)
+	}
+	return fmt.Sprintf("Car %d has active bookings: %v", err.CarID, rentIDs)
+}
+
+/*
+Retire car, retired car is hidden from search and can not be rented anymore.
+Car with active bookings is retired only when reassign is requested and every booking is moved to equivalent car.
+Returns storage.ErrNotFound when car does not exist
+*/
+func (rentPr *RentProcessor) RetireCar(carID int, reassign bool) (*domain.CarRetirement, error) {
+	car, err := rentPr.cars.GetCar(carID)
+	if err != nil {
+		return nil, err
+	}
+	if len(car.RetiredAt) > 0 {
+		return &domain.CarRetirement{CarID: carID, RetiredAt: car.RetiredAt}, nil
+	}
+	now := time.Now().UTC()
+	plan, cars, err := rentPr.planReassignment(*car, retirementReason, now)
+	if err != nil {
+		return nil, err
+	}
+	if !reassign && (len(plan.Reassigned) > 0 || len(plan.Unassigned) > 0) {
+		bookings, err := rentPr.activeBookings(carID, now)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &CarInUseError{CarID: carID, Rents: bookings}
+	}
+	if len(plan.Unassigned) > 0 {
+		return nil, &CarInUseError{CarID: carID, Rents: plan.Unassigned}
+	}
+	retirement := domain.CarRetirement{CarID: carID}
+	if len(plan.Reassigned) > 0 {
+		moved, err := rentPr.applyReassignment(*plan, cars)
+		if err != nil {
+			return nil, err
+		}
+		if len(moved.Unassigned) > 0 {
+			return nil, &CarInUseError{CarID: carID, Rents: moved.Unassigned}
+		}
+		retirement.Reassigned = moved.Reassigned
+	}
+	retirement.RetiredAt = now.Format(domain.TimeLayout)
+	affected, err := rentPr.cars.RetireCar(carID, retirement.RetiredAt)
+	if errors.Is(err, storage.ErrInUse) {
+		// Rent was booked after bookings were planned
+		bookings, err := rentPr.activeBookings(carID, now)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &CarInUseError{CarID: carID, Rents: bookings}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retire car")
+	}
+	if affected == 0 {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Car %d", carID)
+	}
+	return &retirement, nil
+}
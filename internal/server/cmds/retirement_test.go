@@ -0,0 +1,118 @@
+package cmds
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/validation"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetireCarReassignsFutureBookings(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	pastRent := rentTestRent
+	pastRent.CarID = car.CarID
+	_, err := rentProcessor.InsertRentInDB(pastRent, &car)
+	require.NoError(test, err)
+	futureRent := pastRent
+	futureRent.FromDate = time.Now().Add(72 * time.Hour).UTC().Format(domain.TimeLayout)
+	futureRent.ToDate = time.Now().Add(96 * time.Hour).UTC().Format(domain.TimeLayout)
+	futureRentID, err := rentProcessor.InsertRentInDB(futureRent, &car)
+	require.NoError(test, err)
+
+	_, err = rentProcessor.RetireCar(car.CarID, false)
+	var inUseErr *CarInUseError
+	require.True(test, errors.As(err, &inUseErr), "Car with future booking is not retired without reassignment")
+	require.Len(test, inUseErr.Rents, 1)
+	assert.Equal(test, int(futureRentID), inUseErr.Rents[0].RentID)
+	_, err = rentProcessor.RetireCar(car.CarID, true)
+	require.True(test, errors.As(err, &inUseErr), "There is no equivalent car yet")
+
+	spareCar := insertTestCar(test, repos)
+	retirement, err := rentProcessor.RetireCar(car.CarID, true)
+	require.NoError(test, err)
+	assert.NotEmpty(test, retirement.RetiredAt)
+	require.Len(test, retirement.Reassigned, 1)
+	assert.Equal(test, int(futureRentID), retirement.Reassigned[0].RentID)
+	assert.Equal(test, spareCar.CarID, retirement.Reassigned[0].ToCarID)
+	assert.Equal(test, domain.LikeForLikeReassignment, retirement.Reassigned[0].Kind)
+	moved, err := rentProcessor.GetRentFromDB(int(futureRentID))
+	require.NoError(test, err)
+	assert.Equal(test, spareCar.CarID, moved.CarID)
+
+	again, err := rentProcessor.RetireCar(car.CarID, false)
+	require.NoError(test, err)
+	assert.Equal(test, retirement.RetiredAt, again.RetiredAt, "Retirement is kept once")
+	retired, err := repos.Cars.GetCar(car.CarID)
+	require.NoError(test, err)
+	_, violations, err := rentProcessor.QuoteRent(futureRent, retired)
+	require.NoError(test, err)
+	assert.Contains(test, violationCodes(violations), "carID:"+validation.NotAvailable)
+
+	_, err = rentProcessor.RetireCar(1000, false)
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+}
+
+/*
+Car repository which books the car right before it is retired
+*/
+type bookBeforeRetirement struct {
+	storage.CarRepository
+	rents storage.RentRepository
+	rent  domain.RentInfo
+}
+
+func (cars *bookBeforeRetirement) RetireCar(carID int, retiredAt string) (int64, error) {
+	if _, err := cars.rents.InsertRent(cars.rent, nil); err != nil {
+		return 0, err
+	}
+	return cars.CarRepository.RetireCar(carID, retiredAt)
+}
+
+/*
+Test that car is not retired when it is booked after its bookings were planned
+*/
+func TestRetireCarRejectsBookingMadeMeanwhile(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	rent.FromDate = time.Now().Add(72 * time.Hour).UTC().Format(domain.TimeLayout)
+	rent.ToDate = time.Now().Add(96 * time.Hour).UTC().Format(domain.TimeLayout)
+	repos.Cars = &bookBeforeRetirement{CarRepository: repos.Cars, rents: repos.Rents, rent: rent}
+
+	_, err := NewRentProcessor(repos).RetireCar(car.CarID, false)
+	var inUseErr *CarInUseError
+	require.True(test, errors.As(err, &inUseErr))
+	require.Len(test, inUseErr.Rents, 1)
+	stored, err := repos.Cars.GetCar(car.CarID)
+	require.NoError(test, err)
+	assert.Empty(test, stored.RetiredAt)
+}
+
+/*
+Test that car retired after it was read is not booked
+*/
+func TestInsertRentRejectsCarRetiredMeanwhile(test *testing.T) {
+	repos := newTestRepositories(test)
+	car := insertTestCar(test, repos)
+	rentProcessor := NewRentProcessor(repos)
+	_, err := rentProcessor.RetireCar(car.CarID, false)
+	require.NoError(test, err)
+
+	rent := rentTestRent
+	rent.CarID = car.CarID
+	_, err = rentProcessor.InsertRentInDB(rent, &car)
+	violations, ok := validation.From(err)
+	require.True(test, ok, "Car read before retirement is checked again when rent is written")
+	assert.Equal(test, []string{"carID:" + validation.NotAvailable}, violationCodes(violations))
+	rents, err := rentProcessor.GetRentsFromDB()
+	require.NoError(test, err)
+	assert.Empty(test, rents)
+}
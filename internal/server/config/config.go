@@ -0,0 +1,451 @@
+package config
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/cancellation"
+	"car-rental/internal/server/licence"
+	"car-rental/internal/server/pricing"
+	"car-rental/internal/server/rebalancing"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	CleaningBufferEnv    string = "CAR_RENTAL_CLEANING_BUFFER"
+	SameDayTurnaroundEnv string = "CAR_RENTAL_SAME_DAY_TURNAROUND"
+	DBDSNEnv             string = "CAR_RENTAL_DB_DSN"
+	DBPathEnv            string = "CAR_RENTAL_DB_PATH"
+	GenerateCarsEnv      string = "CAR_RENTAL_GENERATE_CARS"
+	SeedBranchesEnv      string = "CAR_RENTAL_SEED_BRANCHES"
+	GracePeriodEnv       string = "CAR_RENTAL_GRACE_PERIOD"
+	MinimumRentDaysEnv   string = "CAR_RENTAL_MINIMUM_RENT_DAYS"
+	TaxPercentEnv        string = "CAR_RENTAL_TAX_PERCENT"
+	ExtrasEnv            string = "CAR_RENTAL_EXTRAS"
+	OneWayFeeEnv         string = "CAR_RENTAL_ONE_WAY_FEE"
+	QuoteTTLEnv          string = "CAR_RENTAL_QUOTE_TTL"
+	CancellationFeesEnv  string = "CAR_RENTAL_CANCELLATION_FEES"
+	LateCancellationEnv  string = "CAR_RENTAL_LATE_CANCELLATION_FEE"
+	TransferTimeEnv      string = "CAR_RENTAL_TRANSFER_TIME"
+	RebalanceHorizonEnv  string = "CAR_RENTAL_REBALANCE_HORIZON"
+	LicenceRulesEnv      string = "CAR_RENTAL_LICENCE_RULES"
+	AuthDisabledEnv      string = "CAR_RENTAL_AUTH_DISABLED"
+	APIKeysFileEnv       string = "CAR_RENTAL_API_KEYS_FILE"
+	JWTSecretEnv         string = "CAR_RENTAL_JWT_SECRET"
+	TokenTTLEnv          string = "CAR_RENTAL_TOKEN_TTL"
+	LogLevelEnv          string = "CAR_RENTAL_LOG_LEVEL"
+	LogFormatEnv         string = "CAR_RENTAL_LOG_FORMAT"
+	AccessLogEnv         string = "CAR_RENTAL_ACCESS_LOG"
+
+	InMemoryDSN string = "file:rental.db?cache=shared&mode=memory&_fk=true"
+
+	DefaultQuoteTTL = 15 * time.Minute
+	DefaultTokenTTL = time.Hour
+
+	SQLiteDriver   string = "sqlite3"
+	PostgresDriver string = "postgres"
+
+	TextLogFormat string = "text"
+	JSONLogFormat string = "json"
+)
+
+type (
+	Config struct {
+		Availability availability.Rules
+		Pricing      pricing.Rules
+		Cancellation cancellation.Rules
+		Rebalancing  rebalancing.Rules
+		Licence      licence.Rules
+		DB           DBConfig
+		Auth         AuthConfig
+		Log          LogConfig
+		// How long issued quote stays valid
+		QuoteTTL time.Duration
+	}
+
+	LogConfig struct {
+		Level log.Level
+		// text or json
+		Format string
+		// One JSON line per handled request
+		AccessLog bool
+	}
+
+	AuthConfig struct {
+		// Every request is let through without credentials
+		Disabled bool
+		// JSON file with API keys of back-office integrations
+		KeysFile string
+		// Secret of customer tokens, random secret is generated when it is empty
+		JWTSecret string
+		// How long issued customer token stays valid
+		TokenTTL time.Duration
+	}
+
+	DBConfig struct {
+		// Data source name passed to sqlite driver
+		DSN string
+		// Fill cars table with random fleet on startup
+		GenerateCars bool
+		// Create default branches on startup when there are none
+		SeedBranches bool
+	}
+)
+
+/*
+Reads service configuration from environment variables, missing values fall back to defaults
+*/
+func Load() (*Config, error) {
+	cfg := Config{Availability: availability.DefaultRules, Pricing: pricing.DefaultRules, Cancellation: cancellation.DefaultRules, Rebalancing: rebalancing.DefaultRules, Licence: licence.DefaultRules, DB: DBConfig{DSN: InMemoryDSN}, Auth: AuthConfig{TokenTTL: DefaultTokenTTL},
+		Log: LogConfig{Level: log.InfoLevel, Format: TextLogFormat, AccessLog: true}, QuoteTTL: DefaultQuoteTTL}
+	if value, ok := os.LookupEnv(CleaningBufferEnv); ok && len(value) > 0 {
+		buffer, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect value of %s", CleaningBufferEnv)
+		}
+		if buffer < 0 {
+			return nil, errors.Errorf("%s should not be negative", CleaningBufferEnv)
+		}
+		cfg.Availability.CleaningBuffer = buffer
+	}
+	if value, ok := os.LookupEnv(SameDayTurnaroundEnv); ok && len(value) > 0 {
+		allowed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect value of %s", SameDayTurnaroundEnv)
+		}
+		cfg.Availability.AllowSameDayTurnaround = allowed
+	}
+	if value, ok := os.LookupEnv(DBPathEnv); ok && len(value) > 0 {
+		cfg.DB.DSN = FileDSN(value)
+	}
+	if value, ok := os.LookupEnv(DBDSNEnv); ok && len(value) > 0 {
+		cfg.DB.DSN = value
+	}
+	if value, ok := os.LookupEnv(GenerateCarsEnv); ok && len(value) > 0 {
+		generate, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect value of %s", GenerateCarsEnv)
+		}
+		cfg.DB.GenerateCars = generate
+	}
+	if value, ok := os.LookupEnv(SeedBranchesEnv); ok && len(value) > 0 {
+		seed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect value of %s", SeedBranchesEnv)
+		}
+		cfg.DB.SeedBranches = seed
+	}
+	if value, ok := os.LookupEnv(QuoteTTLEnv); ok && len(value) > 0 {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect value of %s", QuoteTTLEnv)
+		}
+		if ttl <= 0 {
+			return nil, errors.Errorf("%s should be positive", QuoteTTLEnv)
+		}
+		cfg.QuoteTTL = ttl
+	}
+	if err := loadPricing(&cfg.Pricing); err != nil {
+		return nil, err
+	}
+	if err := loadCancellation(&cfg.Cancellation); err != nil {
+		return nil, err
+	}
+	if err := loadRebalancing(&cfg.Rebalancing); err != nil {
+		return nil, err
+	}
+	if value, ok := os.LookupEnv(LicenceRulesEnv); ok && len(value) > 0 {
+		rules, err := ParseLicenceRules(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect value of %s", LicenceRulesEnv)
+		}
+		cfg.Licence = rules
+	}
+	if err := loadAuth(&cfg.Auth); err != nil {
+		return nil, err
+	}
+	if err := loadLog(&cfg.Log); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func loadLog(logCfg *LogConfig) error {
+	if value, ok := os.LookupEnv(LogLevelEnv); ok && len(value) > 0 {
+		level, err := log.ParseLevel(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", LogLevelEnv)
+		}
+		logCfg.Level = level
+	}
+	if value, ok := os.LookupEnv(LogFormatEnv); ok && len(value) > 0 {
+		format := strings.ToLower(strings.TrimSpace(value))
+		if format != TextLogFormat && format != JSONLogFormat {
+			return errors.Errorf("%s should be %s or %s", LogFormatEnv, TextLogFormat, JSONLogFormat)
+		}
+		logCfg.Format = format
+	}
+	if value, ok := os.LookupEnv(AccessLogEnv); ok && len(value) > 0 {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", AccessLogEnv)
+		}
+		logCfg.AccessLog = enabled
+	}
+	return nil
+}
+
+func loadAuth(auth *AuthConfig) error {
+	if value, ok := os.LookupEnv(AuthDisabledEnv); ok && len(value) > 0 {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", AuthDisabledEnv)
+		}
+		auth.Disabled = disabled
+	}
+	auth.KeysFile = os.Getenv(APIKeysFileEnv)
+	auth.JWTSecret = os.Getenv(JWTSecretEnv)
+	if value, ok := os.LookupEnv(TokenTTLEnv); ok && len(value) > 0 {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", TokenTTLEnv)
+		}
+		if ttl <= 0 {
+			return errors.Errorf("%s should be positive", TokenTTLEnv)
+		}
+		auth.TokenTTL = ttl
+	}
+	return nil
+}
+
+func loadPricing(rules *pricing.Rules) error {
+	if value, ok := os.LookupEnv(GracePeriodEnv); ok && len(value) > 0 {
+		gracePeriod, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", GracePeriodEnv)
+		}
+		if gracePeriod < 0 {
+			return errors.Errorf("%s should not be negative", GracePeriodEnv)
+		}
+		rules.GracePeriod = gracePeriod
+	}
+	if value, ok := os.LookupEnv(MinimumRentDaysEnv); ok && len(value) > 0 {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", MinimumRentDaysEnv)
+		}
+		if days < 1 {
+			return errors.Errorf("%s should be at least 1", MinimumRentDaysEnv)
+		}
+		rules.MinimumDays = days
+	}
+	if value, ok := os.LookupEnv(TaxPercentEnv); ok && len(value) > 0 {
+		taxRate, err := pricing.ParseHundredths(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", TaxPercentEnv)
+		}
+		rules.TaxRate = taxRate
+	}
+	if value, ok := os.LookupEnv(ExtrasEnv); ok && len(value) > 0 {
+		extras, err := ParseExtras(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", ExtrasEnv)
+		}
+		rules.Extras = extras
+	}
+	if value, ok := os.LookupEnv(OneWayFeeEnv); ok && len(value) > 0 {
+		fee, err := pricing.ParseHundredths(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", OneWayFeeEnv)
+		}
+		rules.OneWayFee = fee
+	}
+	return nil
+}
+
+func loadCancellation(rules *cancellation.Rules) error {
+	if value, ok := os.LookupEnv(CancellationFeesEnv); ok && len(value) > 0 {
+		tiers, err := ParseCancellationTiers(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", CancellationFeesEnv)
+		}
+		rules.Tiers = tiers
+	}
+	if value, ok := os.LookupEnv(LateCancellationEnv); ok && len(value) > 0 {
+		feeRate, err := pricing.ParseHundredths(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", LateCancellationEnv)
+		}
+		rules.LateFeeRate = feeRate
+	}
+	return errors.Wrap(rules.Validate(), "Incorrect cancellation policy")
+}
+
+func loadRebalancing(rules *rebalancing.Rules) error {
+	if value, ok := os.LookupEnv(TransferTimeEnv); ok && len(value) > 0 {
+		transferTime, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", TransferTimeEnv)
+		}
+		if transferTime <= 0 {
+			return errors.Errorf("%s should be positive", TransferTimeEnv)
+		}
+		rules.TransferTime = transferTime
+	}
+	if value, ok := os.LookupEnv(RebalanceHorizonEnv); ok && len(value) > 0 {
+		horizon, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Wrapf(err, "Incorrect value of %s", RebalanceHorizonEnv)
+		}
+		if horizon <= 0 {
+			return errors.Errorf("%s should be positive", RebalanceHorizonEnv)
+		}
+		rules.Horizon = horizon
+	}
+	return nil
+}
+
+/*
+Parses cancellation fees in format "48h=0%,0s=50%", fee applies when rent is cancelled at least that long before pickup
+*/
+func ParseCancellationTiers(value string) ([]cancellation.Tier, error) {
+	var tiers []cancellation.Tier
+	for _, definition := range strings.Split(value, ",") {
+		parts := strings.SplitN(definition, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Cancellation fee [%s] should be defined as notice=percent", definition)
+		}
+		notice, err := time.ParseDuration(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect notice of cancellation fee [%s]", definition)
+		}
+		feeRate, err := pricing.ParseHundredths(strings.TrimSuffix(strings.TrimSpace(parts[1]), "%"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect percent of cancellation fee [%s]", definition)
+		}
+		tiers = append(tiers, cancellation.Tier{Notice: notice, FeeRate: feeRate})
+	}
+	return tiers, nil
+}
+
+/*
+Parses licence rules in format "*=B/1,5=C/3,US:*=D/1". Every rule is [country:]group=categories/years,
+group * applies to car groups which are not listed and categories are separated by |.
+Rules replace built-in ones, country without its own * rule falls back to the * rule of other countries
+*/
+func ParseLicenceRules(value string) (licence.Rules, error) {
+	defaultRules := licence.GroupRules{Groups: make(map[int]licence.Requirement), Default: licence.DefaultRequirement}
+	countryRules := make(map[string]*licence.GroupRules)
+	countryDefaults := make(map[string]bool)
+	for _, definition := range strings.Split(value, ",") {
+		parts := strings.SplitN(definition, "=", 2)
+		if len(parts) != 2 {
+			return licence.Rules{}, fmt.Errorf("Licence rule [%s] should be defined as [country:]group=categories/years", definition)
+		}
+		rules := &defaultRules
+		group := strings.TrimSpace(parts[0])
+		country := ""
+		if countryGroup := strings.SplitN(group, ":", 2); len(countryGroup) == 2 {
+			country, group = strings.TrimSpace(countryGroup[0]), strings.TrimSpace(countryGroup[1])
+			if len(country) != 2 || strings.ToUpper(country) != country {
+				return licence.Rules{}, fmt.Errorf("Country of licence rule [%s] should be two letter code", definition)
+			}
+			if countryRules[country] == nil {
+				countryRules[country] = &licence.GroupRules{Groups: make(map[int]licence.Requirement)}
+			}
+			rules = countryRules[country]
+		}
+		requirement, err := parseRequirement(parts[1])
+		if err != nil {
+			return licence.Rules{}, errors.Wrapf(err, "Incorrect licence rule [%s]", definition)
+		}
+		if group == "*" {
+			rules.Default = requirement
+			countryDefaults[country] = true
+			continue
+		}
+		carGroup, err := strconv.Atoi(group)
+		if err != nil || carGroup <= 0 {
+			return licence.Rules{}, fmt.Errorf("Car group of licence rule [%s] should be positive number or *", definition)
+		}
+		rules.Groups[carGroup] = requirement
+	}
+	result := licence.Rules{Countries: make(map[string]licence.Validator), Default: defaultRules}
+	for country, rules := range countryRules {
+		if !countryDefaults[country] {
+			rules.Default = defaultRules.Default
+		}
+		result.Countries[country] = *rules
+	}
+	return result, nil
+}
+
+/*
+Parses licence requirement in format "C|CE/3"
+*/
+func parseRequirement(value string) (licence.Requirement, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return licence.Requirement{}, fmt.Errorf("Requirement [%s] should be defined as categories/years", value)
+	}
+	var requirement licence.Requirement
+	for _, category := range strings.Split(parts[0], "|") {
+		if category = strings.TrimSpace(category); len(category) > 0 {
+			requirement.Categories = append(requirement.Categories, category)
+		}
+	}
+	years, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || years < 0 {
+		return licence.Requirement{}, fmt.Errorf("Years held of requirement [%s] should not be negative number", value)
+	}
+	requirement.MinYearsHeld = years
+	return requirement, nil
+}
+
+/*
+Parses priced extras in format "GPS=10/day,Cleaning=30", price is charged per day when it ends with /day
+*/
+func ParseExtras(value string) (map[string]pricing.Extra, error) {
+	extras := make(map[string]pricing.Extra)
+	for _, definition := range strings.Split(value, ",") {
+		parts := strings.SplitN(definition, "=", 2)
+		if len(parts) != 2 || len(strings.TrimSpace(parts[0])) == 0 {
+			return nil, fmt.Errorf("Extra [%s] should be defined as name=price", definition)
+		}
+		extra := pricing.Extra{Name: strings.TrimSpace(parts[0])}
+		price := strings.TrimSpace(parts[1])
+		if strings.HasSuffix(price, "/day") {
+			extra.PerDay = true
+			price = strings.TrimSuffix(price, "/day")
+		}
+		var err error
+		extra.Price, err = pricing.ParseHundredths(price)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect price of extra %s", extra.Name)
+		}
+		extras[extra.Name] = extra
+	}
+	return extras, nil
+}
+
+/*
+Builds DSN of file backed database with WAL journaling and foreign keys enabled
+*/
+func FileDSN(path string) string {
+	return fmt.Sprintf("file:%s?_journal_mode=WAL&_fk=true&_busy_timeout=5000", path)
+}
+
+/*
+Storage backend is selected by DSN, postgres:// and postgresql:// URLs use PostgreSQL, everything else SQLite
+*/
+func (cfg DBConfig) Driver() string {
+	if strings.HasPrefix(cfg.DSN, "postgres://") || strings.HasPrefix(cfg.DSN, "postgresql://") {
+		return PostgresDriver
+	}
+	return SQLiteDriver
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/db/instrument"
+	"car-rental/internal/server/metrics"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Test that cars stored in file backed DB survive reopening and existing tables are reused
+*/
+func TestNewDBStructPersistent(test *testing.T) {
+	dbConfig := config.DBConfig{DSN: config.FileDSN(filepath.Join(test.TempDir(), "rental.db"))}
+	dbStruct, err := NewDBStruct(dbConfig)
+	require.NoError(test, err)
+	_, err = dbStruct.internalDB.Exec(InsertIntoCarTable, "Kia", 5, 1, 1, 4, true, 30, "Haifa", 2, "Brand new car", 100)
+	require.NoError(test, err)
+	var journalMode string
+	require.NoError(test, dbStruct.internalDB.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(test, "wal", journalMode)
+	require.NoError(test, dbStruct.Close())
+
+	dbStruct, err = NewDBStruct(dbConfig)
+	require.NoError(test, err)
+	defer dbStruct.Close()
+	var count int
+	require.NoError(test, dbStruct.internalDB.QueryRow("SELECT count(*) FROM cars").Scan(&count))
+	assert.Equal(test, 1, count)
+}
+
+/*
+Test that queries are timed per statement of sql.go, also when conditions are added to the statement
+*/
+func TestQueryDuration(test *testing.T) {
+	dbStruct, err := NewDBStruct(config.DBConfig{DSN: config.FileDSN(filepath.Join(test.TempDir(), "rental.db"))})
+	require.NoError(test, err)
+	defer dbStruct.Close()
+	inserted := metrics.DBQueryDuration.Count("InsertIntoCarTable")
+	selected := metrics.DBQueryDuration.Count("SelectCars")
+	_, err = dbStruct.Exec(InsertIntoCarTable, "Kia", 5, 1, 1, 4, true, 30, "Haifa", 2, "Brand new car", 100)
+	require.NoError(test, err)
+	rows, err := dbStruct.Query(SelectCars+" WHERE car_id = ?", 1)
+	require.NoError(test, err)
+	require.NoError(test, rows.Close())
+	assert.Equal(test, inserted+1, metrics.DBQueryDuration.Count("InsertIntoCarTable"))
+	assert.Equal(test, selected+1, metrics.DBQueryDuration.Count("SelectCars"))
+	assert.Equal(test, "UpdateRentStatus", instrument.StatementName(statementNames, UpdateRentStatus))
+	assert.Equal(test, instrument.OtherStatement, instrument.StatementName(statementNames, "PRAGMA journal_mode"))
+}
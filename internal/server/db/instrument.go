@@ -0,0 +1,16 @@
+package db
+
+import (
+	"car-rental/internal/server/db/instrument"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+/*
+SQLite driver which reports query durations to metrics
+*/
+const instrumentedDriver = "sqlite3-instrumented"
+
+func init() {
+	instrument.Register(instrumentedDriver, &sqlite3.SQLiteDriver{}, statementNames)
+}
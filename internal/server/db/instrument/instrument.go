@@ -0,0 +1,246 @@
+package instrument
+
+import (
+	"car-rental/internal/server/metrics"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Queries which do not start with any known statement, e.g. migrations and pragmas
+const OtherStatement = "other"
+
+/*
+Registers driver which reports query durations of base driver to metrics. Statements are given by name,
+query is reported under the name of the statement it starts with
+*/
+func Register(name string, base driver.Driver, statements map[string]string) {
+	sql.Register(name, &timedDriver{driver: base, statements: statements})
+}
+
+type (
+	timedDriver struct {
+		driver     driver.Driver
+		statements map[string]string
+	}
+
+	/*
+		Connection of base driver, methods missing in base connection fall back the way database/sql does
+	*/
+	timedConn struct {
+		conn       driver.Conn
+		statements map[string]string
+	}
+
+	timedStmt struct {
+		stmt      driver.Stmt
+		statement string
+	}
+
+	/*
+		Rows are read step by step, so query lasts until rows are closed
+	*/
+	timedRows struct {
+		driver.Rows
+		statement string
+		start     time.Time
+	}
+)
+
+func (timed *timedDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := timed.driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{conn: conn, statements: timed.statements}, nil
+}
+
+func (conn *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return conn.PrepareContext(context.Background(), query)
+}
+
+func (conn *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := conn.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{stmt: stmt, statement: StatementName(conn.statements, query)}, nil
+}
+
+func (conn *timedConn) Close() error {
+	return conn.conn.Close()
+}
+
+func (conn *timedConn) Begin() (driver.Tx, error) {
+	return conn.conn.Begin()
+}
+
+func (conn *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := conn.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("Driver does not support transaction options")
+	}
+	return conn.conn.Begin()
+}
+
+/*
+Query without ExecerContext of base connection is prepared by database/sql and timed as statement
+*/
+func (conn *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := conn.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer observeQuery(StatementName(conn.statements, query), time.Now())
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (conn *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := conn.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	statement := StatementName(conn.statements, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		observeQuery(statement, start)
+		return nil, err
+	}
+	return &timedRows{Rows: rows, statement: statement, start: start}, nil
+}
+
+func (conn *timedConn) Ping(ctx context.Context) error {
+	if pinger, ok := conn.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (conn *timedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := conn.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (conn *timedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := conn.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (stmt *timedStmt) Close() error {
+	return stmt.stmt.Close()
+}
+
+func (stmt *timedStmt) NumInput() int {
+	return stmt.stmt.NumInput()
+}
+
+func (stmt *timedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	defer observeQuery(stmt.statement, time.Now())
+	return stmt.stmt.Exec(args)
+}
+
+func (stmt *timedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stmt.QueryContext(context.Background(), namedValues(args))
+}
+
+func (stmt *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := stmt.stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := plainValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return stmt.Exec(values)
+	}
+	defer observeQuery(stmt.statement, time.Now())
+	return execer.ExecContext(ctx, args)
+}
+
+func (stmt *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := stmt.stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = plainValues(args); err == nil {
+			rows, err = stmt.stmt.Query(values)
+		}
+	}
+	if err != nil {
+		observeQuery(stmt.statement, start)
+		return nil, err
+	}
+	return &timedRows{Rows: rows, statement: stmt.statement, start: start}, nil
+}
+
+func (stmt *timedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := stmt.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+func (rows *timedRows) Close() error {
+	defer observeQuery(rows.statement, rows.start)
+	return rows.Rows.Close()
+}
+
+func observeQuery(statement string, start time.Time) {
+	metrics.DBQueryDuration.Observe(time.Since(start).Seconds(), statement)
+}
+
+func namedValues(values []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(values))
+	for i, value := range values {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: value}
+	}
+	return named
+}
+
+/*
+Statement of base driver without context methods takes only positional arguments
+*/
+func plainValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, value := range named {
+		if len(value.Name) > 0 {
+			return nil, errors.Errorf("Driver does not support named argument %s", value.Name)
+		}
+		values[i] = value.Value
+	}
+	return values, nil
+}
+
+/*
+Name of the longest statement which starts the query, queries extend statements with conditions
+*/
+func StatementName(statements map[string]string, query string) string {
+	name := OtherStatement
+	matched := 0
+	for statementName, statement := range statements {
+		if len(statement) > matched && strings.HasPrefix(query, statement) {
+			name = statementName
+			matched = len(statement)
+		}
+	}
+	return name
+}
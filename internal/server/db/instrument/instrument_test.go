@@ -0,0 +1,86 @@
+package instrument
+
+import (
+	"car-rental/internal/server/metrics"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Driver without context methods, the way lib/pq statements are
+*/
+type (
+	legacyDriver struct{}
+	legacyConn   struct{}
+	legacyStmt   struct{}
+	legacyTx     struct{}
+	legacyRows   struct{ read bool }
+	legacyResult struct{}
+)
+
+func (legacyDriver) Open(string) (driver.Conn, error)         { return legacyConn{}, nil }
+func (legacyConn) Prepare(string) (driver.Stmt, error)        { return legacyStmt{}, nil }
+func (legacyConn) Close() error                               { return nil }
+func (legacyConn) Begin() (driver.Tx, error)                  { return legacyTx{}, nil }
+func (legacyTx) Commit() error                                { return nil }
+func (legacyTx) Rollback() error                              { return nil }
+func (legacyStmt) Close() error                               { return nil }
+func (legacyStmt) NumInput() int                              { return 1 }
+func (legacyStmt) Exec([]driver.Value) (driver.Result, error) { return legacyResult{}, nil }
+func (legacyStmt) Query([]driver.Value) (driver.Rows, error)  { return &legacyRows{}, nil }
+func (legacyResult) LastInsertId() (int64, error)             { return 1, nil }
+func (legacyResult) RowsAffected() (int64, error)             { return 1, nil }
+func (*legacyRows) Columns() []string                         { return []string{"id"} }
+func (*legacyRows) Close() error                              { return nil }
+func (rows *legacyRows) Next(dest []driver.Value) error {
+	if rows.read {
+		return io.EOF
+	}
+	rows.read = true
+	dest[0] = int64(1)
+	return nil
+}
+
+var legacyStatements = map[string]string{
+	"SelectCars":         "SELECT car_id FROM cars",
+	"InsertIntoCarTable": "INSERT INTO cars",
+}
+
+func init() {
+	Register("legacy-instrumented", legacyDriver{}, legacyStatements)
+}
+
+/*
+Test that queries of driver without context methods are timed per statement too
+*/
+func TestLegacyDriverQueryDuration(test *testing.T) {
+	testDB, err := sql.Open("legacy-instrumented", "")
+	require.NoError(test, err)
+	defer testDB.Close()
+	inserted := metrics.DBQueryDuration.Count("InsertIntoCarTable")
+	selected := metrics.DBQueryDuration.Count("SelectCars")
+
+	_, err = testDB.Exec("INSERT INTO cars(price) VALUES ($1)", 100)
+	require.NoError(test, err)
+	var id int
+	require.NoError(test, testDB.QueryRow("SELECT car_id FROM cars WHERE car_id = $1", 1).Scan(&id))
+	tx, err := testDB.Begin()
+	require.NoError(test, err)
+	_, err = tx.Exec("INSERT INTO cars(price) VALUES ($1)", 120)
+	require.NoError(test, err)
+	require.NoError(test, tx.Commit())
+
+	assert.Equal(test, 1, id)
+	assert.Equal(test, inserted+2, metrics.DBQueryDuration.Count("InsertIntoCarTable"))
+	assert.Equal(test, selected+1, metrics.DBQueryDuration.Count("SelectCars"))
+}
+
+func TestStatementName(test *testing.T) {
+	assert.Equal(test, "SelectCars", StatementName(legacyStatements, "SELECT car_id FROM cars WHERE car_id = $1"))
+	assert.Equal(test, OtherStatement, StatementName(legacyStatements, "SELECT 1"))
+}
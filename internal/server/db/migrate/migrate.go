@@ -0,0 +1,259 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations(version INTEGER PRIMARY KEY NOT NULL,
+					name TEXT NOT NULL,
+					checksum TEXT NOT NULL,
+					applied_at TIMESTAMP NOT NULL);`
+	selectAppliedMigrations = `SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`
+	insertAppliedMigration  = `INSERT INTO schema_migrations(version, name, checksum, applied_at) VALUES (?,?,?,?)`
+	removeAppliedMigration  = `DELETE FROM schema_migrations WHERE version = ?`
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type (
+	/*
+		Single schema version with statements to apply and revert it
+	*/
+	Migration struct {
+		Version  int
+		Name     string
+		Up       string
+		Down     string
+		Checksum string
+	}
+
+	/*
+		Migration together with information when it was applied
+	*/
+	MigrationStatus struct {
+		Migration
+		Applied   bool
+		AppliedAt string
+	}
+
+	/*
+		Applies and reverts migrations in version order, every migration runs in its own transaction
+	*/
+	Runner struct {
+		db         *sql.DB
+		migrations []Migration
+		rebind     func(query string) string
+	}
+
+	appliedMigration struct {
+		version   int
+		name      string
+		checksum  string
+		appliedAt string
+	}
+)
+
+/*
+Loads migrations from source and prepares migrations table
+*/
+func NewRunner(db *sql.DB, source fs.FS) (*Runner, error) {
+	return NewRunnerWithRebind(db, source, func(query string) string { return query })
+}
+
+/*
+Same as NewRunner for drivers which do not support "?" placeholders, rebind converts bookkeeping queries
+*/
+func NewRunnerWithRebind(db *sql.DB, source fs.FS, rebind func(query string) string) (*Runner, error) {
+	migrations, err := Load(source)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(createMigrationsTable); err != nil {
+		return nil, errors.Wrap(err, "Failed to create schema_migrations table")
+	}
+	return &Runner{db: db, migrations: migrations, rebind: rebind}, nil
+}
+
+/*
+Reads pairs of up/down files from source and orders them by version
+*/
+func Load(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read migrations")
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		matches := migrationFileName.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "Incorrect version of migration %s", entry.Name())
+		}
+		content, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read migration %s", entry.Name())
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("Migration version %d is used by %s and %s", version, migration.Name, matches[2])
+		}
+		if matches[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if len(migration.Up) == 0 || len(migration.Down) == 0 {
+			return nil, fmt.Errorf("Migration %04d_%s should have both up and down files", migration.Version, migration.Name)
+		}
+		migration.Checksum = checksum(migration.Up, migration.Down)
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+/*
+Applies all pending migrations, returns number of applied migrations
+*/
+func (runner *Runner) Up() (int, error) {
+	applied, err := runner.verify()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, migration := range runner.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		log.Infof("Applying migration %04d_%s", migration.Version, migration.Name)
+		if err := runner.apply(migration.Up, insertAppliedMigration,
+			migration.Version, migration.Name, migration.Checksum, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return count, errors.Wrapf(err, "Failed to apply migration %04d_%s", migration.Version, migration.Name)
+		}
+		count++
+	}
+	return count, nil
+}
+
+/*
+Reverts given number of latest applied migrations, returns number of reverted migrations
+*/
+func (runner *Runner) Down(steps int) (int, error) {
+	applied, err := runner.verify()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for index := len(runner.migrations) - 1; index >= 0 && count < steps; index-- {
+		migration := runner.migrations[index]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		log.Infof("Reverting migration %04d_%s", migration.Version, migration.Name)
+		if err := runner.apply(migration.Down, removeAppliedMigration, migration.Version); err != nil {
+			return count, errors.Wrapf(err, "Failed to revert migration %04d_%s", migration.Version, migration.Name)
+		}
+		count++
+	}
+	return count, nil
+}
+
+/*
+Lists all known migrations and marks applied ones
+*/
+func (runner *Runner) Status() ([]MigrationStatus, error) {
+	applied, err := runner.verify()
+	if err != nil {
+		return nil, err
+	}
+	var result []MigrationStatus
+	for _, migration := range runner.migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedMigration, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = appliedMigration.appliedAt
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+/*
+Checks that every applied migration is still known and was not changed after it was applied
+*/
+func (runner *Runner) verify() (map[int]appliedMigration, error) {
+	rows, err := runner.db.Query(selectAppliedMigrations)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query applied migrations")
+	}
+	defer rows.Close()
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var receivedRow appliedMigration
+		if err := rows.Scan(&receivedRow.version, &receivedRow.name, &receivedRow.checksum, &receivedRow.appliedAt); err != nil {
+			return nil, errors.Wrap(err, "Failed to scan applied migration")
+		}
+		applied[receivedRow.version] = receivedRow
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to query applied migrations")
+	}
+	known := make(map[int]Migration, len(runner.migrations))
+	for _, migration := range runner.migrations {
+		known[migration.Version] = migration
+	}
+	for version, appliedMigration := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("Applied migration %04d_%s is unknown", version, appliedMigration.name)
+		}
+		if migration.Checksum != appliedMigration.checksum {
+			return nil, fmt.Errorf("Checksum of migration %04d_%s was changed after it was applied", version, migration.Name)
+		}
+	}
+	return applied, nil
+}
+
+func (runner *Runner) apply(statements string, bookkeeping string, args ...interface{}) error {
+	tx, err := runner.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(statements); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(runner.rebind(bookkeeping), args...); err != nil {
+		return errors.Wrap(err, "Failed to update schema_migrations")
+	}
+	return tx.Commit()
+}
+
+func checksum(up string, down string) string {
+	hash := sha256.Sum256([]byte(up + "\x00" + down))
+	return hex.EncodeToString(hash[:])
+}
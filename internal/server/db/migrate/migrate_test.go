@@ -0,0 +1,166 @@
+package migrate
+
+import (
+	"car-rental/internal/server/db/migrations"
+	"database/sql"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(test *testing.T) *sql.DB {
+	testDB, err := sql.Open("sqlite3", "file:"+test.Name()+"?mode=memory&cache=shared")
+	require.NoError(test, err)
+	test.Cleanup(func() { testDB.Close() })
+	return testDB
+}
+
+func tableExists(test *testing.T, testDB *sql.DB, name string) bool {
+	var count int
+	require.NoError(test, testDB.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?`, name).Scan(&count))
+	return count > 0
+}
+
+var testMigrations = fstest.MapFS{
+	"0002_create_second.up.sql":   {Data: []byte("CREATE TABLE second(id INTEGER);")},
+	"0002_create_second.down.sql": {Data: []byte("DROP TABLE second;")},
+	"0001_create_first.up.sql":    {Data: []byte("CREATE TABLE first(id INTEGER);")},
+	"0001_create_first.down.sql":  {Data: []byte("DROP TABLE first;")},
+	"README.md":                   {Data: []byte("not a migration")},
+}
+
+func TestLoadOrdersMigrations(test *testing.T) {
+	loaded, err := Load(testMigrations)
+	require.NoError(test, err)
+	require.Len(test, loaded, 2)
+	assert.Equal(test, 1, loaded[0].Version)
+	assert.Equal(test, "create_first", loaded[0].Name)
+	assert.Equal(test, 2, loaded[1].Version)
+
+	_, err = Load(fstest.MapFS{"0001_only_up.up.sql": {Data: []byte("SELECT 1;")}})
+	assert.Error(test, err)
+}
+
+func TestUpAndDown(test *testing.T) {
+	testDB := openTestDB(test)
+	runner, err := NewRunner(testDB, testMigrations)
+	require.NoError(test, err)
+
+	applied, err := runner.Up()
+	require.NoError(test, err)
+	assert.Equal(test, 2, applied)
+	assert.True(test, tableExists(test, testDB, "first"))
+	assert.True(test, tableExists(test, testDB, "second"))
+
+	applied, err = runner.Up()
+	require.NoError(test, err)
+	assert.Equal(test, 0, applied)
+
+	reverted, err := runner.Down(1)
+	require.NoError(test, err)
+	assert.Equal(test, 1, reverted)
+	assert.True(test, tableExists(test, testDB, "first"))
+	assert.False(test, tableExists(test, testDB, "second"))
+
+	statuses, err := runner.Status()
+	require.NoError(test, err)
+	require.Len(test, statuses, 2)
+	assert.True(test, statuses[0].Applied)
+	assert.False(test, statuses[1].Applied)
+}
+
+func TestFailedMigrationIsRolledBack(test *testing.T) {
+	testDB := openTestDB(test)
+	runner, err := NewRunner(testDB, fstest.MapFS{
+		"0001_broken.up.sql":   {Data: []byte("CREATE TABLE broken(id INTEGER); INSERT INTO missing VALUES (1);")},
+		"0001_broken.down.sql": {Data: []byte("DROP TABLE broken;")},
+	})
+	require.NoError(test, err)
+	_, err = runner.Up()
+	assert.Error(test, err)
+	assert.False(test, tableExists(test, testDB, "broken"))
+}
+
+func TestChangedMigrationIsRejected(test *testing.T) {
+	testDB := openTestDB(test)
+	runner, err := NewRunner(testDB, testMigrations)
+	require.NoError(test, err)
+	_, err = runner.Up()
+	require.NoError(test, err)
+
+	changed := fstest.MapFS{}
+	for name, file := range testMigrations {
+		changed[name] = file
+	}
+	changed["0001_create_first.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE first(id INTEGER, name TEXT);")}
+	runner, err = NewRunner(testDB, changed)
+	require.NoError(test, err)
+	_, err = runner.Up()
+	assert.Error(test, err)
+}
+
+/*
+Test that embedded service migrations can be applied and fully reverted
+*/
+func TestServiceMigrations(test *testing.T) {
+	testDB := openTestDB(test)
+	runner, err := NewRunner(testDB, migrations.FS)
+	require.NoError(test, err)
+	applied, err := runner.Up()
+	require.NoError(test, err)
+	assert.Greater(test, applied, 0)
+	assert.True(test, tableExists(test, testDB, "cars"))
+
+	reverted, err := runner.Down(applied)
+	require.NoError(test, err)
+	assert.Equal(test, applied, reverted)
+	assert.False(test, tableExists(test, testDB, "cars"))
+}
+
+/*
+Test that branches migration creates branches only for names used by existing cars and rents
+*/
+func TestBranchesMigrationBackfill(test *testing.T) {
+	testDB := openTestDB(test)
+	beforeBranches := fstest.MapFS{}
+	for _, name := range []string{"0001_create_cars_table", "0002_create_rents_table"} {
+		for _, suffix := range []string{".up.sql", ".down.sql"} {
+			data, err := fs.ReadFile(migrations.FS, name+suffix)
+			require.NoError(test, err)
+			beforeBranches[name+suffix] = &fstest.MapFile{Data: data}
+		}
+	}
+	runner, err := NewRunner(testDB, beforeBranches)
+	require.NoError(test, err)
+	_, err = runner.Up()
+	require.NoError(test, err)
+	_, err = testDB.Exec(`INSERT INTO cars(car_id, locations) VALUES (1, 'Haifa,Tel Aviv'), (2, NULL)`)
+	require.NoError(test, err)
+	_, err = testDB.Exec(`INSERT INTO rents(rent_id, car_id, location) VALUES (1, 1, 'Holon')`)
+	require.NoError(test, err)
+
+	runner, err = NewRunner(testDB, migrations.FS)
+	require.NoError(test, err)
+	_, err = runner.Up()
+	require.NoError(test, err)
+	rows, err := testDB.Query(`SELECT name FROM branches ORDER BY name`)
+	require.NoError(test, err)
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		require.NoError(test, rows.Scan(&name))
+		names = append(names, name)
+	}
+	assert.Equal(test, []string{"Haifa", "Holon", "Tel Aviv"}, names)
+	var carBranches int
+	require.NoError(test, testDB.QueryRow(`SELECT count(*) FROM car_branches WHERE car_id = 1`).Scan(&carBranches))
+	assert.Equal(test, 2, carBranches)
+	var rentBranch string
+	require.NoError(test, testDB.QueryRow(`SELECT branches.name FROM rents JOIN branches USING (branch_id)`).Scan(&rentBranch))
+	assert.Equal(test, "Holon", rentBranch)
+}
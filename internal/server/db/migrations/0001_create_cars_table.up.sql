@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS cars(car_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+					car_comp_name text,
+					doors INTEGER,
+					big_lag INTEGER,
+					small_lag INTEGER,
+					adult_place INTEGER,
+					condition boolean,
+					min_age INTEGER,
+					locations TEXT,
+					car_group INTEGER,
+					description TEXT,
+					price INTEGER);
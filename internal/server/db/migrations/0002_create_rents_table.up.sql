@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS rents(rent_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+					car_id INTEGER,
+					from_time TIMESTAMP,
+					to_time TIMESTAMP,
+					location TEXT,
+					extras TEXT,
+					discounts TEXT,
+					rent_detail text,
+					FOREIGN KEY(car_id) REFERENCES cars(car_id) ON DELETE RESTRICT
+					);
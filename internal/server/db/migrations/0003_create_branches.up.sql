@@ -0,0 +1,28 @@
+CREATE TABLE branches(branch_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+					name TEXT NOT NULL UNIQUE,
+					address TEXT,
+					latitude REAL,
+					longitude REAL,
+					timezone TEXT,
+					opening_hours TEXT);
+CREATE TABLE car_branches(car_id INTEGER NOT NULL,
+					branch_id INTEGER NOT NULL,
+					PRIMARY KEY(car_id, branch_id),
+					FOREIGN KEY(car_id) REFERENCES cars(car_id) ON DELETE CASCADE,
+					FOREIGN KEY(branch_id) REFERENCES branches(branch_id) ON DELETE CASCADE
+					);
+-- SQLite can not drop column used in foreign key, rent branch is checked by application
+ALTER TABLE rents ADD COLUMN branch_id INTEGER;
+-- Branches are created only for names already used by cars and rents, their details are filled by admin
+INSERT INTO branches(name, latitude, longitude)
+	WITH RECURSIVE car_locations(name, rest) AS (
+		SELECT '', IFNULL(cars.locations, '') || ',' FROM cars
+		UNION ALL
+		SELECT substr(rest, 1, instr(rest, ',') - 1), substr(rest, instr(rest, ',') + 1) FROM car_locations WHERE rest <> '')
+	SELECT name, 0, 0 FROM car_locations WHERE name <> ''
+	UNION
+	SELECT location, 0, 0 FROM rents WHERE location <> '';
+INSERT INTO car_branches(car_id, branch_id)
+	SELECT cars.car_id, branches.branch_id FROM cars
+	JOIN branches ON instr(',' || cars.locations || ',', ',' || branches.name || ',') > 0;
+UPDATE rents SET branch_id = (SELECT branch_id FROM branches WHERE branches.name = rents.location);
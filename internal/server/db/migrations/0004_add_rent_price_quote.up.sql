@@ -0,0 +1 @@
+ALTER TABLE rents ADD COLUMN price_quote TEXT;
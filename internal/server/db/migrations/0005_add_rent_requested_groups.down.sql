@@ -0,0 +1,2 @@
+ALTER TABLE rents DROP COLUMN requested_car_group;
+ALTER TABLE rents DROP COLUMN driver_age_group;
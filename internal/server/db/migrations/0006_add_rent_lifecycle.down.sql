@@ -0,0 +1,3 @@
+DROP INDEX rent_transitions_rent_id;
+DROP TABLE rent_transitions;
+ALTER TABLE rents DROP COLUMN status;
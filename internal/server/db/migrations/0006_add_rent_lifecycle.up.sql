@@ -0,0 +1,12 @@
+ALTER TABLE rents ADD COLUMN status TEXT NOT NULL DEFAULT 'reserved';
+CREATE TABLE rent_transitions(transition_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+					rent_id INTEGER NOT NULL,
+					from_status TEXT NOT NULL,
+					to_status TEXT NOT NULL,
+					odometer INTEGER,
+					fuel_level INTEGER,
+					reason TEXT,
+					created_at TEXT NOT NULL,
+					FOREIGN KEY(rent_id) REFERENCES rents(rent_id) ON DELETE CASCADE
+					);
+CREATE INDEX rent_transitions_rent_id ON rent_transitions(rent_id);
@@ -0,0 +1 @@
+ALTER TABLE cars DROP COLUMN retired_at;
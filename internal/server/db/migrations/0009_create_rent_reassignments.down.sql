@@ -0,0 +1,2 @@
+DROP INDEX rent_reassignments_rent_id;
+DROP TABLE rent_reassignments;
@@ -0,0 +1,10 @@
+CREATE TABLE rent_reassignments(reassignment_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+					rent_id INTEGER NOT NULL,
+					from_car_id INTEGER NOT NULL,
+					to_car_id INTEGER NOT NULL,
+					kind TEXT NOT NULL,
+					reason TEXT,
+					created_at TEXT NOT NULL,
+					FOREIGN KEY(rent_id) REFERENCES rents(rent_id) ON DELETE CASCADE
+					);
+CREATE INDEX rent_reassignments_rent_id ON rent_reassignments(rent_id);
@@ -0,0 +1,2 @@
+DROP INDEX car_blackouts_car_id;
+DROP TABLE car_blackouts;
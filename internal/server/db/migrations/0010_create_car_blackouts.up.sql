@@ -0,0 +1,9 @@
+CREATE TABLE car_blackouts(blackout_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+					car_id INTEGER NOT NULL,
+					from_time TIMESTAMP NOT NULL,
+					to_time TIMESTAMP NOT NULL,
+					reason TEXT NOT NULL,
+					description TEXT,
+					FOREIGN KEY(car_id) REFERENCES cars(car_id) ON DELETE CASCADE
+					);
+CREATE INDEX car_blackouts_car_id ON car_blackouts(car_id);
@@ -0,0 +1,4 @@
+-- Rents booked before one-way rentals are returned where they were picked up
+ALTER TABLE rents ADD COLUMN return_location TEXT;
+ALTER TABLE rents ADD COLUMN return_branch_id INTEGER;
+UPDATE rents SET return_location = location, return_branch_id = branch_id;
@@ -0,0 +1,2 @@
+ALTER TABLE car_blackouts DROP COLUMN to_branch_id;
+ALTER TABLE car_blackouts DROP COLUMN to_location;
@@ -0,0 +1,3 @@
+-- Transfer blackout moves the car to other branch
+ALTER TABLE car_blackouts ADD COLUMN to_location TEXT;
+ALTER TABLE car_blackouts ADD COLUMN to_branch_id INTEGER;
@@ -0,0 +1,14 @@
+CREATE TABLE customers(customer_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+					name TEXT NOT NULL,
+					email TEXT NOT NULL,
+					phone TEXT,
+					date_of_birth TEXT NOT NULL,
+					licence_number TEXT NOT NULL,
+					licence_country TEXT NOT NULL,
+					licence_expiry TEXT NOT NULL,
+					UNIQUE(licence_country, licence_number)
+					);
+-- Rents booked before customers were introduced have no customer.
+-- SQLite can not drop column used in foreign key, rent customer is checked by application
+ALTER TABLE rents ADD COLUMN customer_id INTEGER;
+CREATE INDEX rents_customer_id ON rents(customer_id);
@@ -0,0 +1,3 @@
+-- Licence issue date and categories are not known for customers created before licence rules
+ALTER TABLE customers ADD COLUMN licence_issued TEXT;
+ALTER TABLE customers ADD COLUMN licence_categories TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+/*
+SQL migrations of service schema. Every version has NNNN_name.up.sql and NNNN_name.down.sql files
+*/
+//go:embed *.sql
+var FS embed.FS
@@ -1,12 +1,12 @@
 package db
 
 import (
-	"car-rental/internal/server/cars"
-	"car-rental/internal/server/domain"
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/db/migrate"
+	"car-rental/internal/server/db/migrations"
 	"database/sql"
-	"math/rand"
 	"strings"
-	"time"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
@@ -16,27 +16,37 @@ import (
 
 type DBStruct struct {
 	internalDB *sql.DB
-	carsArray  []domain.Car
+	// Held by checked transaction from begin to commit or rollback
+	checkMutex sync.Mutex
 }
 
 /*
-Generates and fills Inmemory db with cars
+Transaction which releases checked transaction lock when it is committed or rolled back
 */
-func NewDBStruct() (*DBStruct, error) {
-	inMemoryDB, err := sql.Open("sqlite3", "file:rental.db?cache=shared&mode=memory&_fk=true")
+type CheckedTx struct {
+	*sql.Tx
+	release sync.Once
+	unlock  func()
+}
+
+/*
+Opens DB described by configuration and applies migrations
+*/
+func NewDBStruct(cfg config.DBConfig) (*DBStruct, error) {
+	internalDB, err := Open(cfg.DSN)
 	if err != nil {
 		return nil, err
 	}
-	db := DBStruct{internalDB: inMemoryDB}
-	log.Info("Prefilling DB")
-	if err := db.createInMemoryTables(); err != nil {
-		return nil, errors.Wrap(err, "Failed to create tables")
+	db := DBStruct{internalDB: internalDB}
+	runner, err := NewMigrationRunner(internalDB)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prepare migrations")
 	}
-	db.generateCarsData()
-	if err := db.insertCarsIntoDB(); err != nil {
-		return nil, errors.Wrap(err, "Failed to insert cars into DB")
+	applied, err := runner.Up()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to migrate DB")
 	}
-	log.Info("DB filled sussesfully")
+	log.Infof("DB schema is up to date, %d migrations applied", applied)
 	return &db, nil
 }
 
@@ -45,82 +55,73 @@ func NewDBStructWithDBProvided(inMemoryDB *sql.DB) *DBStruct {
 }
 
 /*
-Creates in memory tables such as cars and rents
+Creates migration runner with SQLite migrations
 */
-func (db *DBStruct) createInMemoryTables() error {
-	log.Info("Creating cars table")
-	if _, err := db.internalDB.Exec(createCarTable); err != nil {
-		return errors.Wrapf(err, "Failed to create cars table")
-	}
-	log.Info("Cars table created sussesfully")
-	log.Info("Creating rents table")
-	if _, err := db.internalDB.Exec(createRentTable); err != nil {
-		return errors.Wrapf(err, "Failed to create rent table")
-	}
-	log.Info("Rents table created sussesfully")
-	return nil
+func NewMigrationRunner(internalDB *sql.DB) (*migrate.Runner, error) {
+	return migrate.NewRunner(internalDB, migrations.FS)
 }
 
 /*
-Creates mock car data
+Opens sqlite DB, file backed DB is switched to WAL journaling
 */
-func (db *DBStruct) generateCarsData() {
-	log.Info("Generating Cars data")
-
-	numberOfCars := rand.Intn(50)
-	for i := 0; i < numberOfCars; i++ {
-		mockCar := cars.GenerateNewCar(time.Now().UTC().UnixNano())
-		db.carsArray = append(db.carsArray, mockCar)
+func Open(dsn string) (*sql.DB, error) {
+	internalDB, err := sql.Open(instrumentedDriver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if !isInMemory(dsn) {
+		if _, err := internalDB.Exec("PRAGMA journal_mode=WAL"); err != nil {
+			return nil, errors.Wrap(err, "Failed to enable WAL journaling")
+		}
 	}
+	return internalDB, nil
+}
 
-	log.Info("Cars data generated sussesfully")
+func isInMemory(dsn string) bool {
+	return strings.Contains(dsn, "mode=memory") || strings.Contains(dsn, ":memory:")
+}
+
+func (db *DBStruct) BeginTransaction() (*sql.Tx, error) {
+	return db.internalDB.Begin()
 }
 
 /*
-Inserts mock car data into DB
+Starts transaction which reads rows, checks them and writes. Checked transactions run one at a time, so rows
+checked by one of them are not changed by another before it ends. Other writes are kept out by SQLite locking,
+transaction fails instead of writing over rows it checked
 */
-func (db *DBStruct) insertCarsIntoDB() error {
-	log.Println("Inserting cars records")
+func (db *DBStruct) BeginCheckedTransaction() (*CheckedTx, error) {
+	db.checkMutex.Lock()
 	tx, err := db.internalDB.Begin()
 	if err != nil {
-		return errors.Wrap(err, "Failed to start a transaction")
-	}
-	statement, err := tx.Prepare(InsertIntoCarTable)
-	if err != nil {
-		return errors.Wrap(err, "Failed to prepare stmt")
-	}
-	for _, car := range db.carsArray {
-		_, err := statement.Exec(car.CarCompanyName,
-			car.Doors,
-			car.BigLuggage,
-			car.SmallLuggage,
-			car.AdultPlaces,
-			car.AirConditioner,
-			car.MinimumAge,
-			strings.Join(car.AvailableLocations, ","),
-			car.CarGroup,
-			car.Description,
-			car.Price)
-		if err != nil {
-			return errors.Wrap(err, "Failed to execute a prepared statement")
-		}
-	}
-	if err := tx.Commit(); err != nil {
-		return errors.Wrap(err, "Failed to commit a transaction")
+		db.checkMutex.Unlock()
+		return nil, err
 	}
-	log.Info("Cars records inserted sussesfully")
+	return &CheckedTx{Tx: tx, unlock: db.checkMutex.Unlock}, nil
+}
 
-	return nil
+func (tx *CheckedTx) Commit() error {
+	defer tx.release.Do(tx.unlock)
+	return tx.Tx.Commit()
 }
 
-func (db *DBStruct) BeginTransaction() (*sql.Tx, error) {
-	return db.internalDB.Begin()
+func (tx *CheckedTx) Rollback() error {
+	defer tx.release.Do(tx.unlock)
+	return tx.Tx.Rollback()
 }
 
-func (db *DBStruct) Query(query string) (*sql.Rows, error) {
-	return db.internalDB.Query(query)
+func (db *DBStruct) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.internalDB.Query(query, args...)
+}
+
+func (db *DBStruct) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.internalDB.Exec(query, args...)
 }
 
 func (db *DBStruct) Prepare(query string) (*sql.Stmt, error) {
 	return db.internalDB.Prepare(query)
 }
+
+func (db *DBStruct) Close() error {
+	return db.internalDB.Close()
+}
@@ -0,0 +1,114 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+Part of WHERE clause together with values bound to its placeholders
+*/
+type Condition struct {
+	Clause string
+	Args   []interface{}
+}
+
+/*
+Builds SELECT query from base statement and conditions joined by AND
+*/
+type Builder struct {
+	base       string
+	conditions []Condition
+	suffix     string
+}
+
+func NewBuilder(base string) *Builder {
+	return &Builder{base: base}
+}
+
+/*
+Creates condition, every "?" in clause must have matching argument
+*/
+func Cond(clause string, args ...interface{}) Condition {
+	return Condition{Clause: clause, Args: args}
+}
+
+/*
+Joins conditions with OR
+*/
+func Or(conditions ...Condition) Condition {
+	return join(" OR ", conditions)
+}
+
+/*
+Joins conditions with AND
+*/
+func And(conditions ...Condition) Condition {
+	return join(" AND ", conditions)
+}
+
+func join(separator string, conditions []Condition) Condition {
+	var clauses []string
+	var args []interface{}
+	for _, condition := range conditions {
+		if len(condition.Clause) == 0 {
+			continue
+		}
+		clauses = append(clauses, condition.Clause)
+		args = append(args, condition.Args...)
+	}
+	if len(clauses) == 0 {
+		return Condition{}
+	}
+	return Condition{Clause: "(" + strings.Join(clauses, separator) + ")", Args: args}
+}
+
+/*
+Adds condition to WHERE clause, empty conditions are skipped
+*/
+func (builder *Builder) Where(condition Condition) *Builder {
+	if len(condition.Clause) > 0 {
+		builder.conditions = append(builder.conditions, condition)
+	}
+	return builder
+}
+
+/*
+Adds text after WHERE clause such as ORDER BY
+*/
+func (builder *Builder) Suffix(suffix string) *Builder {
+	builder.suffix = suffix
+	return builder
+}
+
+/*
+Returns query text and arguments in order of placeholders
+*/
+func (builder *Builder) Build() (string, []interface{}) {
+	query := builder.base
+	where := And(builder.conditions...)
+	if len(where.Clause) > 0 {
+		query += " WHERE " + where.Clause
+	}
+	if len(builder.suffix) > 0 {
+		query += " " + builder.suffix
+	}
+	return query, where.Args
+}
+
+/*
+Replaces "?" placeholders with numbered "$1", "$2"... placeholders used by PostgreSQL
+*/
+func Rebind(query string) string {
+	var result strings.Builder
+	number := 0
+	for _, symbol := range query {
+		if symbol == '?' {
+			number++
+			result.WriteString("$" + strconv.Itoa(number))
+			continue
+		}
+		result.WriteRune(symbol)
+	}
+	return result.String()
+}
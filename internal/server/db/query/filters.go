@@ -0,0 +1,132 @@
+package query
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+Inclusive integer range, single number means range without upper bound
+*/
+type IntRange struct {
+	Min     int
+	Max     int
+	Bounded bool
+}
+
+/*
+Half-open date range
+*/
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+/*
+Parse non negative integer
+*/
+func ParseInt(field string, value string) (int, error) {
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, validation.New(field, validation.InvalidFormat, "[%s] is not a number", value)
+	}
+	if number < 0 {
+		return 0, validation.New(field, validation.OutOfRange, "[%s] should not be negative", value)
+	}
+	return number, nil
+}
+
+/*
+Parse range in format "min" or "min-max"
+*/
+func ParseIntRange(field string, value string) (IntRange, error) {
+	var result IntRange
+	splitted := strings.Split(value, "-")
+	if len(splitted) > 2 {
+		return result, validation.New(field, validation.InvalidFormat, "[%s] should be in format min or min-max", value)
+	}
+	var err error
+	result.Min, err = ParseInt(field, splitted[0])
+	if err != nil {
+		return result, err
+	}
+	if len(splitted) == 2 {
+		result.Max, err = ParseInt(field, splitted[1])
+		if err != nil {
+			return result, err
+		}
+		if result.Min > result.Max {
+			return result, validation.New(field, validation.OutOfRange, "minimum %d is bigger than maximum %d", result.Min, result.Max)
+		}
+		result.Bounded = true
+	}
+	return result, nil
+}
+
+/*
+Parse dates range, both dates should be provided and from should be before to.
+Dates may have offset or fractional seconds, they are returned in UTC
+*/
+func ParseDateRange(fromField string, from string, toField string, to string) (DateRange, error) {
+	var result DateRange
+	if len(from) == 0 {
+		return result, validation.New(fromField, validation.Required, "should be provided together with %s", toField)
+	}
+	if len(to) == 0 {
+		return result, validation.New(toField, validation.Required, "should be provided together with %s", fromField)
+	}
+	var err error
+	result.From, err = time.Parse(time.RFC3339, from)
+	if err != nil {
+		return result, validation.New(fromField, validation.InvalidFormat, "[%s] should be in format %s", from, domain.TimeLayout)
+	}
+	result.To, err = time.Parse(time.RFC3339, to)
+	if err != nil {
+		return result, validation.New(toField, validation.InvalidFormat, "[%s] should be in format %s", to, domain.TimeLayout)
+	}
+	result.From = result.From.UTC()
+	result.To = result.To.UTC()
+	if !result.From.Before(result.To) {
+		return result, validation.New(fromField, validation.OutOfRange, "should be before %s", toField)
+	}
+	return result, nil
+}
+
+/*
+Parse comma separated list where every value must be one of allowed values
+*/
+func ParseEnumList(field string, value string, allowed []string) ([]string, error) {
+	allowedMap := make(map[string]bool, len(allowed))
+	for _, allowedValue := range allowed {
+		allowedMap[allowedValue] = true
+	}
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if !allowedMap[item] {
+			return nil, validation.New(field, validation.NotAllowed, "[%s] is not one of %s", item, strings.Join(allowed, ", "))
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+/*
+Extract single value of URL parameter, empty string returned when parameter is missing
+*/
+func SingleValue(values map[string][]string, field string) (string, error) {
+	fieldValues, ok := values[field]
+	if !ok {
+		return "", nil
+	}
+	if len(fieldValues) > 1 {
+		return "", validation.New(field, validation.InvalidFormat, "should be provided only once")
+	}
+	if len(fieldValues) == 0 {
+		return "", nil
+	}
+	return fieldValues[0], nil
+}
@@ -0,0 +1,100 @@
+package query
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Test that values never become part of query text
+*/
+func TestBuilderBindsArguments(test *testing.T) {
+	builder := NewBuilder("SELECT * FROM cars")
+	builder.Where(Or(Cond("locations like ?", "%Holon' OR '1'='1%"), Cond("locations like ?", "%Haifa%")))
+	builder.Where(Cond("min_age between ? and ?", 30, 45))
+	builder.Where(Condition{})
+
+	query, args := builder.Build()
+	assert.Equal(test, "SELECT * FROM cars WHERE ((locations like ? OR locations like ?) AND min_age between ? and ?)", query)
+	assert.Equal(test, []interface{}{"%Holon' OR '1'='1%", "%Haifa%", 30, 45}, args)
+}
+
+func TestBuilderWithoutConditions(test *testing.T) {
+	query, args := NewBuilder("SELECT * FROM cars").Suffix("ORDER BY car_id").Build()
+	assert.Equal(test, "SELECT * FROM cars ORDER BY car_id", query)
+	assert.Empty(test, args)
+}
+
+func TestParseIntRange(test *testing.T) {
+	result, err := ParseIntRange("age", "30-45")
+	assert.NoError(test, err)
+	assert.Equal(test, IntRange{Min: 30, Max: 45, Bounded: true}, result)
+
+	result, err = ParseIntRange("age", "30")
+	assert.NoError(test, err)
+	assert.Equal(test, IntRange{Min: 30}, result)
+
+	for _, incorrect := range []string{"45-30", "30-", "1 or 1=1", "1-2-3", "-5"} {
+		_, err = ParseIntRange("age", incorrect)
+		var validationErr *validation.Violation
+		if assert.True(test, errors.As(err, &validationErr), incorrect) {
+			assert.Equal(test, "age", validationErr.Field)
+		}
+	}
+}
+
+func TestParseDateRange(test *testing.T) {
+	_, err := ParseDateRange("fromDate", "2022-01-14T15:13:30Z", "toDate", "2022-01-15T15:13:30Z")
+	assert.NoError(test, err)
+	dates, err := ParseDateRange("fromDate", "2022-01-14T17:13:30+02:00", "toDate", "2022-01-15T15:13:30.250Z")
+	require.NoError(test, err)
+	assert.Equal(test, "2022-01-14T15:13:30Z", dates.From.Format(domain.TimeLayout), "Offset is converted to UTC")
+	assert.Equal(test, "2022-01-15T15:13:30Z", dates.To.Format(domain.TimeLayout))
+
+	testCases := []struct {
+		from  string
+		to    string
+		field string
+		code  string
+	}{
+		{"2022-01-14T15:13:30Z", "", "toDate", validation.Required},
+		{"", "2022-01-14T15:13:30Z", "fromDate", validation.Required},
+		{"2022-01-14", "2022-01-15T15:13:30Z", "fromDate", validation.InvalidFormat},
+		{"2022-01-14T15:13:30Z", "' or 1=1 --", "toDate", validation.InvalidFormat},
+		{"2022-01-15T15:13:30Z", "2022-01-14T15:13:30Z", "fromDate", validation.OutOfRange},
+	}
+	for _, testCase := range testCases {
+		_, err = ParseDateRange("fromDate", testCase.from, "toDate", testCase.to)
+		var validationErr *validation.Violation
+		if assert.True(test, errors.As(err, &validationErr)) {
+			assert.Equal(test, testCase.field, validationErr.Field)
+			assert.Equal(test, testCase.code, validationErr.Code)
+		}
+	}
+}
+
+func TestParseEnumList(test *testing.T) {
+	allowed := []string{"Tel Aviv", "Jerusalem"}
+	result, err := ParseEnumList("location", "Tel Aviv, Jerusalem", allowed)
+	assert.NoError(test, err)
+	assert.Equal(test, []string{"Tel Aviv", "Jerusalem"}, result)
+
+	_, err = ParseEnumList("location", "Tel Aviv,x' OR '1'='1", allowed)
+	var validationErr *validation.Violation
+	if assert.True(test, errors.As(err, &validationErr)) {
+		assert.Equal(test, "location", validationErr.Field)
+	}
+}
+
+func TestRebind(test *testing.T) {
+	query, args := NewBuilder("SELECT * FROM cars").
+		Where(Cond("car_group=?", 2)).
+		Where(Cond("min_age between ? and ?", 30, 45)).Build()
+	assert.Equal(test, "SELECT * FROM cars WHERE (car_group=$1 AND min_age between $2 and $3)", Rebind(query))
+	assert.Len(test, args, 3)
+}
@@ -1,28 +1,6 @@
 package db
 
 var (
-	createCarTable = `CREATE TABLE cars(car_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
-					car_comp_name text,
-					doors INTEGER,
-					big_lag INTEGER,
-					small_lag INTEGER,
-					adult_place INTEGER,
-					condition boolean,
-					min_age INTEGER,
-					locations TEXT,
-					car_group INTEGER,
-					description TEXT,
-					price INTEGER);`
-	createRentTable = `CREATE TABLE rents(rent_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
-					car_id INTEGER,
-					from_time TIMESTAMP,
-					to_time TIMESTAMP,
-					location TEXT,
-					extras TEXT,
-					discounts TEXT,
-					rent_detail text,
-					FOREIGN KEY(car_id) REFERENCES cars(car_id) ON DELETE RESTRICT
-					);`
 	InsertIntoCarTable = `INSERT INTO cars(car_comp_name , doors,
 												big_lag, small_lag,
 												adult_place, condition,
@@ -34,7 +12,14 @@ var (
 											location,
 											extras,
 											discounts,
-											rent_detail) VALUES (?,?,?,?,?,?,?)`
+											rent_detail,
+											branch_id,
+											price_quote,
+											driver_age_group,
+											requested_car_group,
+											return_location,
+											return_branch_id,
+											customer_id) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
 	SelectCars = `SELECT car_id,
 					car_comp_name ,
 					doors,
@@ -46,7 +31,8 @@ var (
 					locations,
 					car_group,
 					description,
-					price FROM cars`
+					price,
+					retired_at FROM cars`
 	UpdateCar = `UPDATE cars 
 				SET car_comp_name = ? ,
 				doors = ? ,
@@ -60,6 +46,13 @@ var (
 				description = ?,
 				price = ?
 				WHERE car_id = ?`
+	RetireCar = `UPDATE cars
+				SET retired_at = ?
+				WHERE car_id = ? AND retired_at IS NULL`
+	SelectCarRetirement = `SELECT retired_at FROM cars
+				WHERE car_id = ?`
+	CountActiveCarRents = `SELECT count(*) FROM rents
+				WHERE car_id = ? AND status IN (?, ?) AND to_time > ?`
 	RemoveCar = `DELETE FROM cars 
 				WHERE car_id = ?`
 	SelectRents = `SELECT rent_id,
@@ -69,8 +62,95 @@ var (
 						location,
 						extras,
 						discounts,
-						rent_detail
+						rent_detail,
+						branch_id,
+						price_quote,
+						driver_age_group,
+						requested_car_group,
+						status,
+						cancellation,
+						return_location,
+						return_branch_id,
+						customer_id
 						FROM rents`
+	UpdateRent = `UPDATE rents
+				SET car_id = ? ,
+				from_time = ? ,
+				to_time = ? ,
+				location = ? ,
+				extras = ? ,
+				discounts = ? ,
+				rent_detail = ? ,
+				branch_id = ? ,
+				price_quote = ? ,
+				driver_age_group = ? ,
+				requested_car_group = ? ,
+				return_location = ? ,
+				return_branch_id = ? ,
+				customer_id = ?
+				WHERE rent_id = ? AND status IN (?, ?)`
+	UpdateRentStatus = `UPDATE rents
+				SET status = ? ,
+				cancellation = ?
+				WHERE rent_id = ? AND status = ?`
+	InsertIntoRentTransitionTable = `INSERT INTO rent_transitions(rent_id,
+											from_status,
+											to_status,
+											odometer,
+											fuel_level,
+											reason,
+											created_at) VALUES (?,?,?,?,?,?,?)`
+	SelectRentTransitions = `SELECT transition_id,
+						rent_id,
+						from_status,
+						to_status,
+						odometer,
+						fuel_level,
+						reason,
+						created_at
+						FROM rent_transitions`
+	ReassignRent = `UPDATE rents
+				SET car_id = ? ,
+				rent_detail = ?
+				WHERE rent_id = ? AND car_id = ? AND status = ?`
+	InsertIntoRentReassignmentTable = `INSERT INTO rent_reassignments(rent_id,
+											from_car_id,
+											to_car_id,
+											kind,
+											reason,
+											created_at) VALUES (?,?,?,?,?,?)`
+	SelectRentReassignments = `SELECT reassignment_id,
+						rent_id,
+						from_car_id,
+						to_car_id,
+						kind,
+						reason,
+						created_at
+						FROM rent_reassignments`
+	InsertIntoBlackoutTable = `INSERT INTO car_blackouts(car_id,
+											from_time,
+											to_time,
+											reason,
+											description,
+											to_location,
+											to_branch_id) VALUES (?,?,?,?,?,?,?)`
+	SelectBlackouts = `SELECT blackout_id,
+						car_id,
+						from_time,
+						to_time,
+						reason,
+						description,
+						to_location,
+						to_branch_id
+						FROM car_blackouts`
+	UpdateBlackout = `UPDATE car_blackouts
+				SET from_time = ? ,
+				to_time = ? ,
+				reason = ? ,
+				description = ?
+				WHERE blackout_id = ?`
+	RemoveBlackout = `DELETE FROM car_blackouts
+				WHERE blackout_id = ?`
 	RemoveRent = `DELETE FROM rents 
 							  WHERE rent_id = ?`
 	SelectCarsRents = `SELECT car_id,
@@ -94,4 +174,109 @@ var (
 								rent_detail
 								FROM cars
 								LEFT JOIN rents using (car_id)`
+	InsertIntoCustomerTable = `INSERT INTO customers(name,
+											email,
+											phone,
+											date_of_birth,
+											licence_number,
+											licence_country,
+											licence_expiry,
+											licence_issued,
+											licence_categories) VALUES (?,?,?,?,?,?,?,?,?)`
+	SelectCustomers = `SELECT customer_id,
+						name,
+						email,
+						phone,
+						date_of_birth,
+						licence_number,
+						licence_country,
+						licence_expiry,
+						licence_issued,
+						licence_categories
+						FROM customers`
+	UpdateCustomer = `UPDATE customers
+				SET name = ? ,
+				email = ? ,
+				phone = ? ,
+				date_of_birth = ? ,
+				licence_number = ? ,
+				licence_country = ? ,
+				licence_expiry = ? ,
+				licence_issued = ? ,
+				licence_categories = ?
+				WHERE customer_id = ?`
+	RemoveCustomer = `DELETE FROM customers
+				WHERE customer_id = ?`
+	InsertIntoBranchTable = `INSERT INTO branches(name,
+											address,
+											latitude,
+											longitude,
+											timezone,
+											opening_hours) VALUES (?,?,?,?,?,?)`
+	SelectBranches = `SELECT branch_id,
+						name,
+						address,
+						latitude,
+						longitude,
+						timezone,
+						opening_hours
+						FROM branches`
+	UpdateBranch = `UPDATE branches
+				SET name = ? ,
+				address = ? ,
+				latitude = ? ,
+				longitude = ? ,
+				timezone = ? ,
+				opening_hours = ?
+				WHERE branch_id = ?`
+	RemoveBranch = `DELETE FROM branches
+				WHERE branch_id = ?`
+	CountBranchRents         = `SELECT (SELECT count(*) FROM rents WHERE branch_id = ? OR return_branch_id = ?) + (SELECT count(*) FROM car_blackouts WHERE to_branch_id = ?)`
+	CountCustomerRents       = `SELECT count(*) FROM rents WHERE customer_id = ?`
+	InsertIntoCarBranchTable = `INSERT INTO car_branches(car_id, branch_id) VALUES (?,?)`
+	RemoveCarBranches        = `DELETE FROM car_branches WHERE car_id = ?`
+	SelectCarBranches        = `SELECT car_id, branch_id FROM car_branches`
+	SelectCarIDsByBranchName = `SELECT car_id FROM car_branches JOIN branches using (branch_id)`
 )
+
+/*
+Statements by name, DB query durations are reported per statement name
+*/
+var statementNames = map[string]string{
+	"InsertIntoCarTable":              InsertIntoCarTable,
+	"InsertIntoRentTable":             InsertIntoRentTable,
+	"SelectCars":                      SelectCars,
+	"UpdateCar":                       UpdateCar,
+	"RetireCar":                       RetireCar,
+	"SelectCarRetirement":             SelectCarRetirement,
+	"CountActiveCarRents":             CountActiveCarRents,
+	"RemoveCar":                       RemoveCar,
+	"SelectRents":                     SelectRents,
+	"UpdateRent":                      UpdateRent,
+	"UpdateRentStatus":                UpdateRentStatus,
+	"InsertIntoRentTransitionTable":   InsertIntoRentTransitionTable,
+	"SelectRentTransitions":           SelectRentTransitions,
+	"ReassignRent":                    ReassignRent,
+	"InsertIntoRentReassignmentTable": InsertIntoRentReassignmentTable,
+	"SelectRentReassignments":         SelectRentReassignments,
+	"InsertIntoBlackoutTable":         InsertIntoBlackoutTable,
+	"SelectBlackouts":                 SelectBlackouts,
+	"UpdateBlackout":                  UpdateBlackout,
+	"RemoveBlackout":                  RemoveBlackout,
+	"RemoveRent":                      RemoveRent,
+	"SelectCarsRents":                 SelectCarsRents,
+	"InsertIntoCustomerTable":         InsertIntoCustomerTable,
+	"SelectCustomers":                 SelectCustomers,
+	"UpdateCustomer":                  UpdateCustomer,
+	"RemoveCustomer":                  RemoveCustomer,
+	"InsertIntoBranchTable":           InsertIntoBranchTable,
+	"SelectBranches":                  SelectBranches,
+	"UpdateBranch":                    UpdateBranch,
+	"RemoveBranch":                    RemoveBranch,
+	"CountBranchRents":                CountBranchRents,
+	"CountCustomerRents":              CountCustomerRents,
+	"InsertIntoCarBranchTable":        InsertIntoCarBranchTable,
+	"RemoveCarBranches":               RemoveCarBranches,
+	"SelectCarBranches":               SelectCarBranches,
+	"SelectCarIDsByBranchName":        SelectCarIDsByBranchName,
+}
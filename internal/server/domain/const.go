@@ -1,13 +1,43 @@
 package domain
 
 const (
-	CarIDPathParam   string = "carID"
-	RentIDPathParam  string = "rentID"
-	FromDateUrlValue string = "fromDate"
-	ToDateUrlValue   string = "toDate"
-	LocationUrlValue string = "location"
-	AgeGroupUrlValue string = "age"
-	CarGroupUrlValue string = "car"
-
-	TimeLayout string = "2006-01-02T15:04:05Z"
+	CarIDPathParam      string = "carID"
+	RentIDPathParam     string = "rentID"
+	BranchIDPathParam   string = "branchID"
+	QuoteIDPathParam    string = "quoteID"
+	BlackoutIDPathParam string = "blackoutID"
+	CustomerIDPathParam string = "customerID"
+	RentActionPathParam string = "action"
+	FromDateUrlValue    string = "fromDate"
+	ToDateUrlValue      string = "toDate"
+	LocationUrlValue    string = "location"
+	AgeGroupUrlValue    string = "age"
+	CarGroupUrlValue    string = "car"
+	DryRunUrlValue      string = "dryRun"
+	ReassignUrlValue    string = "reassign"
+
+	RentalPriceItem   string = "rental"
+	ExtraPriceItem    string = "extra"
+	OneWayPriceItem   string = "one_way"
+	DiscountPriceItem string = "discount"
+	TaxPriceItem      string = "tax"
+
+	ReservedRentStatus  string = "reserved"
+	PickedUpRentStatus  string = "picked_up"
+	ReturnedRentStatus  string = "returned"
+	ClosedRentStatus    string = "closed"
+	CancelledRentStatus string = "cancelled"
+
+	ServiceBlackoutReason    string = "service"
+	DamageBlackoutReason     string = "damage"
+	InspectionBlackoutReason string = "inspection"
+	// Car is moved to other branch during transfer blackout
+	TransferBlackoutReason string = "transfer"
+
+	LikeForLikeReassignment string = "like_for_like"
+	UpgradeReassignment     string = "upgrade"
+
+	TimeLayout         string = "2006-01-02T15:04:05Z"
+	DateLayout         string = "2006-01-02"
+	OpeningHoursLayout string = "15:04"
 )
@@ -7,12 +7,15 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+/*
+Writes response, error is logged here with logger of the request, so handlers do not log it before
+*/
 func WriteResponse(writer http.ResponseWriter, responseCode int, message interface{}, err error) (int, error) {
 	writer.WriteHeader(responseCode)
 	errMsg := ""
 	if err != nil {
 		errMsg = err.Error()
-		log.Error(errMsg)
+		writerLogger(writer).Error(errMsg)
 	}
 	responseMessage := RestResponse{ResponseMessage: message, ResponseError: errMsg}
 	marshaledResponse, err := json.Marshal(responseMessage)
@@ -21,3 +24,13 @@ func WriteResponse(writer http.ResponseWriter, responseCode int, message interfa
 	}
 	return writer.Write(marshaledResponse)
 }
+
+/*
+Logger of the request when writer belongs to pipeline
+*/
+func writerLogger(writer http.ResponseWriter) *log.Entry {
+	if recorder, ok := writer.(*responseRecorder); ok {
+		return Logger(recorder.request)
+	}
+	return log.NewEntry(log.StandardLogger())
+}
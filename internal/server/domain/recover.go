@@ -5,14 +5,20 @@ import (
 	"net/http"
 	"runtime/debug"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/pkg/errors"
 )
 
-// RecoverHTTPPanic - recovers possible panic during HTTP processing and writes it into output HTTP message
-func RecoverHTTPPanic(writer http.ResponseWriter) {
+// RecoverHTTPPanic - recovers possible panic during HTTP processing and answers 500 with ID of the request
+func RecoverHTTPPanic(writer http.ResponseWriter, request *http.Request) {
 	if r := recover(); r != nil {
-		err := fmt.Errorf("Detected panic: %s", r)
-		log.Errorf("Error on processing the http request: %s\n%s", err, string(debug.Stack())) // print panic message and stack trace
-		WriteResponse(writer, http.StatusBadRequest, "", err)
+		Logger(request).Errorf("Detected panic on processing the http request: %v\n%s", r, string(debug.Stack())) // print panic message and stack trace
+		if recorder, ok := writer.(*responseRecorder); ok && recorder.written {
+			// Part of response is already sent, status can not be changed
+			return
+		}
+		message := fmt.Sprintf("Internal server error, request ID %s", RequestID(request))
+		if _, err := WriteResponse(writer, http.StatusInternalServerError, message, errors.New("Internal server error")); err != nil {
+			Logger(request).Error(err)
+		}
 	}
 }
@@ -7,22 +7,115 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// RequestHTTPFilterFunc - prefilters incoming requests
-type RequestHTTPFilterFunc = func(request *http.Request) (int, error)
+type (
+	// RequestHTTPFilterFunc - prefilters incoming requests, request is rejected with returned code when filter returns error
+	RequestHTTPFilterFunc = func(request *http.Request) (int, error)
 
-func WrapREST(handleFunc func(writer http.ResponseWriter, request *http.Request), filterFunc ...RequestHTTPFilterFunc) http.Handler {
+	// ResponseHTTPHookFunc - called after request is handled, rejected by filter or recovered from panic
+	ResponseHTTPHookFunc = func(request *http.Request, response ResponseInfo)
+
+	/*
+		Written response of the request
+	*/
+	ResponseInfo struct {
+		Code     int
+		Bytes    int
+		Duration time.Duration
+	}
 
-	processingFunc := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		defer RecoverHTTPPanic(writer)
+	/*
+		Filters and hooks wrapped around REST handlers. Filters are called in order before handler
+		and share request scope with it, hooks are called in order after response is written
+	*/
+	Pipeline struct {
+		filters []RequestHTTPFilterFunc
+		hooks   []ResponseHTTPHookFunc
+	}
+)
+
+func NewPipeline() Pipeline {
+	return Pipeline{}
+}
+
+/*
+New pipeline with filters added after existing ones
+*/
+func (pipeline Pipeline) Filter(filterFunc ...RequestHTTPFilterFunc) Pipeline {
+	filters := make([]RequestHTTPFilterFunc, 0, len(pipeline.filters)+len(filterFunc))
+	pipeline.filters = append(append(filters, pipeline.filters...), filterFunc...)
+	return pipeline
+}
+
+/*
+New pipeline with hooks added after existing ones
+*/
+func (pipeline Pipeline) Hook(hookFunc ...ResponseHTTPHookFunc) Pipeline {
+	hooks := make([]ResponseHTTPHookFunc, 0, len(pipeline.hooks)+len(hookFunc))
+	pipeline.hooks = append(append(hooks, pipeline.hooks...), hookFunc...)
+	return pipeline
+}
 
-		log.Debugln("Start handle request", request)
+/*
+Wraps REST handler. Every request gets its own scope with request ID, which is returned in X-Request-ID header.
+Request rejected by filter is answered with its code and error, panic is answered with 500
+*/
+func (pipeline Pipeline) Wrap(handleFunc func(writer http.ResponseWriter, request *http.Request)) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		request = withScope(request)
+		recorder := &responseRecorder{ResponseWriter: writer, request: request, code: http.StatusOK}
+		recorder.Header().Set(RequestIDHeader, RequestID(request))
 		handleStartTime := time.Now()
+		defer func() {
+			response := ResponseInfo{Code: recorder.code, Bytes: recorder.bytes, Duration: time.Since(handleStartTime)}
+			for _, hook := range pipeline.hooks {
+				hook(request, response)
+			}
+		}()
+		defer RecoverHTTPPanic(recorder, request)
 
-		handleFunc(writer, request) // call original
+		Logger(request).WithFields(log.Fields{"method": request.Method, "path": request.URL.Path}).Debug("Start handle request")
+		for _, filter := range pipeline.filters {
+			if responseCode, err := filter(request); err != nil {
+				if _, err := WriteResponse(recorder, responseCode, http.StatusText(responseCode), err); err != nil {
+					Logger(request).Error(err)
+				}
+				return
+			}
+		}
 
-		handleEndTime := time.Now()
-		log.Debugln("Request handled in", handleEndTime.Sub(handleStartTime))
+		handleFunc(recorder, request) // call original
+
+		Logger(request).Debugln("Request handled in", time.Since(handleStartTime))
 	})
+}
+
+func WrapREST(handleFunc func(writer http.ResponseWriter, request *http.Request), filterFunc ...RequestHTTPFilterFunc) http.Handler {
+	return NewPipeline().Filter(filterFunc...).Wrap(handleFunc)
+}
+
+/*
+Keeps response code and size for hooks and tells whether response was started
+*/
+type responseRecorder struct {
+	http.ResponseWriter
+	request *http.Request
+	code    int
+	bytes   int
+	written bool
+}
+
+func (recorder *responseRecorder) WriteHeader(code int) {
+	if recorder.written {
+		return
+	}
+	recorder.code = code
+	recorder.written = true
+	recorder.ResponseWriter.WriteHeader(code)
+}
 
-	return processingFunc
+func (recorder *responseRecorder) Write(body []byte) (int, error) {
+	recorder.written = true
+	written, err := recorder.ResponseWriter.Write(body)
+	recorder.bytes += written
+	return written, err
 }
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type callerKey struct{}
+
+func TestPipeline(test *testing.T) {
+	var calls []string
+	var hookCodes []int
+	pipeline := NewPipeline().
+		Filter(func(request *http.Request) (int, error) {
+			calls = append(calls, "caller")
+			SetRequestValue(request, callerKey{}, request.Header.Get("X-Caller"))
+			return http.StatusOK, nil
+		}).
+		Filter(func(request *http.Request) (int, error) {
+			calls = append(calls, "check")
+			if RequestValue(request, callerKey{}) == "" {
+				return http.StatusUnauthorized, errors.New("Caller is not known")
+			}
+			return http.StatusOK, nil
+		}).
+		Hook(func(request *http.Request, response ResponseInfo) {
+			hookCodes = append(hookCodes, response.Code)
+		})
+	handler := pipeline.Wrap(func(writer http.ResponseWriter, request *http.Request) {
+		calls = append(calls, "handler")
+		WriteResponse(writer, http.StatusCreated, RequestValue(request, callerKey{}), nil)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/api/cars", nil)
+	request.Header.Set("X-Caller", "admin")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(test, http.StatusCreated, recorder.Code)
+	assert.JSONEq(test, `{"responseMessage":"admin","responseError":""}`, recorder.Body.String(), "Handler sees values set by filters")
+	assert.Len(test, recorder.Header().Get(RequestIDHeader), 16, "Request ID is generated")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/cars", nil))
+	assert.Equal(test, http.StatusUnauthorized, recorder.Code)
+	assert.JSONEq(test, `{"responseMessage":"Unauthorized","responseError":"Caller is not known"}`, recorder.Body.String())
+
+	assert.Equal(test, []string{"caller", "check", "handler", "caller", "check"}, calls, "Rejected request does not reach handler")
+	assert.Equal(test, []int{http.StatusCreated, http.StatusUnauthorized}, hookCodes)
+
+	// Extending pipeline does not change it
+	assert.Len(test, pipeline.Filter(func(request *http.Request) (int, error) { return http.StatusOK, nil }).filters, 3)
+	assert.Len(test, pipeline.filters, 2)
+}
+
+func TestPipelineRecoversPanic(test *testing.T) {
+	var hookCode int
+	handler := NewPipeline().
+		Hook(func(request *http.Request, response ResponseInfo) { hookCode = response.Code }).
+		Wrap(func(writer http.ResponseWriter, request *http.Request) {
+			var cars map[string]int
+			cars["broken"]++
+		})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/cars", nil))
+	assert.Equal(test, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(test, http.StatusInternalServerError, hookCode, "Hooks see recovered panic")
+
+	requestID := recorder.Header().Get(RequestIDHeader)
+	require.NotEmpty(test, requestID)
+	var response RestResponse
+	require.NoError(test, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.True(test, strings.HasSuffix(response.ResponseMessage.(string), requestID))
+	assert.NotContains(test, recorder.Body.String(), "nil map", "Panic details are only logged")
+}
+
+func TestPipelineRequestID(test *testing.T) {
+	var hooked ResponseInfo
+	var logged string
+	handler := NewPipeline().
+		Hook(func(request *http.Request, response ResponseInfo) { hooked = response }).
+		Wrap(func(writer http.ResponseWriter, request *http.Request) {
+			logged, _ = Logger(request).Data["requestID"].(string)
+			writer.Write([]byte("done"))
+		})
+	expected := map[string]bool{
+		"edge-7f3a.1":             true,
+		"":                        false,
+		"has space":               false,
+		strings.Repeat("a", 129):  false,
+		"line\nbreak":             false,
+		"1b4e28ba-2fa1-11d2-883f": true,
+	}
+	for requestID, kept := range expected {
+		request := httptest.NewRequest(http.MethodGet, "/api/cars", nil)
+		request.Header.Set(RequestIDHeader, requestID)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		returned := recorder.Header().Get(RequestIDHeader)
+		assert.Equal(test, returned, logged, "Logger has request ID")
+		if kept {
+			assert.Equal(test, requestID, returned)
+		} else {
+			assert.Len(test, returned, 16, "ID [%s] is replaced", requestID)
+		}
+	}
+	assert.Equal(test, http.StatusOK, hooked.Code)
+	assert.Equal(test, 4, hooked.Bytes)
+}
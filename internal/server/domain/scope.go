@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Request header with ID of the request, it is generated when client does not send it and is returned in response
+const RequestIDHeader = "X-Request-ID"
+
+// Request ID sent by client is kept when it is short and has no spaces or control characters
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:/+=-]{1,128}$`)
+
+type (
+	scopeKey struct{}
+
+	/*
+		Values shared by filters, handler and hooks of one request
+	*/
+	requestScope struct {
+		requestID string
+		mutex     sync.RWMutex
+		logger    *log.Entry
+		values    map[interface{}]interface{}
+	}
+)
+
+func withScope(request *http.Request) *http.Request {
+	if _, ok := request.Context().Value(scopeKey{}).(*requestScope); ok {
+		return request
+	}
+	requestID := request.Header.Get(RequestIDHeader)
+	if !requestIDPattern.MatchString(requestID) {
+		requestID = newRequestID()
+	}
+	scope := &requestScope{requestID: requestID, logger: log.WithField("requestID", requestID), values: make(map[interface{}]interface{})}
+	return request.WithContext(context.WithValue(request.Context(), scopeKey{}, scope))
+}
+
+/*
+Store value in scope of the request, it is ignored for request which is not wrapped by pipeline
+*/
+func SetRequestValue(request *http.Request, key interface{}, value interface{}) {
+	if scope, ok := request.Context().Value(scopeKey{}).(*requestScope); ok {
+		scope.mutex.Lock()
+		defer scope.mutex.Unlock()
+		scope.values[key] = value
+	}
+}
+
+/*
+Value stored in scope of the request, nil when it was not stored
+*/
+func RequestValue(request *http.Request, key interface{}) interface{} {
+	if scope, ok := request.Context().Value(scopeKey{}).(*requestScope); ok {
+		scope.mutex.RLock()
+		defer scope.mutex.RUnlock()
+		return scope.values[key]
+	}
+	return nil
+}
+
+/*
+ID of the request, it is logged together with every message of the request and returned to client
+*/
+func RequestID(request *http.Request) string {
+	if scope, ok := request.Context().Value(scopeKey{}).(*requestScope); ok {
+		return scope.requestID
+	}
+	return ""
+}
+
+/*
+Logger of the request, every message has request ID and fields added by AddLogFields
+*/
+func Logger(request *http.Request) *log.Entry {
+	if scope, ok := request.Context().Value(scopeKey{}).(*requestScope); ok {
+		scope.mutex.RLock()
+		defer scope.mutex.RUnlock()
+		return scope.logger
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+/*
+Add fields to every following message of the request logger, e.g. caller found by filter
+*/
+func AddLogFields(request *http.Request, fields log.Fields) {
+	if scope, ok := request.Context().Value(scopeKey{}).(*requestScope); ok {
+		scope.mutex.Lock()
+		defer scope.mutex.Unlock()
+		scope.logger = scope.logger.WithFields(fields)
+	}
+}
+
+func newRequestID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "car-rental/internal/server/validation"
+
 type (
 	RestResponse struct {
 		ResponseMessage interface{} `json:"responseMessage,omitempty"`
@@ -17,21 +19,32 @@ type (
 		MinimumAge         int      `json:"minimumAge"`
 		Price              int      `json:"price"`
 		AvailableLocations []string `json:"availableLocations,omitempty"`
+		BranchIDs          []int    `json:"branchIDs,omitempty"`
 		CarGroup           int      `json:"carGroup"`
 		Description        string   `json:"description"`
+		// Set when car is retired, retired car is kept for its rents only
+		RetiredAt string `json:"retiredAt,omitempty"`
 	}
 
 	RentInfo struct {
-		RentID          int      `json:"rentID"`
-		CarID           int      `json:"carID"`
-		FromDate        string   `json:"fromDate"`
-		ToDate          string   `json:"toDate"`
-		Location        string   `json:"location"`
-		AvailableExtras []string `json:"availableExtras,omitempty"`
-		Discounts       []string `json:"discounts,omitempty"`
-		CarDetails      string   `json:"carDetails"`
-		AgeGroup        string   `json:"ageGroup,omitempty"`
-		CarGroup        int      `json:"carGroup,omitempty"`
+		RentID          int         `json:"rentID"`
+		CarID           int         `json:"carID"`
+		CustomerID      int         `json:"customerID,omitempty"`
+		FromDate        string      `json:"fromDate"`
+		ToDate          string      `json:"toDate"`
+		Location        string      `json:"location"`
+		BranchID        int         `json:"branchID,omitempty"`
+		ReturnLocation  string      `json:"returnLocation,omitempty"`
+		ReturnBranchID  int         `json:"returnBranchID,omitempty"`
+		AvailableExtras []string    `json:"availableExtras,omitempty"`
+		Discounts       []string    `json:"discounts,omitempty"`
+		CarDetails      string      `json:"carDetails"`
+		Quote           *PriceQuote `json:"quote,omitempty"`
+		AgeGroup        string      `json:"ageGroup,omitempty"`
+		CarGroup        int         `json:"carGroup,omitempty"`
+		Status          string      `json:"status,omitempty"`
+		// Set when rent is cancelled
+		Cancellation *Cancellation `json:"cancellation,omitempty"`
 	}
 
 	CombinedRentInfo struct {
@@ -45,8 +58,219 @@ type (
 		CarDetails      string   `json:"carDetails,omitempty"`
 	}
 
-	SearchParams struct {
-		DateFilter    string
-		DefaultFilter string
+	/*
+		Customer who rents cars together with driver licence. Dates are in format 2006-01-02
+	*/
+	Customer struct {
+		CustomerID     int    `json:"customerID"`
+		Name           string `json:"name"`
+		Email          string `json:"email"`
+		Phone          string `json:"phone,omitempty"`
+		DateOfBirth    string `json:"dateOfBirth"`
+		LicenceNumber  string `json:"licenceNumber"`
+		LicenceCountry string `json:"licenceCountry"`
+		LicenceExpiry  string `json:"licenceExpiry"`
+		// Customers created before licence rules have no issue date and categories
+		LicenceIssued     string   `json:"licenceIssued,omitempty"`
+		LicenceCategories []string `json:"licenceCategories,omitempty"`
+	}
+
+	Branch struct {
+		BranchID     int            `json:"branchID"`
+		Name         string         `json:"name"`
+		Address      string         `json:"address,omitempty"`
+		Latitude     float64        `json:"latitude"`
+		Longitude    float64        `json:"longitude"`
+		Timezone     string         `json:"timezone"`
+		OpeningHours []OpeningHours `json:"openingHours,omitempty"`
+	}
+
+	OpeningHours struct {
+		Day   string `json:"day"`
+		Open  string `json:"open"`
+		Close string `json:"close"`
+	}
+
+	/*
+		Itemized price of a rent, all amounts are in cents
+	*/
+	PriceQuote struct {
+		DailyRate int64       `json:"dailyRate"`
+		Days      int         `json:"days"`
+		Items     []PriceItem `json:"items"`
+		Subtotal  int64       `json:"subtotal"`
+		Discount  int64       `json:"discount"`
+		Tax       int64       `json:"tax"`
+		Total     int64       `json:"total"`
+	}
+
+	PriceItem struct {
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Quantity  int    `json:"quantity"`
+		UnitPrice int64  `json:"unitPrice,omitempty"`
+		Amount    int64  `json:"amount"`
+	}
+
+	/*
+		Priced rent which can be booked until quote expires
+	*/
+	RentQuote struct {
+		QuoteID   string   `json:"quoteID"`
+		ExpiresAt string   `json:"expiresAt"`
+		Rent      RentInfo `json:"rent"`
+	}
+
+	/*
+		Change of rent status together with car state at the moment of change
+	*/
+	RentTransition struct {
+		TransitionID int    `json:"transitionID"`
+		RentID       int    `json:"rentID"`
+		FromStatus   string `json:"fromStatus"`
+		ToStatus     string `json:"toStatus"`
+		Odometer     *int   `json:"odometer,omitempty"`
+		FuelLevel    *int   `json:"fuelLevel,omitempty"`
+		Reason       string `json:"reason,omitempty"`
+		CreatedAt    string `json:"createdAt"`
+	}
+
+	/*
+		Fee kept from rent total when rent is cancelled, all amounts are in cents
+	*/
+	Cancellation struct {
+		Reason      string `json:"reason,omitempty"`
+		CancelledAt string `json:"cancelledAt"`
+		// Applied rule of cancellation policy
+		Policy string `json:"policy"`
+		Total  int64  `json:"total"`
+		Fee    int64  `json:"fee"`
+		Refund int64  `json:"refund"`
+	}
+
+	/*
+		Booking moved from one car to another, kind tells whether new car is an upgrade or like-for-like swap
+	*/
+	Reassignment struct {
+		ReassignmentID int    `json:"reassignmentID,omitempty"`
+		RentID         int    `json:"rentID"`
+		FromCarID      int    `json:"fromCarID"`
+		ToCarID        int    `json:"toCarID"`
+		Kind           string `json:"kind"`
+		Reason         string `json:"reason,omitempty"`
+		CreatedAt      string `json:"createdAt,omitempty"`
+	}
+
+	/*
+		Bookings of unavailable car which are moved to other cars and bookings which can not be moved.
+		Nothing is moved by dry run
+	*/
+	ReassignmentPlan struct {
+		CarID      int            `json:"carID"`
+		DryRun     bool           `json:"dryRun"`
+		Reassigned []Reassignment `json:"reassigned"`
+		Unassigned []RentInfo     `json:"unassigned"`
+	}
+
+	CarRetirement struct {
+		CarID      int            `json:"carID"`
+		RetiredAt  string         `json:"retiredAt"`
+		Reassigned []Reassignment `json:"reassigned,omitempty"`
+	}
+
+	/*
+		Bookings which prevent car retirement
+	*/
+	CarInUse struct {
+		Message string     `json:"message"`
+		Rents   []RentInfo `json:"rents"`
+	}
+
+	RentConflict struct {
+		Message                string `json:"message"`
+		ConflictingRentIDs     []int  `json:"conflictingRentIDs"`
+		ConflictingBlackoutIDs []int  `json:"conflictingBlackoutIDs,omitempty"`
+	}
+
+	/*
+		Branch where car stays after rent ends until it is picked up again
+	*/
+	CarPosition struct {
+		CarID int `json:"carID"`
+		// Rent or transfer which moved the car
+		RentID     int    `json:"rentID,omitempty"`
+		BlackoutID int    `json:"blackoutID,omitempty"`
+		Location   string `json:"location"`
+		BranchID   int    `json:"branchID,omitempty"`
+		Since      string `json:"since"`
+	}
+
+	/*
+		Date range when car is out of service, car can not be rented during blackout
+	*/
+	Blackout struct {
+		BlackoutID  int    `json:"blackoutID"`
+		CarID       int    `json:"carID"`
+		FromDate    string `json:"fromDate"`
+		ToDate      string `json:"toDate"`
+		Reason      string `json:"reason"`
+		Description string `json:"description,omitempty"`
+		// Branch where transfer leaves the car
+		ToLocation string `json:"toLocation,omitempty"`
+		ToBranchID int    `json:"toBranchID,omitempty"`
+	}
+
+	/*
+		Move of idle car from branch with surplus to branch with shortage of its car group
+	*/
+	Transfer struct {
+		CarID        int    `json:"carID"`
+		CarGroup     int    `json:"carGroup"`
+		FromLocation string `json:"fromLocation"`
+		FromBranchID int    `json:"fromBranchID"`
+		ToLocation   string `json:"toLocation"`
+		ToBranchID   int    `json:"toBranchID"`
+		FromDate     string `json:"fromDate"`
+		ToDate       string `json:"toDate"`
+		// Transfer blackout created when plan is applied
+		BlackoutID int `json:"blackoutID,omitempty"`
+	}
+
+	/*
+		Pickups of car group booked at branch in planning period, idle cars at branch and number of idle cars it should have
+	*/
+	BranchDemand struct {
+		BranchID int    `json:"branchID"`
+		Location string `json:"location"`
+		CarGroup int    `json:"carGroup"`
+		Demand   int    `json:"demand"`
+		IdleCars int    `json:"idleCars"`
+		Target   int    `json:"target"`
+	}
+
+	RebalancePlan struct {
+		FromDate  string         `json:"fromDate"`
+		ToDate    string         `json:"toDate"`
+		Applied   bool           `json:"applied"`
+		Demand    []BranchDemand `json:"demand"`
+		Transfers []Transfer     `json:"transfers"`
+	}
+
+	/*
+		Customer token issued to back-office integration, it is sent as Authorization: Bearer <token>
+	*/
+	AuthToken struct {
+		Token      string `json:"token"`
+		TokenType  string `json:"tokenType"`
+		ExpiresAt  string `json:"expiresAt"`
+		CustomerID int    `json:"customerID"`
+	}
+
+	/*
+		Every violation found in rejected request
+	*/
+	ValidationFailure struct {
+		Message    string                 `json:"message"`
+		Violations []validation.Violation `json:"violations"`
 	}
 )
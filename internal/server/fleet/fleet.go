@@ -0,0 +1,220 @@
+package fleet
+
+import (
+	"car-rental/internal/server/domain"
+	"sort"
+	"time"
+)
+
+type (
+	/*
+		Rents and transfers of one car ordered by time they take the car. Car stays at branch where its last
+		rent or transfer left it, car which was not moved yet stays at branches it is linked to
+	*/
+	Timeline struct {
+		car   domain.Car
+		moves []Move
+	}
+
+	/*
+		Rent or transfer which takes the car at From and leaves it at ReturnLocation at To.
+		Transfer takes the car wherever it is, so its Location is empty
+	*/
+	Move struct {
+		RentID         int
+		BlackoutID     int
+		Location       string
+		ReturnLocation string
+		ReturnBranchID int
+		From           time.Time
+		To             time.Time
+	}
+)
+
+/*
+Build timeline of the car from its rents and blackouts. Cancelled rents, blackouts which are not transfers
+and records with broken dates do not move the car
+*/
+func NewTimeline(car domain.Car, rents []domain.RentInfo, blackouts []domain.Blackout) *Timeline {
+	timeline := &Timeline{car: car}
+	for _, rent := range rents {
+		if rent.Status == domain.CancelledRentStatus {
+			continue
+		}
+		from, to, ok := parseDates(rent.FromDate, rent.ToDate)
+		if !ok {
+			continue
+		}
+		timeline.moves = append(timeline.moves, Move{
+			RentID:         rent.RentID,
+			Location:       rent.Location,
+			ReturnLocation: ReturnLocation(rent),
+			ReturnBranchID: ReturnBranchID(rent),
+			From:           from,
+			To:             to,
+		})
+	}
+	for _, blackout := range blackouts {
+		if !IsTransfer(blackout) {
+			continue
+		}
+		from, to, ok := parseDates(blackout.FromDate, blackout.ToDate)
+		if !ok {
+			continue
+		}
+		timeline.moves = append(timeline.moves, Move{
+			BlackoutID:     blackout.BlackoutID,
+			ReturnLocation: blackout.ToLocation,
+			ReturnBranchID: blackout.ToBranchID,
+			From:           from,
+			To:             to,
+		})
+	}
+	sort.SliceStable(timeline.moves, func(i, j int) bool {
+		if !timeline.moves[i].From.Equal(timeline.moves[j].From) {
+			return timeline.moves[i].From.Before(timeline.moves[j].From)
+		}
+		if timeline.moves[i].RentID != timeline.moves[j].RentID {
+			return timeline.moves[i].RentID < timeline.moves[j].RentID
+		}
+		return timeline.moves[i].BlackoutID < timeline.moves[j].BlackoutID
+	})
+	return timeline
+}
+
+/*
+Position of the car after every rent and transfer in order they end
+*/
+func (timeline *Timeline) Positions() []domain.CarPosition {
+	positions := []domain.CarPosition{}
+	for _, move := range timeline.moves {
+		positions = append(positions, timeline.position(move))
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].Since < positions[j].Since
+	})
+	return positions
+}
+
+/*
+Where car stays at the moment, nil is returned when no rent or transfer of the car ended before
+*/
+func (timeline *Timeline) PositionAt(at time.Time) *domain.CarPosition {
+	var last *Move
+	for index, move := range timeline.moves {
+		if move.To.After(at) {
+			continue
+		}
+		if last == nil || move.To.After(last.To) {
+			last = &timeline.moves[index]
+		}
+	}
+	if last == nil {
+		return nil
+	}
+	position := timeline.position(*last)
+	return &position
+}
+
+/*
+First rent or transfer which takes the car at the moment or later, nil is returned when there is no such move
+*/
+func (timeline *Timeline) NextMove(at time.Time) *Move {
+	for _, move := range timeline.moves {
+		if !move.From.Before(at) {
+			next := move
+			return &next
+		}
+	}
+	return nil
+}
+
+/*
+Car is idle when nothing holds it at the moment and nothing is planned for it later
+*/
+func (timeline *Timeline) IsIdle(at time.Time) bool {
+	for _, move := range timeline.moves {
+		if move.To.After(at) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Names of branches where car can be picked up at from and returned at to, so that the next rent finds the car
+at its pickup branch
+*/
+func (timeline *Timeline) Locations(from time.Time, to time.Time) []string {
+	locations := timeline.car.AvailableLocations
+	if position := timeline.PositionAt(from); position != nil {
+		locations = []string{position.Location}
+	}
+	next := timeline.NextMove(to)
+	if next == nil || len(next.Location) == 0 {
+		return locations
+	}
+	for _, location := range locations {
+		if location == next.Location {
+			return []string{location}
+		}
+	}
+	return nil
+}
+
+func (timeline *Timeline) position(move Move) domain.CarPosition {
+	return domain.CarPosition{
+		CarID:      timeline.car.CarID,
+		RentID:     move.RentID,
+		BlackoutID: move.BlackoutID,
+		Location:   move.ReturnLocation,
+		BranchID:   move.ReturnBranchID,
+		Since:      move.To.Format(domain.TimeLayout),
+	}
+}
+
+func parseDates(from string, to string) (time.Time, time.Time, bool) {
+	fromTime, err := time.Parse(domain.TimeLayout, from)
+	if err != nil {
+		return fromTime, fromTime, false
+	}
+	toTime, err := time.Parse(domain.TimeLayout, to)
+	if err != nil || !fromTime.Before(toTime) {
+		return fromTime, toTime, false
+	}
+	return fromTime, toTime, true
+}
+
+/*
+Branch name where rent returns the car, rent without drop-off branch is returned where it started
+*/
+func ReturnLocation(rent domain.RentInfo) string {
+	if len(rent.ReturnLocation) == 0 {
+		return rent.Location
+	}
+	return rent.ReturnLocation
+}
+
+/*
+Branch ID where rent returns the car, rent without drop-off branch is returned where it started
+*/
+func ReturnBranchID(rent domain.RentInfo) int {
+	if rent.ReturnBranchID == 0 {
+		return rent.BranchID
+	}
+	return rent.ReturnBranchID
+}
+
+/*
+Rent is one-way when car is returned to other branch than it was picked up at
+*/
+func IsOneWay(rent domain.RentInfo) bool {
+	return ReturnLocation(rent) != rent.Location
+}
+
+/*
+Transfer is blackout which moves the car to other branch
+*/
+func IsTransfer(blackout domain.Blackout) bool {
+	return blackout.Reason == domain.TransferBlackoutReason && len(blackout.ToLocation) > 0
+}
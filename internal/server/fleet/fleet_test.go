@@ -0,0 +1,80 @@
+package fleet
+
+import (
+	"car-rental/internal/server/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCar = domain.Car{CarID: 1, AvailableLocations: []string{"Haifa", "Tel Aviv"}}
+
+func moment(value string) time.Time {
+	parsed, _ := time.Parse(time.RFC3339, value)
+	return parsed
+}
+
+func testTimeline() *Timeline {
+	return NewTimeline(testCar, []domain.RentInfo{
+		{RentID: 3, FromDate: "2022-01-20T10:00:00Z", ToDate: "2022-01-22T10:00:00Z", Location: "Jerusalem", ReturnLocation: "Jerusalem"},
+		{RentID: 1, FromDate: "2022-01-10T10:00:00Z", ToDate: "2022-01-12T10:00:00Z", Location: "Haifa", ReturnLocation: "Jerusalem"},
+		{RentID: 2, FromDate: "2022-01-14T10:00:00Z", ToDate: "2022-01-16T10:00:00Z", Location: "Jerusalem", ReturnLocation: "Ashdod", Status: domain.CancelledRentStatus},
+	}, []domain.Blackout{
+		{BlackoutID: 1, FromDate: "2022-01-24T10:00:00Z", ToDate: "2022-01-24T14:00:00Z", Reason: domain.TransferBlackoutReason, ToLocation: "Haifa"},
+		{BlackoutID: 2, FromDate: "2022-01-17T10:00:00Z", ToDate: "2022-01-17T14:00:00Z", Reason: domain.ServiceBlackoutReason},
+	})
+}
+
+func TestPositions(test *testing.T) {
+	assert.Equal(test, []domain.CarPosition{
+		{CarID: 1, RentID: 1, Location: "Jerusalem", Since: "2022-01-12T10:00:00Z"},
+		{CarID: 1, RentID: 3, Location: "Jerusalem", Since: "2022-01-22T10:00:00Z"},
+		{CarID: 1, BlackoutID: 1, Location: "Haifa", Since: "2022-01-24T14:00:00Z"},
+	}, testTimeline().Positions())
+}
+
+/*
+Test that car is at its branches until the first rent ends, cancelled rent and service blackout do not move it
+*/
+func TestPositionAt(test *testing.T) {
+	timeline := testTimeline()
+	assert.Nil(test, timeline.PositionAt(moment("2022-01-12T09:59:59Z")))
+	position := timeline.PositionAt(moment("2022-01-17T10:00:00Z"))
+	require.NotNil(test, position)
+	assert.Equal(test, 1, position.RentID)
+	assert.Equal(test, "Jerusalem", position.Location)
+}
+
+func TestLocations(test *testing.T) {
+	timeline := testTimeline()
+	testCases := []struct {
+		name     string
+		from     string
+		to       string
+		expected []string
+	}{
+		{"before first rent", "2022-01-01T10:00:00Z", "2022-01-05T10:00:00Z", []string{"Haifa"}},
+		{"after one-way rent", "2022-01-13T10:00:00Z", "2022-01-18T10:00:00Z", []string{"Jerusalem"}},
+		{"before transfer", "2022-01-22T10:00:00Z", "2022-01-23T10:00:00Z", []string{"Jerusalem"}},
+		{"after transfer", "2022-01-25T10:00:00Z", "2022-01-26T10:00:00Z", []string{"Haifa"}},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(test, testCase.expected, timeline.Locations(moment(testCase.from), moment(testCase.to)), testCase.name)
+	}
+	empty := NewTimeline(testCar, nil, nil)
+	assert.Equal(test, []string{"Haifa", "Tel Aviv"}, empty.Locations(moment("2022-01-01T10:00:00Z"), moment("2022-01-05T10:00:00Z")))
+}
+
+func TestIsIdle(test *testing.T) {
+	timeline := testTimeline()
+	assert.False(test, timeline.IsIdle(moment("2022-01-23T10:00:00Z")), "Transfer is planned")
+	assert.True(test, timeline.IsIdle(moment("2022-01-24T14:00:00Z")))
+}
+
+func TestIsOneWay(test *testing.T) {
+	assert.False(test, IsOneWay(domain.RentInfo{Location: "Haifa"}))
+	assert.False(test, IsOneWay(domain.RentInfo{Location: "Haifa", ReturnLocation: "Haifa"}))
+	assert.True(test, IsOneWay(domain.RentInfo{Location: "Haifa", ReturnLocation: "Jerusalem"}))
+}
@@ -0,0 +1,142 @@
+package licence
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"strings"
+	"time"
+)
+
+// Field of rent body which refers to the driver
+const customerField = "customerID"
+
+type (
+	/*
+		Licence driver should hold to rent car of some car group
+	*/
+	Requirement struct {
+		// Licence with any of the categories is enough, empty list accepts every licence
+		Categories []string
+		// Full years since licence was issued at pickup
+		MinYearsHeld int
+	}
+
+	/*
+		Licence check of one issuing country. Violations are reported for rent field customerID
+	*/
+	Validator interface {
+		Check(customer domain.Customer, carGroup int, pickup time.Time) validation.Violations
+	}
+
+	/*
+		Requirements per car group, car groups which are not listed use Default
+	*/
+	GroupRules struct {
+		Groups  map[int]Requirement
+		Default Requirement
+	}
+
+	/*
+		Licence validators keyed by issuing country, licences of other countries are checked by Default
+	*/
+	Rules struct {
+		Countries map[string]Validator
+		Default   Validator
+	}
+)
+
+var (
+	// Car licence (category B) held at least one year
+	DefaultRequirement = Requirement{Categories: []string{"B"}, MinYearsHeld: 1}
+
+	/*
+		US licences have classes instead of categories, class D is for cars
+	*/
+	DefaultRules = Rules{
+		Countries: map[string]Validator{
+			"US": GroupRules{Default: Requirement{Categories: []string{"D"}, MinYearsHeld: 1}},
+		},
+		Default: GroupRules{Default: DefaultRequirement},
+	}
+)
+
+/*
+Check licence of the customer for rent of car group from pickup to return.
+Licence of every country should be valid until return, other checks are done by validator of issuing country
+*/
+func (rules Rules) Check(customer domain.Customer, carGroup int, pickup time.Time, returnAt time.Time) validation.Violations {
+	var violations validation.Violations
+	expiry, err := time.Parse(domain.DateLayout, customer.LicenceExpiry)
+	if err != nil || expiry.AddDate(0, 0, 1).Before(returnAt) {
+		violations = append(violations, *validation.New(customerField, validation.Mismatch, "Driver licence expires on %s before return", customer.LicenceExpiry))
+	}
+	if validator := rules.validator(customer.LicenceCountry); validator != nil {
+		violations = append(violations, validator.Check(customer, carGroup, pickup)...)
+	}
+	return violations
+}
+
+func (rules Rules) validator(country string) Validator {
+	if validator, ok := rules.Countries[country]; ok {
+		return validator
+	}
+	return rules.Default
+}
+
+/*
+Check categories and years held against requirement of car group
+*/
+func (rules GroupRules) Check(customer domain.Customer, carGroup int, pickup time.Time) validation.Violations {
+	requirement := rules.Requirement(carGroup)
+	var violations validation.Violations
+	if len(requirement.Categories) > 0 && !hasAnyCategory(customer.LicenceCategories, requirement.Categories) {
+		violations = append(violations, *validation.New(customerField, validation.Mismatch, "Driver licence categories %v do not include any of %v required for car group %d",
+			customer.LicenceCategories, requirement.Categories, carGroup))
+	}
+	if requirement.MinYearsHeld > 0 {
+		issued, err := time.Parse(domain.DateLayout, customer.LicenceIssued)
+		if err != nil {
+			violations = append(violations, *validation.New(customerField, validation.Mismatch, "Driver licence issue date is not known, car group %d requires licence held %d years",
+				carGroup, requirement.MinYearsHeld))
+		} else if held := fullYears(issued, pickup); held < requirement.MinYearsHeld {
+			violations = append(violations, *validation.New(customerField, validation.Mismatch, "Driver licence is held %d years at pickup, car group %d requires at least %d",
+				held, carGroup, requirement.MinYearsHeld))
+		}
+	}
+	return violations
+}
+
+/*
+Requirement of car group, Default when car group is not listed
+*/
+func (rules GroupRules) Requirement(carGroup int) Requirement {
+	if requirement, ok := rules.Groups[carGroup]; ok {
+		return requirement
+	}
+	return rules.Default
+}
+
+/*
+Categories are compared ignoring case
+*/
+func hasAnyCategory(held []string, accepted []string) bool {
+	for _, category := range held {
+		for _, acceptedCategory := range accepted {
+			if strings.EqualFold(category, acceptedCategory) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+Full years passed from since to at
+*/
+func fullYears(since time.Time, at time.Time) int {
+	years := at.Year() - since.Year()
+	if at.Month() < since.Month() || (at.Month() == since.Month() && at.Day() < since.Day()) {
+		years--
+	}
+	return years
+}
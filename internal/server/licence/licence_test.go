@@ -0,0 +1,70 @@
+package licence
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	pickup     = time.Date(2022, 3, 10, 10, 0, 0, 0, time.UTC)
+	returnAt   = time.Date(2022, 3, 12, 10, 0, 0, 0, time.UTC)
+	testDriver = domain.Customer{
+		LicenceCountry:    "IL",
+		LicenceExpiry:     "2025-01-01",
+		LicenceIssued:     "2020-03-10",
+		LicenceCategories: []string{"B"},
+	}
+	testRules = Rules{
+		Countries: map[string]Validator{
+			"US": GroupRules{Default: Requirement{Categories: []string{"D"}}},
+		},
+		Default: GroupRules{
+			Groups:  map[int]Requirement{5: {Categories: []string{"C", "C1"}, MinYearsHeld: 3}},
+			Default: DefaultRequirement,
+		},
+	}
+)
+
+func messages(violations validation.Violations) []string {
+	var result []string
+	for _, violation := range violations {
+		result = append(result, violation.Message)
+	}
+	return result
+}
+
+func TestCheck(test *testing.T) {
+	expired := testDriver
+	expired.LicenceExpiry = "2022-03-11"
+	lastDay := testDriver
+	lastDay.LicenceExpiry = "2022-03-12"
+	unknownIssue := testDriver
+	unknownIssue.LicenceIssued = ""
+	american := testDriver
+	american.LicenceCountry = "US"
+	american.LicenceCategories = []string{"d"}
+	testCases := []struct {
+		name     string
+		customer domain.Customer
+		carGroup int
+		expected []string
+	}{
+		{"car licence", testDriver, 1, nil},
+		{"valid on return day", lastDay, 1, nil},
+		{"expired before return", expired, 1, []string{"Driver licence expires on 2022-03-11 before return"}},
+		{"van group", testDriver, 5, []string{
+			"Driver licence categories [B] do not include any of [C C1] required for car group 5",
+			"Driver licence is held 2 years at pickup, car group 5 requires at least 3",
+		}},
+		{"customer created before licence rules", unknownIssue, 1, []string{"Driver licence issue date is not known, car group 1 requires licence held 1 years"}},
+		{"country validator", american, 5, nil},
+	}
+	for _, testCase := range testCases {
+		violations := testRules.Check(testCase.customer, testCase.carGroup, pickup, returnAt)
+		assert.Equal(test, testCase.expected, messages(violations), testCase.name)
+	}
+}
@@ -0,0 +1,299 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Content type of Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Latency buckets in seconds
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type (
+	/*
+		Metrics written together in Prometheus text format, metrics are written in order they were added
+	*/
+	Registry struct {
+		mutex   sync.RWMutex
+		metrics []metric
+		names   map[string]int
+	}
+
+	metric interface {
+		write(writer *bufio.Writer) error
+	}
+
+	/*
+		Counter per combination of label values
+	*/
+	Counter struct {
+		family
+		values map[string]float64
+	}
+
+	/*
+		Histogram of observed values per combination of label values
+	*/
+	Histogram struct {
+		family
+		buckets []float64
+		values  map[string]*histogramValue
+	}
+
+	/*
+		Gauge which is collected when metrics are written
+	*/
+	GaugeFunc struct {
+		family
+		collect func() (float64, error)
+	}
+
+	family struct {
+		mutex      sync.Mutex
+		name       string
+		help       string
+		metricType string
+		labelNames []string
+		// Label values of every series by their key
+		labels map[string][]string
+	}
+
+	histogramValue struct {
+		counts []uint64
+		sum    float64
+		count  uint64
+	}
+)
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]int)}
+}
+
+/*
+Add counter, metric of the same name replaces the previous one
+*/
+func (registry *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	counter := &Counter{family: newFamily(name, help, "counter", labelNames), values: make(map[string]float64)}
+	if len(labelNames) == 0 {
+		// Counter without labels is written as 0 before anything is added
+		counter.Add(0)
+	}
+	registry.add(name, counter)
+	return counter
+}
+
+/*
+Add histogram with upper bounds of buckets in increasing order, +Inf bucket is added on write
+*/
+func (registry *Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	histogram := &Histogram{family: newFamily(name, help, "histogram", labelNames), buckets: buckets, values: make(map[string]*histogramValue)}
+	registry.add(name, histogram)
+	return histogram
+}
+
+/*
+Add gauge collected on every write, gauge is left out when collect fails
+*/
+func (registry *Registry) NewGaugeFunc(name string, help string, collect func() (float64, error)) *GaugeFunc {
+	gauge := &GaugeFunc{family: newFamily(name, help, "gauge", nil), collect: collect}
+	registry.add(name, gauge)
+	return gauge
+}
+
+func (registry *Registry) add(name string, added metric) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if index, ok := registry.names[name]; ok {
+		registry.metrics[index] = added
+		return
+	}
+	registry.names[name] = len(registry.metrics)
+	registry.metrics = append(registry.metrics, added)
+}
+
+/*
+Write every metric in Prometheus text format
+*/
+func (registry *Registry) Write(writer io.Writer) error {
+	registry.mutex.RLock()
+	metrics := append([]metric(nil), registry.metrics...)
+	registry.mutex.RUnlock()
+	buffered := bufio.NewWriter(writer)
+	for _, written := range metrics {
+		if err := written.write(buffered); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+/*
+Add one to the series of label values
+*/
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+/*
+Add value to the series of label values, values are given in order of label names
+*/
+func (counter *Counter) Add(value float64, labelValues ...string) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	counter.values[counter.series(labelValues)] += value
+}
+
+/*
+Value of the series, 0 when nothing was added
+*/
+func (counter *Counter) Value(labelValues ...string) float64 {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	return counter.values[labelKey(labelValues)]
+}
+
+func (counter *Counter) write(writer *bufio.Writer) error {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	counter.writeHeader(writer)
+	for _, key := range sortedKeys(counter.labels) {
+		counter.writeSample(writer, "", counter.labels[key], "", counter.values[key])
+	}
+	return nil
+}
+
+/*
+Observe value in the series of label values
+*/
+func (histogram *Histogram) Observe(value float64, labelValues ...string) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	key := histogram.series(labelValues)
+	observed, ok := histogram.values[key]
+	if !ok {
+		observed = &histogramValue{counts: make([]uint64, len(histogram.buckets))}
+		histogram.values[key] = observed
+	}
+	for index, upperBound := range histogram.buckets {
+		if value <= upperBound {
+			observed.counts[index]++
+		}
+	}
+	observed.sum += value
+	observed.count++
+}
+
+/*
+Number of observations in the series
+*/
+func (histogram *Histogram) Count(labelValues ...string) uint64 {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	if observed, ok := histogram.values[labelKey(labelValues)]; ok {
+		return observed.count
+	}
+	return 0
+}
+
+func (histogram *Histogram) write(writer *bufio.Writer) error {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	histogram.writeHeader(writer)
+	for _, key := range sortedKeys(histogram.labels) {
+		labelValues := histogram.labels[key]
+		observed := histogram.values[key]
+		for index, upperBound := range histogram.buckets {
+			histogram.writeSample(writer, "_bucket", labelValues, formatValue(upperBound), float64(observed.counts[index]))
+		}
+		histogram.writeSample(writer, "_bucket", labelValues, "+Inf", float64(observed.count))
+		histogram.writeSample(writer, "_sum", labelValues, "", observed.sum)
+		histogram.writeSample(writer, "_count", labelValues, "", float64(observed.count))
+	}
+	return nil
+}
+
+func (gauge *GaugeFunc) write(writer *bufio.Writer) error {
+	value, err := gauge.collect()
+	if err != nil {
+		// Other metrics are still useful, scrape is not failed
+		fmt.Fprintf(writer, "# Failed to collect %s: %s\n", gauge.name, strings.ReplaceAll(err.Error(), "\n", " "))
+		return nil
+	}
+	gauge.writeHeader(writer)
+	gauge.writeSample(writer, "", nil, "", value)
+	return nil
+}
+
+func newFamily(name string, help string, metricType string, labelNames []string) family {
+	return family{name: name, help: help, metricType: metricType, labelNames: labelNames, labels: make(map[string][]string)}
+}
+
+/*
+Key of the series, label values are remembered for writing. Missing values are empty, extra values are dropped
+*/
+func (metricFamily *family) series(labelValues []string) string {
+	values := make([]string, len(metricFamily.labelNames))
+	copy(values, labelValues)
+	key := labelKey(values)
+	if _, ok := metricFamily.labels[key]; !ok {
+		metricFamily.labels[key] = values
+	}
+	return key
+}
+
+func (metricFamily *family) writeHeader(writer *bufio.Writer) {
+	fmt.Fprintf(writer, "# HELP %s %s\n", metricFamily.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(metricFamily.help))
+	fmt.Fprintf(writer, "# TYPE %s %s\n", metricFamily.name, metricFamily.metricType)
+}
+
+func (metricFamily *family) writeSample(writer *bufio.Writer, suffix string, labelValues []string, upperBound string, value float64) {
+	writer.WriteString(metricFamily.name + suffix)
+	var labels []string
+	for index, labelName := range metricFamily.labelNames {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, labelName, escapeLabel(labelValues[index])))
+	}
+	if len(upperBound) > 0 {
+		labels = append(labels, fmt.Sprintf(`le="%s"`, upperBound))
+	}
+	if len(labels) > 0 {
+		writer.WriteString("{" + strings.Join(labels, ",") + "}")
+	}
+	writer.WriteString(" " + formatValue(value) + "\n")
+}
+
+func labelKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func sortedKeys(labels map[string][]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWrite(test *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounter("requests_total", "Requests", "method", "route")
+	requests.Inc("GET", "/api/cars")
+	requests.Add(2, "POST", `/api/"rents"`)
+	requests.Inc("GET", "/api/cars")
+	latency := registry.NewHistogram("latency_seconds", "Request latency", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/api/cars")
+	latency.Observe(0.5, "/api/cars")
+	latency.Observe(3, "/api/cars")
+	registry.NewGaugeFunc("cars", "Cars", func() (float64, error) { return 3, nil })
+	registry.NewGaugeFunc("broken", "Broken", func() (float64, error) { return 0, errors.New("DB is closed") })
+
+	var written bytes.Buffer
+	require.NoError(test, registry.Write(&written))
+	assert.Equal(test, `# HELP requests_total Requests
+# TYPE requests_total counter
+requests_total{method="GET",route="/api/cars"} 2
+requests_total{method="POST",route="/api/\"rents\""} 2
+# HELP latency_seconds Request latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/api/cars",le="0.1"} 1
+latency_seconds_bucket{route="/api/cars",le="1"} 2
+latency_seconds_bucket{route="/api/cars",le="+Inf"} 3
+latency_seconds_sum{route="/api/cars"} 3.55
+latency_seconds_count{route="/api/cars"} 3
+# HELP cars Cars
+# TYPE cars gauge
+cars 3
+# Failed to collect broken: DB is closed
+`, written.String())
+	assert.Equal(test, float64(2), requests.Value("GET", "/api/cars"))
+	assert.Equal(test, uint64(3), latency.Count("/api/cars"))
+}
+
+/*
+Metric added again under the same name replaces the previous one and keeps its place
+*/
+func TestRegistryReplace(test *testing.T) {
+	registry := NewRegistry()
+	registry.NewGaugeFunc("cars", "Cars", func() (float64, error) { return 1, nil })
+	registry.NewCounter("rents_total", "Rents").Inc()
+	registry.NewGaugeFunc("cars", "Cars", func() (float64, error) { return 2, nil })
+	registry.NewCounter("cancelled_total", "Cancelled rents")
+
+	var written bytes.Buffer
+	require.NoError(test, registry.Write(&written))
+	assert.Equal(test, "# HELP cars Cars\n# TYPE cars gauge\ncars 2\n# HELP rents_total Rents\n# TYPE rents_total counter\nrents_total 1\n"+
+		"# HELP cancelled_total Cancelled rents\n# TYPE cancelled_total counter\ncancelled_total 0\n", written.String())
+}
@@ -0,0 +1,21 @@
+package metrics
+
+/*
+Registry served by /metrics
+*/
+var Default = NewRegistry()
+
+var (
+	HTTPRequests = Default.NewCounter("car_rental_http_requests_total",
+		"HTTP requests by method, route template and response status", "method", "route", "status")
+	HTTPRequestDuration = Default.NewHistogram("car_rental_http_request_duration_seconds",
+		"HTTP request latency by method and route template", DefaultBuckets, "method", "route")
+	DBQueryDuration = Default.NewHistogram("car_rental_db_query_duration_seconds",
+		"DB query latency by statement", DefaultBuckets, "statement")
+	RentsCreated = Default.NewCounter("car_rental_rents_created_total",
+		"Rents booked")
+	RentsCancelled = Default.NewCounter("car_rental_rents_cancelled_total",
+		"Rents cancelled")
+	RentConflicts = Default.NewCounter("car_rental_rent_conflicts_total",
+		"Rents rejected because car is booked or blacked out at requested dates")
+)
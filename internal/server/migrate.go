@@ -0,0 +1,69 @@
+package server
+
+import (
+	"car-rental/internal/server/config"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const migrateUsage = "usage: migrate [up | down [steps] | status]"
+
+/*
+Runs migrate subcommand against DB from configuration
+*/
+func Migrate(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	runner, internalDB, err := openMigrationRunner(cfg.DB)
+	if internalDB != nil {
+		defer internalDB.Close()
+	}
+	if err != nil {
+		return err
+	}
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "up":
+		applied, err := runner.Up()
+		if err != nil {
+			return err
+		}
+		log.Infof("%d migrations applied", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.Errorf("Incorrect number of steps [%s]. %s", args[1], migrateUsage)
+			}
+		}
+		reverted, err := runner.Down(steps)
+		if err != nil {
+			return err
+		}
+		log.Infof("%d migrations reverted", reverted)
+	case "status":
+		statuses, err := runner.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = "applied at " + status.AppliedAt
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		return errors.Errorf("Unknown migrate command [%s]. %s", command, migrateUsage)
+	}
+	return nil
+}
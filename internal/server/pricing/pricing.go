@@ -0,0 +1,240 @@
+package pricing
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Field names used in pricing validation errors
+	ExtrasField    string = "availableExtras"
+	DiscountsField string = "discounts"
+
+	// Percentages are kept in basis points, 1% = 100
+	fullPercent int64 = 10000
+	dayLength         = 24 * time.Hour
+)
+
+type (
+	/*
+		Extra which can be added to a rent, price is in cents
+	*/
+	Extra struct {
+		Name   string
+		Price  int64
+		PerDay bool
+	}
+
+	/*
+		Rules used to price a rent, all amounts are in cents
+	*/
+	Rules struct {
+		// Rent time which is not charged after the last full day
+		GracePeriod time.Duration
+		// Shortest rent is charged at least for this number of days
+		MinimumDays int
+		// Tax in basis points, 1700 is 17%
+		TaxRate int64
+		// Priced extras by name, rent can not use extras missing here
+		Extras map[string]Extra
+		// Charged once when car is returned to other branch than it was picked up at
+		OneWayFee int64
+	}
+
+	discount struct {
+		text string
+		// Percentage in basis points or fixed amount in cents
+		value     int64
+		isPercent bool
+	}
+)
+
+var DefaultRules = Rules{
+	GracePeriod: 0,
+	MinimumDays: 1,
+	TaxRate:     1700,
+	Extras: map[string]Extra{
+		"GPS":               {Name: "GPS", Price: 1000, PerDay: true},
+		"Child seat":        {Name: "Child seat", Price: 800, PerDay: true},
+		"Additional driver": {Name: "Additional driver", Price: 1500, PerDay: true},
+		"Full insurance":    {Name: "Full insurance", Price: 2500, PerDay: true},
+		"Cleaning":          {Name: "Cleaning", Price: 3000},
+	},
+	OneWayFee: 5000,
+}
+
+/*
+Number of charged days, every started day is charged once grace period is over
+*/
+func (rules Rules) RentDays(interval availability.Interval) int {
+	duration := interval.To.Sub(interval.From)
+	days := int(duration / dayLength)
+	if remainder := duration % dayLength; remainder > rules.GracePeriod {
+		days++
+	}
+	if days < rules.MinimumDays {
+		days = rules.MinimumDays
+	}
+	return days
+}
+
+/*
+Builds itemized quote: daily rate for every charged day, extras, one-way fee, discounts and tax.
+Percentage discounts are applied to price of rent with extras, fixed ones are subtracted after them,
+total discount never exceeds the price
+*/
+func (rules Rules) Quote(dailyRate int, interval availability.Interval, extras []string, discounts []string, oneWay bool) (domain.PriceQuote, error) {
+	var quote domain.PriceQuote
+	if !interval.IsValid() {
+		return quote, fmt.Errorf("Please provide correct dates, from must be less than to")
+	}
+	parsedDiscounts, err := parseDiscounts(discounts)
+	if err != nil {
+		return quote, err
+	}
+	quote.DailyRate = int64(dailyRate) * 100
+	quote.Days = rules.RentDays(interval)
+	quote.Items = append(quote.Items, domain.PriceItem{
+		Kind:      domain.RentalPriceItem,
+		Name:      "Daily rate",
+		Quantity:  quote.Days,
+		UnitPrice: quote.DailyRate,
+		Amount:    quote.DailyRate * int64(quote.Days),
+	})
+	for _, name := range extras {
+		if len(strings.TrimSpace(name)) == 0 {
+			continue
+		}
+		extra, ok := rules.Extras[name]
+		if !ok {
+			return quote, validation.New(ExtrasField, validation.NotAllowed, "[%s] is not a known extra", name)
+		}
+		quantity := 1
+		if extra.PerDay {
+			quantity = quote.Days
+		}
+		quote.Items = append(quote.Items, domain.PriceItem{
+			Kind:      domain.ExtraPriceItem,
+			Name:      extra.Name,
+			Quantity:  quantity,
+			UnitPrice: extra.Price,
+			Amount:    extra.Price * int64(quantity),
+		})
+	}
+	if oneWay && rules.OneWayFee > 0 {
+		quote.Items = append(quote.Items, domain.PriceItem{
+			Kind:      domain.OneWayPriceItem,
+			Name:      "One-way fee",
+			Quantity:  1,
+			UnitPrice: rules.OneWayFee,
+			Amount:    rules.OneWayFee,
+		})
+	}
+	for _, item := range quote.Items {
+		quote.Subtotal += item.Amount
+	}
+
+	remaining := quote.Subtotal
+	for _, parsed := range parsedDiscounts {
+		amount := parsed.value
+		if parsed.isPercent {
+			amount = percentOf(quote.Subtotal, parsed.value)
+		}
+		if amount > remaining {
+			amount = remaining
+		}
+		remaining -= amount
+		quote.Discount += amount
+		quote.Items = append(quote.Items, domain.PriceItem{
+			Kind:     domain.DiscountPriceItem,
+			Name:     parsed.text,
+			Quantity: 1,
+			Amount:   -amount,
+		})
+	}
+
+	quote.Tax = percentOf(remaining, rules.TaxRate)
+	quote.Items = append(quote.Items, domain.PriceItem{
+		Kind:     domain.TaxPriceItem,
+		Name:     fmt.Sprintf("Tax %s%%", formatHundredths(rules.TaxRate)),
+		Quantity: 1,
+		Amount:   quote.Tax,
+	})
+	quote.Total = remaining + quote.Tax
+	return quote, nil
+}
+
+/*
+Percentage discounts are applied before fixed ones, so result does not depend on order of discounts in rent
+*/
+func parseDiscounts(discounts []string) ([]discount, error) {
+	var percentages []discount
+	var fixed []discount
+	for _, text := range discounts {
+		trimmed := strings.TrimSpace(text)
+		if len(trimmed) == 0 {
+			continue
+		}
+		if strings.HasSuffix(trimmed, "%") {
+			value, err := ParseHundredths(strings.TrimSuffix(trimmed, "%"))
+			if err != nil || value <= 0 || value > fullPercent {
+				return nil, validation.New(DiscountsField, validation.OutOfRange, "[%s] should be a percentage between 0 and 100", text)
+			}
+			percentages = append(percentages, discount{text: trimmed, value: value, isPercent: true})
+			continue
+		}
+		value, err := ParseHundredths(trimmed)
+		if err != nil || value <= 0 {
+			return nil, validation.New(DiscountsField, validation.InvalidFormat, "[%s] should be a percentage like 5%% or a positive amount like 20.50", text)
+		}
+		fixed = append(fixed, discount{text: trimmed, value: value})
+	}
+	return append(percentages, fixed...), nil
+}
+
+/*
+Parses decimal number with at most two fraction digits into hundredths, "12.5" is 1250
+*/
+func ParseHundredths(value string) (int64, error) {
+	whole, fraction := value, ""
+	if index := strings.Index(value, "."); index >= 0 {
+		whole, fraction = value[:index], value[index+1:]
+		if len(fraction) == 0 {
+			return 0, fmt.Errorf("[%s] is not a decimal number with two fraction digits", value)
+		}
+	}
+	if len(fraction) > 2 || len(whole) == 0 || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("[%s] is not a decimal number with two fraction digits", value)
+	}
+	wholeNumber, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("[%s] is not a decimal number with two fraction digits", value)
+	}
+	fractionNumber := int64(0)
+	if len(fraction) > 0 {
+		fractionNumber, err = strconv.ParseInt((fraction + "0")[:2], 10, 64)
+		if err != nil || strings.HasPrefix(fraction, "-") || strings.HasPrefix(fraction, "+") {
+			return 0, fmt.Errorf("[%s] is not a decimal number with two fraction digits", value)
+		}
+	}
+	return wholeNumber*100 + fractionNumber, nil
+}
+
+/*
+Part of amount given in basis points, rounded half up
+*/
+func percentOf(amount int64, basisPoints int64) int64 {
+	return (amount*basisPoints + fullPercent/2) / fullPercent
+}
+
+func formatHundredths(value int64) string {
+	if value%100 == 0 {
+		return strconv.FormatInt(value/100, 10)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%02d", value/100, value%100), "0")
+}
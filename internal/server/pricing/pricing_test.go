@@ -0,0 +1,125 @@
+package pricing
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/validation"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func interval(from string, to string) availability.Interval {
+	fromTime, _ := time.Parse(time.RFC3339, from)
+	toTime, _ := time.Parse(time.RFC3339, to)
+	return availability.Interval{From: fromTime, To: toTime}
+}
+
+func TestRentDays(test *testing.T) {
+	rules := Rules{GracePeriod: time.Hour, MinimumDays: 1}
+	testCases := []struct {
+		name     string
+		interval availability.Interval
+		expected int
+	}{
+		{"exactly one day", interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z"), 1},
+		{"short rent is charged as minimum", interval("2022-01-15T10:00:00Z", "2022-01-15T12:00:00Z"), 1},
+		{"late return inside grace period", interval("2022-01-15T10:00:00Z", "2022-01-17T11:00:00Z"), 2},
+		{"late return after grace period", interval("2022-01-15T10:00:00Z", "2022-01-17T11:01:00Z"), 3},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(test, testCase.expected, rules.RentDays(testCase.interval), testCase.name)
+	}
+	rules.MinimumDays = 3
+	assert.Equal(test, 3, rules.RentDays(interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z")))
+}
+
+/*
+Test full quote: 3 days by 120, GPS per day and cleaning once, 10% and 20.50 discounts, 17% tax
+*/
+func TestQuote(test *testing.T) {
+	quote, err := DefaultRules.Quote(120, interval("2022-01-15T10:00:00Z", "2022-01-18T10:00:00Z"),
+		[]string{"GPS", "Cleaning", ""}, []string{"20.50", "10%"}, false)
+	require.NoError(test, err)
+	expected := domain.PriceQuote{
+		DailyRate: 12000,
+		Days:      3,
+		Items: []domain.PriceItem{
+			{Kind: domain.RentalPriceItem, Name: "Daily rate", Quantity: 3, UnitPrice: 12000, Amount: 36000},
+			{Kind: domain.ExtraPriceItem, Name: "GPS", Quantity: 3, UnitPrice: 1000, Amount: 3000},
+			{Kind: domain.ExtraPriceItem, Name: "Cleaning", Quantity: 1, UnitPrice: 3000, Amount: 3000},
+			{Kind: domain.DiscountPriceItem, Name: "10%", Quantity: 1, Amount: -4200},
+			{Kind: domain.DiscountPriceItem, Name: "20.50", Quantity: 1, Amount: -2050},
+			{Kind: domain.TaxPriceItem, Name: "Tax 17%", Quantity: 1, Amount: 6078},
+		},
+		Subtotal: 42000,
+		Discount: 6250,
+		Tax:      6078,
+		Total:    41828,
+	}
+	assert.Equal(test, expected, quote)
+}
+
+/*
+One-way fee is charged once and is discounted together with the rest of the price
+*/
+func TestQuoteOneWay(test *testing.T) {
+	quote, err := DefaultRules.Quote(100, interval("2022-01-15T10:00:00Z", "2022-01-17T10:00:00Z"), nil, []string{"10%"}, true)
+	require.NoError(test, err)
+	assert.Equal(test, domain.PriceItem{Kind: domain.OneWayPriceItem, Name: "One-way fee", Quantity: 1, UnitPrice: 5000, Amount: 5000}, quote.Items[1])
+	assert.Equal(test, int64(25000), quote.Subtotal)
+	assert.Equal(test, int64(2500), quote.Discount)
+
+	rules := DefaultRules
+	rules.OneWayFee = 0
+	quote, err = rules.Quote(100, interval("2022-01-15T10:00:00Z", "2022-01-17T10:00:00Z"), nil, nil, true)
+	require.NoError(test, err)
+	assert.Equal(test, int64(20000), quote.Subtotal)
+}
+
+func TestQuoteDiscountDoesNotExceedPrice(test *testing.T) {
+	quote, err := DefaultRules.Quote(10, interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z"), nil, []string{"60%", "60%", "5"}, false)
+	require.NoError(test, err)
+	assert.Equal(test, int64(1000), quote.Discount)
+	assert.Equal(test, int64(0), quote.Tax)
+	assert.Equal(test, int64(0), quote.Total)
+}
+
+func TestQuoteValidation(test *testing.T) {
+	testCases := []struct {
+		name      string
+		extras    []string
+		discounts []string
+		field     string
+		code      string
+	}{
+		{"unknown extra", []string{"Jet pack"}, nil, ExtrasField, validation.NotAllowed},
+		{"percentage over 100", nil, []string{"120%"}, DiscountsField, validation.OutOfRange},
+		{"free text discount", nil, []string{"Days"}, DiscountsField, validation.InvalidFormat},
+		{"negative amount", nil, []string{"-5"}, DiscountsField, validation.InvalidFormat},
+		{"too precise amount", nil, []string{"1.005"}, DiscountsField, validation.InvalidFormat},
+	}
+	for _, testCase := range testCases {
+		_, err := DefaultRules.Quote(10, interval("2022-01-15T10:00:00Z", "2022-01-16T10:00:00Z"), testCase.extras, testCase.discounts, false)
+		var violation *validation.Violation
+		require.True(test, errors.As(err, &violation), testCase.name)
+		assert.Equal(test, testCase.field, violation.Field, testCase.name)
+		assert.Equal(test, testCase.code, violation.Code, testCase.name)
+	}
+}
+
+func TestParseHundredths(test *testing.T) {
+	parsed := map[string]int64{"5": 500, "7.5": 750, "20.05": 2005, "0.1": 10}
+	for value, expected := range parsed {
+		result, err := ParseHundredths(value)
+		require.NoError(test, err, value)
+		assert.Equal(test, expected, result, value)
+	}
+	for _, value := range []string{"", ".5", "1.", "1.-5", "abc", "+1"} {
+		_, err := ParseHundredths(value)
+		assert.Error(test, err, value)
+	}
+}
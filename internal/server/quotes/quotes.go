@@ -0,0 +1,96 @@
+package quotes
+
+import (
+	"car-rental/internal/server/domain"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotFound = errors.New("Quote not found")
+	ErrExpired  = errors.New("Quote is expired")
+)
+
+/*
+Keeps issued quotes in memory until they expire, quotes are not stored in DB
+*/
+type Store struct {
+	mutex  sync.Mutex
+	ttl    time.Duration
+	now    func() time.Time
+	quotes map[string]domain.RentQuote
+}
+
+func NewStore(ttl time.Duration) *Store {
+	return NewStoreWithClock(ttl, time.Now)
+}
+
+/*
+Same as NewStore with custom clock, used by tests
+*/
+func NewStoreWithClock(ttl time.Duration, now func() time.Time) *Store {
+	return &Store{ttl: ttl, now: now, quotes: make(map[string]domain.RentQuote)}
+}
+
+/*
+Issues quote for priced rent, quote is valid for store TTL
+*/
+func (store *Store) Issue(rent domain.RentInfo) (domain.RentQuote, error) {
+	quoteID, err := newQuoteID()
+	if err != nil {
+		return domain.RentQuote{}, err
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := store.now().UTC()
+	store.removeExpired(now)
+	quote := domain.RentQuote{
+		QuoteID:   quoteID,
+		ExpiresAt: now.Add(store.ttl).Format(domain.TimeLayout),
+		Rent:      rent,
+	}
+	store.quotes[quoteID] = quote
+	return quote, nil
+}
+
+/*
+Get quote which is still valid
+*/
+func (store *Store) Get(quoteID string) (*domain.RentQuote, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	quote, ok := store.quotes[quoteID]
+	if !ok {
+		return nil, errors.Wrapf(ErrNotFound, "Quote %s", quoteID)
+	}
+	if store.isExpired(quote, store.now().UTC()) {
+		delete(store.quotes, quoteID)
+		return nil, errors.Wrapf(ErrExpired, "Quote %s", quoteID)
+	}
+	return &quote, nil
+}
+
+func (store *Store) removeExpired(now time.Time) {
+	for quoteID, quote := range store.quotes {
+		if store.isExpired(quote, now) {
+			delete(store.quotes, quoteID)
+		}
+	}
+}
+
+func (store *Store) isExpired(quote domain.RentQuote, now time.Time) bool {
+	expiresAt, err := time.Parse(domain.TimeLayout, quote.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+func newQuoteID() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", errors.Wrap(err, "Failed to generate quote ID")
+	}
+	return hex.EncodeToString(random), nil
+}
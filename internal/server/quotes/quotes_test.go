@@ -0,0 +1,43 @@
+package quotes
+
+import (
+	"car-rental/internal/server/domain"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteExpires(test *testing.T) {
+	now := time.Date(2022, 1, 15, 10, 0, 0, 0, time.UTC)
+	store := NewStoreWithClock(15*time.Minute, func() time.Time { return now })
+
+	quote, err := store.Issue(domain.RentInfo{CarID: 1})
+	require.NoError(test, err)
+	assert.Len(test, quote.QuoteID, 32)
+	assert.Equal(test, "2022-01-15T10:15:00Z", quote.ExpiresAt)
+
+	received, err := store.Get(quote.QuoteID)
+	require.NoError(test, err)
+	assert.Equal(test, quote, *received)
+
+	now = now.Add(15 * time.Minute)
+	_, err = store.Get(quote.QuoteID)
+	assert.True(test, errors.Is(err, ErrExpired))
+	_, err = store.Get(quote.QuoteID)
+	assert.True(test, errors.Is(err, ErrNotFound), "Expired quote is removed")
+}
+
+func TestExpiredQuotesRemovedOnIssue(test *testing.T) {
+	now := time.Date(2022, 1, 15, 10, 0, 0, 0, time.UTC)
+	store := NewStoreWithClock(time.Minute, func() time.Time { return now })
+	first, err := store.Issue(domain.RentInfo{CarID: 1})
+	require.NoError(test, err)
+	now = now.Add(time.Hour)
+	second, err := store.Issue(domain.RentInfo{CarID: 2})
+	require.NoError(test, err)
+	assert.NotEqual(test, first.QuoteID, second.QuoteID)
+	assert.Len(test, store.quotes, 1)
+}
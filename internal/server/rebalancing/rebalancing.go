@@ -0,0 +1,312 @@
+package rebalancing
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/fleet"
+	"math"
+	"sort"
+	"time"
+)
+
+const earthRadiusKm = 6371.0
+
+type (
+	/*
+		Rules used to plan transfers of idle cars between branches
+	*/
+	Rules struct {
+		// Time car is out of service while it is moved to other branch
+		TransferTime time.Duration
+		// Rents picked up in this period after plan start make branch demand
+		Horizon time.Duration
+	}
+
+	/*
+		Fleet state the plan is made from
+	*/
+	Snapshot struct {
+		Cars      []domain.Car
+		Rents     []domain.RentInfo
+		Blackouts []domain.Blackout
+		Branches  []domain.Branch
+	}
+
+	// Idle car waiting at branch
+	idleCar struct {
+		car    domain.Car
+		branch domain.Branch
+	}
+
+	// Branch demand of one car group while plan is made
+	groupBranch struct {
+		demand domain.BranchDemand
+		cars   []domain.Car
+	}
+)
+
+var DefaultRules = Rules{TransferTime: 4 * time.Hour, Horizon: 7 * 24 * time.Hour}
+
+/*
+Plan transfers of idle cars, so that every branch has idle cars of every car group in proportion to rents picked up there
+in planning period. Car is idle when it is not retired and no rent or blackout holds it at start or later.
+Shortage of branch with the biggest shortage is filled first from the nearest branch with surplus,
+car with the lowest ID is moved. Equal input gives equal plan
+*/
+func (rules Rules) Plan(snapshot Snapshot, start time.Time) domain.RebalancePlan {
+	end := start.Add(rules.Horizon)
+	plan := domain.RebalancePlan{
+		FromDate:  start.Format(domain.TimeLayout),
+		ToDate:    end.Format(domain.TimeLayout),
+		Demand:    []domain.BranchDemand{},
+		Transfers: []domain.Transfer{},
+	}
+	branches := newBranchIndex(snapshot.Branches)
+	groups := make(map[int]map[int]*groupBranch)
+	entry := func(carGroup int, branch domain.Branch) *groupBranch {
+		if groups[carGroup] == nil {
+			groups[carGroup] = make(map[int]*groupBranch)
+		}
+		if groups[carGroup][branch.BranchID] == nil {
+			groups[carGroup][branch.BranchID] = &groupBranch{demand: domain.BranchDemand{BranchID: branch.BranchID, Location: branch.Name, CarGroup: carGroup}}
+		}
+		return groups[carGroup][branch.BranchID]
+	}
+
+	for _, idle := range rules.idleCars(snapshot, branches, start) {
+		current := entry(idle.car.CarGroup, idle.branch)
+		current.cars = append(current.cars, idle.car)
+		current.demand.IdleCars++
+	}
+	for _, rent := range snapshot.Rents {
+		if rent.Status == domain.CancelledRentStatus {
+			continue
+		}
+		from, err := time.Parse(domain.TimeLayout, rent.FromDate)
+		if err != nil || from.Before(start) || !from.Before(end) {
+			continue
+		}
+		if branch, ok := branches.find(rent.BranchID, rent.Location); ok {
+			entry(rent.CarGroup, branch).demand.Demand++
+		}
+	}
+
+	var carGroups []int
+	for carGroup := range groups {
+		carGroups = append(carGroups, carGroup)
+	}
+	sort.Ints(carGroups)
+	for _, carGroup := range carGroups {
+		ordered := orderedBranches(groups[carGroup])
+		setTargets(ordered)
+		for _, current := range ordered {
+			plan.Demand = append(plan.Demand, current.demand)
+		}
+		for _, transfer := range planGroup(ordered, branches) {
+			transfer.FromDate = start.Format(domain.TimeLayout)
+			transfer.ToDate = start.Add(rules.TransferTime).Format(domain.TimeLayout)
+			plan.Transfers = append(plan.Transfers, transfer)
+		}
+	}
+	return plan
+}
+
+/*
+Find idle cars and branches where they wait, car which was not moved yet waits at its linked branch with the lowest ID
+*/
+func (rules Rules) idleCars(snapshot Snapshot, branches branchIndex, start time.Time) []idleCar {
+	carRents := make(map[int][]domain.RentInfo)
+	for _, rent := range snapshot.Rents {
+		carRents[rent.CarID] = append(carRents[rent.CarID], rent)
+	}
+	carBlackouts := make(map[int][]domain.Blackout)
+	held := make(map[int]bool)
+	for _, blackout := range snapshot.Blackouts {
+		carBlackouts[blackout.CarID] = append(carBlackouts[blackout.CarID], blackout)
+		to, err := time.Parse(domain.TimeLayout, blackout.ToDate)
+		if err != nil || to.After(start) {
+			held[blackout.CarID] = true
+		}
+	}
+	var result []idleCar
+	for _, car := range snapshot.Cars {
+		if len(car.RetiredAt) > 0 || held[car.CarID] {
+			continue
+		}
+		timeline := fleet.NewTimeline(car, carRents[car.CarID], carBlackouts[car.CarID])
+		if !timeline.IsIdle(start) {
+			continue
+		}
+		var branch domain.Branch
+		var ok bool
+		if position := timeline.PositionAt(start); position != nil {
+			branch, ok = branches.find(position.BranchID, position.Location)
+		} else {
+			branch, ok = branches.home(car)
+		}
+		if ok {
+			result = append(result, idleCar{car: car, branch: branch})
+		}
+	}
+	return result
+}
+
+/*
+Split idle cars of the group between branches in proportion to their demand by the largest remainder method,
+remainder ties go to branch with lower ID. Without demand every branch keeps its idle cars
+*/
+func setTargets(ordered []*groupBranch) {
+	idle, demand := 0, 0
+	for _, current := range ordered {
+		idle += current.demand.IdleCars
+		demand += current.demand.Demand
+	}
+	if demand == 0 {
+		for _, current := range ordered {
+			current.demand.Target = current.demand.IdleCars
+		}
+		return
+	}
+	assigned := 0
+	remainders := make([]int, len(ordered))
+	for index, current := range ordered {
+		current.demand.Target = idle * current.demand.Demand / demand
+		remainders[index] = idle * current.demand.Demand % demand
+		assigned += current.demand.Target
+	}
+	byRemainder := make([]int, len(ordered))
+	for index := range byRemainder {
+		byRemainder[index] = index
+	}
+	sort.SliceStable(byRemainder, func(i, j int) bool {
+		return remainders[byRemainder[i]] > remainders[byRemainder[j]]
+	})
+	for _, index := range byRemainder[:idle-assigned] {
+		ordered[index].demand.Target++
+	}
+}
+
+/*
+Move cars from branches with surplus to branches with shortage, the biggest shortage is filled first
+*/
+func planGroup(ordered []*groupBranch, branches branchIndex) []domain.Transfer {
+	var deficits []*groupBranch
+	surplus := make(map[int]int)
+	for _, current := range ordered {
+		if current.demand.Target > current.demand.IdleCars {
+			deficits = append(deficits, current)
+		} else if current.demand.IdleCars > current.demand.Target {
+			surplus[current.demand.BranchID] = current.demand.IdleCars - current.demand.Target
+			sort.SliceStable(current.cars, func(i, j int) bool {
+				return current.cars[i].CarID < current.cars[j].CarID
+			})
+		}
+	}
+	sort.SliceStable(deficits, func(i, j int) bool {
+		return deficits[i].demand.Target-deficits[i].demand.IdleCars > deficits[j].demand.Target-deficits[j].demand.IdleCars
+	})
+	var transfers []domain.Transfer
+	for _, deficit := range deficits {
+		to := branches.byID[deficit.demand.BranchID]
+		for missing := deficit.demand.Target - deficit.demand.IdleCars; missing > 0; missing-- {
+			var source *groupBranch
+			nearest := math.Inf(1)
+			for _, current := range ordered {
+				if surplus[current.demand.BranchID] == 0 {
+					continue
+				}
+				if distance := distanceKm(branches.byID[current.demand.BranchID], to); distance < nearest {
+					source, nearest = current, distance
+				}
+			}
+			if source == nil {
+				break
+			}
+			car := source.cars[0]
+			source.cars = source.cars[1:]
+			surplus[source.demand.BranchID]--
+			transfers = append(transfers, domain.Transfer{
+				CarID:        car.CarID,
+				CarGroup:     car.CarGroup,
+				FromLocation: source.demand.Location,
+				FromBranchID: source.demand.BranchID,
+				ToLocation:   to.Name,
+				ToBranchID:   to.BranchID,
+			})
+		}
+	}
+	return transfers
+}
+
+func orderedBranches(group map[int]*groupBranch) []*groupBranch {
+	var ordered []*groupBranch
+	for _, current := range group {
+		ordered = append(ordered, current)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].demand.BranchID < ordered[j].demand.BranchID
+	})
+	return ordered
+}
+
+/*
+Great-circle distance between branches
+*/
+func distanceKm(from domain.Branch, to domain.Branch) float64 {
+	fromLatitude, toLatitude := radians(from.Latitude), radians(to.Latitude)
+	latitudeDelta := toLatitude - fromLatitude
+	longitudeDelta := radians(to.Longitude - from.Longitude)
+	haversine := math.Pow(math.Sin(latitudeDelta/2), 2) + math.Cos(fromLatitude)*math.Cos(toLatitude)*math.Pow(math.Sin(longitudeDelta/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(haversine))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+/*
+Branches found by ID or by name
+*/
+type branchIndex struct {
+	byID   map[int]domain.Branch
+	byName map[string]domain.Branch
+}
+
+func newBranchIndex(branches []domain.Branch) branchIndex {
+	index := branchIndex{byID: make(map[int]domain.Branch), byName: make(map[string]domain.Branch)}
+	for _, branch := range branches {
+		index.byID[branch.BranchID] = branch
+		index.byName[branch.Name] = branch
+	}
+	return index
+}
+
+/*
+Branch is found by ID, records stored before branches were introduced are found by name
+*/
+func (index branchIndex) find(branchID int, name string) (domain.Branch, bool) {
+	if branch, ok := index.byID[branchID]; ok {
+		return branch, true
+	}
+	branch, ok := index.byName[name]
+	return branch, ok
+}
+
+/*
+Linked branch of the car with the lowest ID
+*/
+func (index branchIndex) home(car domain.Car) (domain.Branch, bool) {
+	var home domain.Branch
+	found := false
+	consider := func(branch domain.Branch, ok bool) {
+		if ok && (!found || branch.BranchID < home.BranchID) {
+			home, found = branch, true
+		}
+	}
+	for _, branchID := range car.BranchIDs {
+		consider(index.find(branchID, ""))
+	}
+	for _, name := range car.AvailableLocations {
+		consider(index.find(0, name))
+	}
+	return home, found
+}
@@ -0,0 +1,133 @@
+package rebalancing
+
+import (
+	"car-rental/internal/server/cars"
+	"car-rental/internal/server/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	start     = time.Date(2022, 1, 10, 10, 0, 0, 0, time.UTC)
+	haifa     = domain.Branch{BranchID: 1, Name: "Haifa", Latitude: 32.794, Longitude: 34.9896}
+	telAviv   = domain.Branch{BranchID: 2, Name: "Tel Aviv", Latitude: 32.0853, Longitude: 34.7818}
+	jerusalem = domain.Branch{BranchID: 3, Name: "Jerusalem", Latitude: 31.7683, Longitude: 35.2137}
+)
+
+func date(days int) string {
+	return start.AddDate(0, 0, days).Format(domain.TimeLayout)
+}
+
+/*
+Three rents are picked up in Tel Aviv and one in Haifa, three idle cars wait in Haifa and Jerusalem.
+Jerusalem is nearer to Tel Aviv, so its car is moved first
+*/
+func TestPlan(test *testing.T) {
+	snapshot := Snapshot{
+		Branches: []domain.Branch{haifa, telAviv, jerusalem},
+		Cars: []domain.Car{
+			{CarID: 1, CarGroup: 1, BranchIDs: []int{1}},
+			{CarID: 2, CarGroup: 1, BranchIDs: []int{1, 2}},
+			{CarID: 3, CarGroup: 1, BranchIDs: []int{1}},
+			{CarID: 4, CarGroup: 1, BranchIDs: []int{1}},
+			{CarID: 5, CarGroup: 1, BranchIDs: []int{2}},
+			{CarID: 6, CarGroup: 1, BranchIDs: []int{1}, RetiredAt: date(-5)},
+			{CarID: 7, CarGroup: 1, BranchIDs: []int{1}},
+			{CarID: 8, CarGroup: 2, BranchIDs: []int{3}},
+		},
+		Rents: []domain.RentInfo{
+			{RentID: 1, CarID: 4, CarGroup: 1, BranchID: 1, Location: "Haifa", ReturnBranchID: 3, ReturnLocation: "Jerusalem", FromDate: date(-3), ToDate: date(-2)},
+			{RentID: 2, CarID: 5, CarGroup: 1, BranchID: 2, Location: "Tel Aviv", FromDate: date(1), ToDate: date(2)},
+			{RentID: 3, CarID: 5, CarGroup: 1, BranchID: 2, Location: "Tel Aviv", FromDate: date(3), ToDate: date(4)},
+			{RentID: 4, CarID: 5, CarGroup: 1, BranchID: 2, Location: "Tel Aviv", FromDate: date(5), ToDate: date(6)},
+			{RentID: 5, CarID: 5, CarGroup: 1, BranchID: 2, Location: "Tel Aviv", FromDate: date(8), ToDate: date(9)},
+			{RentID: 6, CarID: 5, CarGroup: 1, BranchID: 1, Location: "Haifa", FromDate: date(2), ToDate: date(3), Status: domain.CancelledRentStatus},
+			{RentID: 7, CarID: 7, CarGroup: 1, BranchID: 1, Location: "Haifa", FromDate: date(2), ToDate: date(3)},
+		},
+		Blackouts: []domain.Blackout{
+			{BlackoutID: 1, CarID: 3, FromDate: date(-1), ToDate: date(1), Reason: domain.ServiceBlackoutReason},
+		},
+	}
+	plan := DefaultRules.Plan(snapshot, start)
+	assert.Equal(test, date(0), plan.FromDate)
+	assert.Equal(test, date(7), plan.ToDate)
+	assert.False(test, plan.Applied)
+	assert.Equal(test, []domain.BranchDemand{
+		{BranchID: 1, Location: "Haifa", CarGroup: 1, Demand: 1, IdleCars: 2, Target: 1},
+		{BranchID: 2, Location: "Tel Aviv", CarGroup: 1, Demand: 3, IdleCars: 0, Target: 2},
+		{BranchID: 3, Location: "Jerusalem", CarGroup: 1, Demand: 0, IdleCars: 1, Target: 0},
+		{BranchID: 3, Location: "Jerusalem", CarGroup: 2, Demand: 0, IdleCars: 1, Target: 1},
+	}, plan.Demand)
+	transferEnd := start.Add(DefaultRules.TransferTime).Format(domain.TimeLayout)
+	assert.Equal(test, []domain.Transfer{
+		{CarID: 4, CarGroup: 1, FromLocation: "Jerusalem", FromBranchID: 3, ToLocation: "Tel Aviv", ToBranchID: 2, FromDate: date(0), ToDate: transferEnd},
+		{CarID: 1, CarGroup: 1, FromLocation: "Haifa", FromBranchID: 1, ToLocation: "Tel Aviv", ToBranchID: 2, FromDate: date(0), ToDate: transferEnd},
+	}, plan.Transfers)
+}
+
+func TestPlanWithoutDemand(test *testing.T) {
+	snapshot := Snapshot{
+		Branches: []domain.Branch{haifa, telAviv},
+		Cars:     []domain.Car{{CarID: 1, CarGroup: 1, AvailableLocations: []string{"Haifa"}}},
+	}
+	plan := DefaultRules.Plan(snapshot, start)
+	assert.Empty(test, plan.Transfers)
+	assert.Equal(test, []domain.BranchDemand{{BranchID: 1, Location: "Haifa", CarGroup: 1, IdleCars: 1, Target: 1}}, plan.Demand)
+}
+
+/*
+Seeded fleet gives the same plan every time and order of cars does not change it.
+After transfers every branch has its target of idle cars
+*/
+func TestPlanSeededFleet(test *testing.T) {
+	snapshot := Snapshot{}
+	for index, name := range domain.CitiesList {
+		snapshot.Branches = append(snapshot.Branches, domain.Branch{BranchID: index + 1, Name: name, Latitude: 31 + float64(index)*0.15, Longitude: 34.5 + float64(index%4)*0.2})
+	}
+	for index := 0; index < 60; index++ {
+		car := cars.GenerateNewCar(int64(index))
+		car.CarID = index + 1
+		snapshot.Cars = append(snapshot.Cars, car)
+	}
+	for index := 0; index < 20; index++ {
+		car := snapshot.Cars[index*3]
+		snapshot.Rents = append(snapshot.Rents, domain.RentInfo{
+			RentID:   index + 1,
+			CarID:    car.CarID,
+			CarGroup: car.CarGroup,
+			Location: domain.CitiesList[index%3],
+			FromDate: date(index%6 + 1),
+			ToDate:   date(index%6 + 2),
+		})
+	}
+
+	plan := DefaultRules.Plan(snapshot, start)
+	require.NotEmpty(test, plan.Transfers)
+	assert.Equal(test, plan, DefaultRules.Plan(snapshot, start), "Plan is deterministic")
+	reversed := snapshot
+	reversed.Cars = nil
+	for index := len(snapshot.Cars) - 1; index >= 0; index-- {
+		reversed.Cars = append(reversed.Cars, snapshot.Cars[index])
+	}
+	assert.Equal(test, plan, DefaultRules.Plan(reversed, start), "Order of cars does not change plan")
+
+	type key struct{ carGroup, branchID int }
+	idle := make(map[key]int)
+	for _, demand := range plan.Demand {
+		idle[key{demand.CarGroup, demand.BranchID}] = demand.IdleCars
+	}
+	moved := make(map[int]bool)
+	for _, transfer := range plan.Transfers {
+		assert.False(test, moved[transfer.CarID], "Car %d is moved once", transfer.CarID)
+		moved[transfer.CarID] = true
+		assert.NotEqual(test, transfer.FromBranchID, transfer.ToBranchID)
+		idle[key{transfer.CarGroup, transfer.FromBranchID}]--
+		idle[key{transfer.CarGroup, transfer.ToBranchID}]++
+	}
+	for _, demand := range plan.Demand {
+		assert.Equal(test, demand.Target, idle[key{demand.CarGroup, demand.BranchID}], "Group %d at %s", demand.CarGroup, demand.Location)
+	}
+}
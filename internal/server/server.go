@@ -2,7 +2,8 @@ package server
 
 import (
 	"car-rental/internal/server/api/rest"
-	"car-rental/internal/server/db"
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/metrics"
 	"context"
 	"errors"
 	"fmt"
@@ -22,12 +23,29 @@ var (
 
 func Launch() error {
 	log.Info("Starting Server")
-	dbStruct, err := db.NewDBStruct()
+	cfg, err := config.Load()
 	if err != nil {
 		return err
 	}
+	configureLogging(cfg.Log)
+	repos, err := openRepositories(cfg.DB)
+	if err != nil {
+		return err
+	}
+	// Generated cars are located at default branches
+	if cfg.DB.SeedBranches || cfg.DB.GenerateCars {
+		if err := seedBranches(repos); err != nil {
+			return err
+		}
+	}
+	if cfg.DB.GenerateCars {
+		if err := prefillCars(repos); err != nil {
+			return err
+		}
+	}
 	ctx, cancel = context.WithCancel(context.Background())
-	rtr, err := rest.NewServer(dbStruct)
+	// HTTP and DB metrics are collected in default registry
+	rtr, err := rest.NewServer(repos, cfg, metrics.Default)
 	if err != nil {
 		return err
 	}
@@ -55,3 +73,15 @@ func ShutDown() {
 	server.Shutdown(ctx)
 	cancel()
 }
+
+/*
+Apply level and format of service log
+*/
+func configureLogging(logCfg config.LogConfig) {
+	log.SetLevel(logCfg.Level)
+	if logCfg.Format == config.JSONLogFormat {
+		log.SetFormatter(&log.JSONFormatter{})
+	} else {
+		log.SetFormatter(&log.TextFormatter{})
+	}
+}
@@ -2,17 +2,24 @@ package server
 
 import (
 	"bytes"
+	"car-rental/internal/server/auth"
 	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/config"
 	"car-rental/internal/server/db"
 	"car-rental/internal/server/domain"
+	"car-rental/internal/server/metrics"
+	"car-rental/internal/server/storage/sqlite"
+	"car-rental/internal/server/validation"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -52,7 +59,7 @@ var (
 		ToDate:          "2022-01-16T15:13:30Z",
 		Location:        "New York",
 		Discounts:       []string{"5%"},
-		AvailableExtras: []string{"Free day"},
+		AvailableExtras: []string{"GPS"},
 		CarDetails: fmt.Sprintf(`%s %s.Part of %d group. With %d doors, %d adult places, %d big luggage and %d small luggage places.%s. For drivers with minimal age %d`,
 			testCar.CarCompanyName,
 			testCar.Description,
@@ -63,7 +70,20 @@ var (
 			testCar.SmallLuggage,
 			"Without Air Conditioner",
 			testCar.MinimumAge),
-		AgeGroup: "130",
+		Quote: &domain.PriceQuote{
+			DailyRate: 300,
+			Days:      1,
+			Items: []domain.PriceItem{
+				{Kind: domain.RentalPriceItem, Name: "Daily rate", Quantity: 1, UnitPrice: 300, Amount: 300},
+				{Kind: domain.ExtraPriceItem, Name: "GPS", Quantity: 1, UnitPrice: 1000, Amount: 1000},
+				{Kind: domain.DiscountPriceItem, Name: "5%", Quantity: 1, Amount: -65},
+				{Kind: domain.TaxPriceItem, Name: "Tax 17%", Quantity: 1, Amount: 210},
+			},
+			Subtotal: 1300,
+			Discount: 65,
+			Tax:      210,
+			Total:    1445,
+		},
 		CarGroup: 14,
 	}
 	testRentError = domain.RentInfo{
@@ -71,7 +91,7 @@ var (
 		ToDate:          "2022-01-16T15:13:30Z",
 		Location:        "New York",
 		Discounts:       []string{"5%"},
-		AvailableExtras: []string{"Free day"},
+		AvailableExtras: []string{"GPS"},
 		CarDetails: fmt.Sprintf(`%s %s.Part of %d group. With %d doors, %d adult places, %d big luggage and %d small luggage places.%s. For drivers with minimal age %d`,
 			testCar.CarCompanyName,
 			testCar.Description,
@@ -82,17 +102,93 @@ var (
 			testCar.SmallLuggage,
 			"Without Air Conditioner",
 			testCar.MinimumAge),
-		AgeGroup: "130",
 		CarGroup: 14,
 	}
-	carID         = ""
-	inMemoryDB    *sql.DB
-	err           error
-	carProcessor  *cmds.CarProcessor
-	rentProcessor *cmds.RentProcessor
+	// Test cars are for drivers older than 100 years
+	testCustomer = domain.Customer{
+		Name:              "Old Driver",
+		Email:             "old.driver@example.com",
+		Phone:             "+1 212 555 0100",
+		DateOfBirth:       "1870-01-01",
+		LicenceNumber:     "D1234567",
+		LicenceCountry:    "US",
+		LicenceExpiry:     "2100-01-01",
+		LicenceIssued:     "1890-01-01",
+		LicenceCategories: []string{"D"},
+	}
+	youngCustomer = domain.Customer{
+		Name:              "Young Driver",
+		Email:             "young.driver@example.com",
+		DateOfBirth:       "2004-01-01",
+		LicenceNumber:     "D7654321",
+		LicenceCountry:    "US",
+		LicenceExpiry:     "2100-01-01",
+		LicenceIssued:     "2020-06-01",
+		LicenceCategories: []string{"D"},
+	}
+	carID             = ""
+	inMemoryDB        *sql.DB
+	err               error
+	carProcessor      *cmds.CarProcessor
+	rentProcessor     *cmds.RentProcessor
+	branchProcessor   *cmds.BranchProcessor
+	blackoutProcessor *cmds.BlackoutProcessor
+	customerProcessor *cmds.CustomerProcessor
+	testBranches      = []domain.Branch{
+		{
+			Name:      "New York",
+			Address:   "5th Avenue 1",
+			Latitude:  40.7128,
+			Longitude: -74.0060,
+			Timezone:  "America/New_York",
+			OpeningHours: []domain.OpeningHours{
+				{Day: "Monday", Open: "08:00", Close: "20:00"},
+			},
+		},
+		{
+			Name:      "New Jersey",
+			Latitude:  40.0583,
+			Longitude: -74.4057,
+			Timezone:  "America/New_York",
+		},
+	}
+)
+
+const (
+	testAPIKey = "test-api-key"
+	// Branch agent of Jerusalem branch created by branch seeding
+	agentAPIKey = "agent-api-key"
 )
 
+/*
+Adds API key to every request of the tests
+*/
+type apiKeyTransport struct {
+	transport http.RoundTripper
+}
+
+func (apiKey apiKeyTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if len(request.Header.Get(auth.APIKeyHeader)) == 0 && len(request.Header.Get("Authorization")) == 0 {
+		request = request.Clone(request.Context())
+		request.Header.Set(auth.APIKeyHeader, testAPIKey)
+	}
+	return apiKey.transport.RoundTrip(request)
+}
+
+var plainTransport = http.DefaultTransport
+
 func init() {
+	os.Setenv(config.GenerateCarsEnv, "true")
+	keysFile, err := ioutil.TempFile("", "api-keys-*.json")
+	if err != nil {
+		log.Fatal("failed to create API keys file")
+	}
+	fmt.Fprintf(keysFile, `[{"name": "tests", "keyHash": "%s", "role": "admin"}, {"name": "jerusalem", "keyHash": "%s", "role": "agent", "branchIDs": [1]}]`,
+		auth.HashKey(testAPIKey), auth.HashKey(agentAPIKey))
+	keysFile.Close()
+	os.Setenv(config.APIKeysFileEnv, keysFile.Name())
+	os.Setenv(config.JWTSecretEnv, "test-secret")
+	http.DefaultTransport = apiKeyTransport{transport: plainTransport}
 	go Launch()
 
 	time.Sleep(time.Second * 1)
@@ -100,8 +196,12 @@ func init() {
 	if err != nil {
 		log.Fatal("failed to create to inmemoryDB")
 	}
-	carProcessor = cmds.NewCarProcessor(db.NewDBStructWithDBProvided(inMemoryDB))
-	rentProcessor = cmds.NewRentProcessor(db.NewDBStructWithDBProvided(inMemoryDB))
+	repos := sqlite.NewRepositories(db.NewDBStructWithDBProvided(inMemoryDB))
+	carProcessor = cmds.NewCarProcessor(repos)
+	rentProcessor = cmds.NewRentProcessor(repos)
+	branchProcessor = cmds.NewBranchProcessor(repos)
+	blackoutProcessor = cmds.NewBlackoutProcessor(repos)
+	customerProcessor = cmds.NewCustomerProcessor(repos)
 }
 
 func TestAPICars(test *testing.T) {
@@ -150,6 +250,270 @@ func TestAPICars(test *testing.T) {
 	}
 }
 
+func TestAPICarsSearchValidation(test *testing.T) {
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/cars?location=Tel%%20Aviv,Jerusalem&age=30-45&car=2", restPort))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request cars"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+		test.FailNow()
+	}
+	incorrectFilters := map[string]string{
+		"location=Holon%27%20OR%20%271%27%3D%271":   domain.LocationUrlValue + ":" + validation.NotAllowed,
+		"age=30%20or%201%3D1":                       domain.AgeGroupUrlValue + ":" + validation.InvalidFormat,
+		"car=2%3BDROP%20TABLE%20cars":               domain.CarGroupUrlValue + ":" + validation.InvalidFormat,
+		"fromDate=2022-01-14T15:13:30Z":             domain.ToDateUrlValue + ":" + validation.Required,
+		"fromDate=2022-01-14T15:13:30Z&toDate=2022": domain.ToDateUrlValue + ":" + validation.InvalidFormat,
+	}
+	for filter, violation := range incorrectFilters {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/cars?%s", restPort, filter))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to request cars"))
+			test.FailNow()
+		}
+		if resp.StatusCode != http.StatusBadRequest {
+			test.Error(fmt.Errorf("Status is incorrect for [%s]. Received %d, want %d", filter, resp.StatusCode, http.StatusBadRequest))
+			test.FailNow()
+		}
+		violations := readViolations(test, resp)
+		if !reflect.DeepEqual(violations, []string{violation}) {
+			test.Errorf("Incorrect violations for [%s]. Received %v, want %v", filter, violations, []string{violation})
+			test.FailNow()
+		}
+	}
+}
+
+/*
+Read violations of rejected request as field:code pairs
+*/
+func readViolations(test *testing.T, resp *http.Response) []string {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read response body"))
+		test.FailNow()
+	}
+	var responseMessage struct {
+		ResponseMessage domain.ValidationFailure `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &responseMessage)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	var violations []string
+	for _, violation := range responseMessage.ResponseMessage.Violations {
+		violations = append(violations, violation.Field+":"+violation.Code)
+	}
+	return violations
+}
+
+func TestAPIBranches(test *testing.T) {
+	for index := range testBranches {
+		jsonStr, err := json.Marshal(testBranches[index])
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to marshal branch"))
+			test.FailNow()
+		}
+		resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/branches", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to create branch"))
+			test.FailNow()
+		}
+		if resp.StatusCode != http.StatusCreated {
+			test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+			test.FailNow()
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to read response body of branches"))
+			test.FailNow()
+		}
+		var responseMessage domain.RestResponse
+		err = json.Unmarshal(body, &responseMessage)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to unpack response"))
+			test.FailNow()
+		}
+		respMessage, _ := responseMessage.ResponseMessage.(string)
+		testBranches[index].BranchID, err = strconv.Atoi(regexp.MustCompile("[0-9]+").FindString(respMessage))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to extract branchID"))
+			test.FailNow()
+		}
+		branchFromDB, err := branchProcessor.GetBranchFromDB(testBranches[index].BranchID)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to extract branch from DB"))
+			test.FailNow()
+		}
+		if !reflect.DeepEqual(branchFromDB, &testBranches[index]) {
+			test.Errorf("Rest response is different from database data. Post branch\n[%+v]\n Created branch\n[%+v]", &testBranches[index], branchFromDB)
+			test.FailNow()
+		}
+	}
+	testCar.BranchIDs = []int{testBranches[0].BranchID}
+	updateCar.BranchIDs = []int{testBranches[1].BranchID}
+	testRent.BranchID = testBranches[0].BranchID
+
+	incorrectBranches := []struct {
+		violation string
+		status    int
+		branch    domain.Branch
+	}{
+		{"name:duplicate", http.StatusUnprocessableEntity, domain.Branch{Name: "New York", Timezone: "UTC"}},
+		{"timezone:not_allowed", http.StatusBadRequest, domain.Branch{Name: "Boston", Timezone: "America/Nowhere"}},
+		{"latitude:out_of_range", http.StatusBadRequest, domain.Branch{Name: "Boston", Timezone: "UTC", Latitude: 91}},
+		{"openingHours:out_of_range", http.StatusBadRequest, domain.Branch{Name: "Boston", Timezone: "UTC", OpeningHours: []domain.OpeningHours{{Day: "Monday", Open: "20:00", Close: "08:00"}}}},
+	}
+	for _, incorrect := range incorrectBranches {
+		jsonStr, err := json.Marshal(incorrect.branch)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to marshal branch"))
+			test.FailNow()
+		}
+		resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/branches", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to create branch"))
+			test.FailNow()
+		}
+		if resp.StatusCode != incorrect.status {
+			test.Error(fmt.Errorf("Status is incorrect for [%s]. Received %d, want %d", incorrect.violation, resp.StatusCode, incorrect.status))
+			test.FailNow()
+		}
+		violations := readViolations(test, resp)
+		if !reflect.DeepEqual(violations, []string{incorrect.violation}) {
+			test.Errorf("Incorrect violations. Received %v, want %v", violations, []string{incorrect.violation})
+			test.FailNow()
+		}
+	}
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/branches/1000", restPort))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request branch"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusNotFound))
+		test.FailNow()
+	}
+}
+
+func TestAPICustomers(test *testing.T) {
+	for _, customer := range []*domain.Customer{&testCustomer, &youngCustomer} {
+		jsonStr, err := json.Marshal(customer)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to marshal customer"))
+			test.FailNow()
+		}
+		resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/customers", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to create customer"))
+			test.FailNow()
+		}
+		if resp.StatusCode != http.StatusCreated {
+			test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+			test.FailNow()
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to read response body of customers"))
+			test.FailNow()
+		}
+		var responseMessage domain.RestResponse
+		err = json.Unmarshal(body, &responseMessage)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to unpack response"))
+			test.FailNow()
+		}
+		respMessage, _ := responseMessage.ResponseMessage.(string)
+		customer.CustomerID, err = strconv.Atoi(regexp.MustCompile("[0-9]+").FindString(respMessage))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to extract customerID"))
+			test.FailNow()
+		}
+		customerFromDB, err := customerProcessor.GetCustomerFromDB(customer.CustomerID)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to extract customer from DB"))
+			test.FailNow()
+		}
+		if !reflect.DeepEqual(customerFromDB, customer) {
+			test.Errorf("Rest response is different from database data. Post customer\n[%+v]\n Created customer\n[%+v]", customer, customerFromDB)
+			test.FailNow()
+		}
+	}
+	testRent.CustomerID = testCustomer.CustomerID
+	testRentError.CustomerID = testCustomer.CustomerID
+
+	duplicateLicence := youngCustomer
+	duplicateLicence.Name = "Other Driver"
+	incorrectCustomers := []struct {
+		violation string
+		status    int
+		customer  domain.Customer
+	}{
+		{"licenceNumber:duplicate", http.StatusUnprocessableEntity, duplicateLicence},
+		{"dateOfBirth:invalid_format", http.StatusBadRequest, domain.Customer{Name: "Driver", Email: "driver@example.com", DateOfBirth: "01.01.1990", LicenceNumber: "1", LicenceCountry: "US", LicenceExpiry: "2100-01-01", LicenceIssued: "2010-01-01", LicenceCategories: []string{"D"}}},
+		{"licenceCountry:invalid_format", http.StatusBadRequest, domain.Customer{Name: "Driver", Email: "driver@example.com", DateOfBirth: "1990-01-01", LicenceNumber: "1", LicenceCountry: "USA", LicenceExpiry: "2100-01-01", LicenceIssued: "2010-01-01", LicenceCategories: []string{"D"}}},
+	}
+	for _, incorrect := range incorrectCustomers {
+		jsonStr, err := json.Marshal(incorrect.customer)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to marshal customer"))
+			test.FailNow()
+		}
+		resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/customers", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to create customer"))
+			test.FailNow()
+		}
+		if resp.StatusCode != incorrect.status {
+			test.Error(fmt.Errorf("Status is incorrect for [%s]. Received %d, want %d", incorrect.violation, resp.StatusCode, incorrect.status))
+			test.FailNow()
+		}
+		violations := readViolations(test, resp)
+		if !reflect.DeepEqual(violations, []string{incorrect.violation}) {
+			test.Errorf("Incorrect violations. Received %v, want %v", violations, []string{incorrect.violation})
+			test.FailNow()
+		}
+	}
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/customers/100000", restPort))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request customer"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusNotFound))
+		test.FailNow()
+	}
+}
+
+func TestAPIAddCarUnknownBranch(test *testing.T) {
+	unknownBranchCar := testCar
+	unknownBranchCar.AvailableLocations = []string{"Atlantis"}
+	unknownBranchCar.BranchIDs = nil
+	jsonStr, err := json.Marshal(unknownBranchCar)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to marshal car"))
+		test.FailNow()
+	}
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/cars", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to create cars"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity))
+		test.FailNow()
+	}
+	violations := readViolations(test, resp)
+	if !reflect.DeepEqual(violations, []string{"availableLocations:not_found"}) {
+		test.Errorf("Incorrect violations. Received %v, want %v", violations, []string{"availableLocations:not_found"})
+		test.FailNow()
+	}
+}
+
 func TestAPIAddCarAndGetCar(test *testing.T) {
 	jsonStr, err := json.Marshal(testCar)
 	if err != nil {
@@ -291,6 +655,60 @@ func TestAPIPutCar(test *testing.T) {
 	}
 }
 
+func TestAPIPutCarViolations(test *testing.T) {
+	malformedCar := updateCar
+	malformedCar.CarCompanyName = ""
+	malformedCar.Price = -1
+	malformedCar.AvailableLocations = []string{"Atlantis"}
+	unknownBranchCar := updateCar
+	unknownBranchCar.AvailableLocations = []string{"Atlantis"}
+	incorrectCars := []struct {
+		status     int
+		violations []string
+		car        domain.Car
+	}{
+		{http.StatusBadRequest, []string{"carCompanyName:required", "price:out_of_range", "availableLocations:not_found"}, malformedCar},
+		{http.StatusUnprocessableEntity, []string{"availableLocations:not_found"}, unknownBranchCar},
+	}
+	client := &http.Client{}
+	for _, incorrect := range incorrectCars {
+		jsonStr, err := json.Marshal(incorrect.car)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to marshal car"))
+			test.FailNow()
+		}
+		req, err := http.NewRequest(http.MethodPut, fmt.Sprintf("http://localhost:%d/api/cars/1", restPort), bytes.NewBuffer(jsonStr))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to update car"))
+			test.FailNow()
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		resp, err := client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to update car"))
+			test.FailNow()
+		}
+		if resp.StatusCode != incorrect.status {
+			test.Error(fmt.Errorf("Status is incorrect for %v. Received %d, want %d", incorrect.violations, resp.StatusCode, incorrect.status))
+			test.FailNow()
+		}
+		violations := readViolations(test, resp)
+		if !reflect.DeepEqual(violations, incorrect.violations) {
+			test.Errorf("Incorrect violations. Received %v, want %v", violations, incorrect.violations)
+			test.FailNow()
+		}
+	}
+	carFromDB, err := carProcessor.GetCarFromDB(1)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to extract cars from DB"))
+		test.FailNow()
+	}
+	if !reflect.DeepEqual(carFromDB, &updateCar) {
+		test.Errorf("Rejected update changed car\n[%+v]", carFromDB)
+		test.FailNow()
+	}
+}
+
 func TestAPIDeleteCar(test *testing.T) {
 	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/api/cars/1", restPort), nil)
 	if err != nil {
@@ -311,14 +729,26 @@ func TestAPIDeleteCar(test *testing.T) {
 		test.FailNow()
 	}
 
-	test.Log("Car deleted sussesfully")
-	_, err = carProcessor.GetCarFromDB(1)
-	if err == nil {
-		test.Error("Error should be produces")
+	test.Log("Car retired sussesfully")
+	retiredCar, err := carProcessor.GetCarFromDB(1)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Retired car should be kept"))
 		test.FailNow()
-	} else {
-		test.Log("Car not found in DB")
-
+	}
+	if len(retiredCar.RetiredAt) == 0 {
+		test.Errorf("Car is not retired. Received %+v", retiredCar)
+		test.FailNow()
+	}
+	cars, err := carProcessor.GetCarsFromDB()
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get cars"))
+		test.FailNow()
+	}
+	for _, car := range cars {
+		if car.CarID == 1 {
+			test.Error("Retired car should not be listed")
+			test.FailNow()
+		}
 	}
 }
 
@@ -403,6 +833,10 @@ func TestAPIAddRentAndGetRent(test *testing.T) {
 	testRent.RentID = rent.RentID
 	testRent.AgeGroup = rent.AgeGroup
 	testRent.CarGroup = rent.CarGroup
+	testRent.Status = domain.ReservedRentStatus
+	// Rent without drop-off branch is returned where it was picked up
+	testRent.ReturnLocation = testRent.Location
+	testRent.ReturnBranchID = testRent.BranchID
 
 	if !reflect.DeepEqual(rentFromDB, &testRent) {
 		test.Errorf("Rest response is different from database data. Post rent\n[%+v]\n Created rent\n[%+v]", &testRent, rentFromDB)
@@ -509,80 +943,1276 @@ func TestAPIAddRentError(test *testing.T) {
 	}
 }
 
-func TestAPIRents(test *testing.T) {
-	for i := 0; i < 1000; i++ {
-		resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/rents", restPort))
-		if err != nil {
-			test.Error(errors.Wrap(err, "Faled to request rents"))
-			test.FailNow()
-		}
-		if resp.StatusCode != http.StatusOK {
-			test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
-			test.FailNow()
-		}
-		//We Read the response body on the line below.
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			test.Error(errors.Wrap(err, "Faled to read response body of rents"))
-			test.FailNow()
-		}
-		var responseMessage domain.RestResponse
-		var restRentsResult []domain.RentInfo
-		err = json.Unmarshal(body, &responseMessage)
+func TestAPIAddRentViolations(test *testing.T) {
+	mismatchingRent := testRentError
+	mismatchingRent.FromDate = "2022-03-15T15:13:30Z"
+	mismatchingRent.ToDate = "2022-03-16T15:13:30Z"
+	mismatchingRent.Location = "Haifa"
+	mismatchingRent.CarGroup = testCar.CarGroup + 1
+	mismatchingRent.CustomerID = 0
+	unknownCarRent := mismatchingRent
+	unknownCarRent.CarID = 100000
+	unknownCarRent.CustomerID = testCustomer.CustomerID
+	incorrectRents := []struct {
+		status     int
+		violations []string
+		rent       domain.RentInfo
+	}{
+		{http.StatusBadRequest, []string{"customerID:required", "location:mismatch", "carGroup:mismatch"}, mismatchingRent},
+		{http.StatusUnprocessableEntity, []string{"carID:not_found"}, unknownCarRent},
+	}
+	for _, incorrect := range incorrectRents {
+		jsonStr, err := json.Marshal(incorrect.rent)
 		if err != nil {
-			test.Error(errors.Wrap(err, "Faled to unpack response"))
-			test.FailNow()
-		}
-		bytes, err := json.Marshal(responseMessage.ResponseMessage)
-		if err != nil {
-			test.Error(errors.Wrap(err, "Faled to marshal rents from response"))
+			test.Error(errors.Wrap(err, "Faled to marshal rent"))
 			test.FailNow()
 		}
-		err = json.Unmarshal(bytes, &restRentsResult)
+		resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/rents", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
 		if err != nil {
-			test.Error(errors.Wrap(err, "Faled to unmarshal rents from response"))
+			test.Error(errors.Wrap(err, "Faled to create rents"))
 			test.FailNow()
 		}
-		rentsFromDB, err := rentProcessor.GetRentsFromDB()
-		if err != nil {
-			test.Error(errors.Wrap(err, "Faled to extract rents from DB"))
+		if resp.StatusCode != incorrect.status {
+			test.Error(fmt.Errorf("Status is incorrect for %v. Received %d, want %d", incorrect.violations, resp.StatusCode, incorrect.status))
 			test.FailNow()
 		}
-		if !reflect.DeepEqual(rentsFromDB, restRentsResult) || len(rentsFromDB) != len(restRentsResult) {
-			test.Error("Rest response is different from database data.")
+		violations := readViolations(test, resp)
+		if !reflect.DeepEqual(violations, incorrect.violations) {
+			test.Errorf("Incorrect violations. Received %v, want %v", violations, incorrect.violations)
 			test.FailNow()
 		}
 	}
 }
 
-func TestAPIDeleteRent(test *testing.T) {
-	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/api/rents/1", restPort), nil)
+func TestAPIAddRentConflictingRents(test *testing.T) {
+	conflictingRent := testRentError
+	conflictingRent.FromDate = testRent.FromDate
+	conflictingRent.ToDate = testRent.ToDate
+	jsonStr, err := json.Marshal(conflictingRent)
 	if err != nil {
-		test.Error(errors.Wrap(err, "Faled to delete rent"))
+		test.Error(errors.Wrap(err, "Faled to marshal rent"))
 		test.FailNow()
 	}
-	client := &http.Client{}
-	// set the request header Content-Type for json
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	resp, err := client.Do(req)
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/rents", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
 	if err != nil {
-		test.Error(errors.Wrap(err, "Faled to delete rent"))
+		test.Error(errors.Wrap(err, "Faled to create rents"))
 		test.FailNow()
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+	if resp.StatusCode != http.StatusConflict {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusConflict))
 		test.FailNow()
 	}
-
-	test.Log("Rent deleted sussesfully")
-	_, err = rentProcessor.GetRentFromDB(1)
-	if err == nil {
-		test.Error("Error should be produces")
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read response body of rents"))
 		test.FailNow()
-	} else {
-		test.Log("Rent not found in DB")
+	}
+	var responseMessage struct {
+		ResponseMessage domain.RentConflict `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &responseMessage)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	if !reflect.DeepEqual(responseMessage.ResponseMessage.ConflictingRentIDs, []int{testRent.RentID}) {
+		test.Errorf("Conflicting rents are incorrect. Received %v, want %v", responseMessage.ResponseMessage.ConflictingRentIDs, []int{testRent.RentID})
+		test.FailNow()
+	}
 
+	conflictingRent.FromDate = testRent.ToDate
+	conflictingRent.ToDate = "2022-01-17T15:13:30Z"
+	jsonStr, err = json.Marshal(conflictingRent)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to marshal rent"))
+		test.FailNow()
+	}
+	resp, err = http.Post(fmt.Sprintf("http://localhost:%d/api/rents", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to create rents"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusCreated {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+		test.FailNow()
+	} else {
+		test.Log("Rent starting when previous rent ends created sussesfully")
+	}
+}
+
+func TestAPIUpdateRent(test *testing.T) {
+	client := &http.Client{}
+	mismatchingRent := testRent
+	mismatchingRent.CarGroup = testCar.CarGroup + 1
+	mismatchingRentJSON, err := json.Marshal(mismatchingRent)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to marshal rent"))
+		test.FailNow()
+	}
+	requests := []struct {
+		method       string
+		rentID       int
+		body         string
+		expectedCode int
+	}{
+		{http.MethodPatch, testRent.RentID, `{"toDate":"2022-01-16T18:00:00Z"}`, http.StatusConflict},
+		{http.MethodPut, testRent.RentID, string(mismatchingRentJSON), http.StatusUnprocessableEntity},
+		{http.MethodPatch, 100000, `{"toDate":"2022-01-16T18:00:00Z"}`, http.StatusNotFound},
+		{http.MethodPatch, testRent.RentID, `{"fromDate":"2022-01-14T15:13:30Z"}`, http.StatusOK},
+	}
+	var resp *http.Response
+	for _, rentRequest := range requests {
+		req, err := http.NewRequest(rentRequest.method, fmt.Sprintf("http://localhost:%d/api/rents/%d", restPort, rentRequest.rentID), bytes.NewBufferString(rentRequest.body))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to update rent"))
+			test.FailNow()
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		resp, err = client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to update rent"))
+			test.FailNow()
+		}
+		if resp.StatusCode != rentRequest.expectedCode {
+			test.Error(fmt.Errorf("Status is incorrect for %s %s. Received %d, want %d", rentRequest.method, rentRequest.body, resp.StatusCode, rentRequest.expectedCode))
+			test.FailNow()
+		}
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read response body of rent"))
+		test.FailNow()
+	}
+	var responseMessage struct {
+		ResponseMessage domain.RentInfo `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &responseMessage)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	rentFromDB, err := rentProcessor.GetRentFromDB(testRent.RentID)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to extract rents from DB"))
+		test.FailNow()
+	}
+	if !reflect.DeepEqual(rentFromDB, &responseMessage.ResponseMessage) {
+		test.Errorf("Rest response is different from database data. Updated rent\n[%+v]\n Rent in DB\n[%+v]", responseMessage.ResponseMessage, rentFromDB)
+		test.FailNow()
+	}
+	if rentFromDB.FromDate != "2022-01-14T15:13:30Z" || rentFromDB.Quote == nil || rentFromDB.Quote.Days != 2 {
+		test.Errorf("Rent is not extended and priced again. Received %+v", rentFromDB)
+		test.FailNow()
+	}
+}
+
+func TestAPIRents(test *testing.T) {
+	for i := 0; i < 1000; i++ {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/rents", restPort))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to request rents"))
+			test.FailNow()
+		}
+		if resp.StatusCode != http.StatusOK {
+			test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+			test.FailNow()
+		}
+		//We Read the response body on the line below.
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to read response body of rents"))
+			test.FailNow()
+		}
+		var responseMessage domain.RestResponse
+		var restRentsResult []domain.RentInfo
+		err = json.Unmarshal(body, &responseMessage)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to unpack response"))
+			test.FailNow()
+		}
+		bytes, err := json.Marshal(responseMessage.ResponseMessage)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to marshal rents from response"))
+			test.FailNow()
+		}
+		err = json.Unmarshal(bytes, &restRentsResult)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to unmarshal rents from response"))
+			test.FailNow()
+		}
+		rentsFromDB, err := rentProcessor.GetRentsFromDB()
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to extract rents from DB"))
+			test.FailNow()
+		}
+		if !reflect.DeepEqual(rentsFromDB, restRentsResult) || len(rentsFromDB) != len(restRentsResult) {
+			test.Error("Rest response is different from database data.")
+			test.FailNow()
+		}
+	}
+}
+
+func TestAPIRentLifecycle(test *testing.T) {
+	rents, err := rentProcessor.GetRentsFromDB()
+	if err != nil || len(rents) == 0 {
+		test.Error(errors.Wrap(err, "Faled to extract rents from DB"))
+		test.FailNow()
+	}
+	rentID := rents[len(rents)-1].RentID
+	client := &http.Client{}
+	requests := []struct {
+		method             string
+		path               string
+		body               string
+		expectedCode       int
+		expectedViolations []string
+	}{
+		{http.MethodPost, "return", `{"odometer":1000,"fuelLevel":50}`, http.StatusConflict, nil},
+		{http.MethodPost, "pickup", `{"odometer":-1}`, http.StatusBadRequest, []string{"odometer:out_of_range", "fuelLevel:required"}},
+		{http.MethodPost, "pickup", `{"odometer":1000,"fuelLevel":100}`, http.StatusOK, nil},
+		{http.MethodPost, "cancel", "", http.StatusConflict, nil},
+		{http.MethodPost, "return", `{"odometer":900,"fuelLevel":50}`, http.StatusUnprocessableEntity, []string{"odometer:mismatch"}},
+		{http.MethodPost, "return", `{"odometer":1250,"fuelLevel":50}`, http.StatusOK, nil},
+		{http.MethodPatch, "", `{"toDate":"2022-01-18T15:13:30Z"}`, http.StatusConflict, nil},
+		{http.MethodPost, "close", "", http.StatusOK, nil},
+	}
+	for _, rentRequest := range requests {
+		url := fmt.Sprintf("http://localhost:%d/api/rents/%d", restPort, rentID)
+		if len(rentRequest.path) > 0 {
+			url += "/" + rentRequest.path
+		}
+		req, err := http.NewRequest(rentRequest.method, url, bytes.NewBufferString(rentRequest.body))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to change rent status"))
+			test.FailNow()
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		resp, err := client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to change rent status"))
+			test.FailNow()
+		}
+		if resp.StatusCode != rentRequest.expectedCode {
+			test.Error(fmt.Errorf("Status is incorrect for %s %s. Received %d, want %d", rentRequest.method, rentRequest.path, resp.StatusCode, rentRequest.expectedCode))
+			test.FailNow()
+		}
+		if rentRequest.expectedViolations != nil {
+			if violations := readViolations(test, resp); !reflect.DeepEqual(violations, rentRequest.expectedViolations) {
+				test.Errorf("Violations are incorrect for %s. Received %v, want %v", rentRequest.body, violations, rentRequest.expectedViolations)
+				test.FailNow()
+			}
+		}
+	}
+
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/rents/%d/history", restPort, rentID))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request rent history"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+		test.FailNow()
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read response body of rent history"))
+		test.FailNow()
+	}
+	var historyResponse struct {
+		ResponseMessage []domain.RentTransition `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &historyResponse)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	var statuses []string
+	for _, transition := range historyResponse.ResponseMessage {
+		statuses = append(statuses, transition.ToStatus)
+	}
+	expectedStatuses := []string{domain.PickedUpRentStatus, domain.ReturnedRentStatus, domain.ClosedRentStatus}
+	if !reflect.DeepEqual(statuses, expectedStatuses) || *historyResponse.ResponseMessage[1].Odometer != 1250 {
+		test.Errorf("Rent history is incorrect. Received %+v", historyResponse.ResponseMessage)
+		test.FailNow()
+	}
+
+	resp, err = http.Get(fmt.Sprintf("http://localhost:%d/api/rents/100000/history", restPort))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request rent history"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusNotFound))
+		test.FailNow()
+	}
+}
+
+func TestAPIDeleteRent(test *testing.T) {
+	client := &http.Client{}
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/api/rents/1", restPort), bytes.NewBufferString(`{"reason":"Plans changed"}`))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to delete rent"))
+		test.FailNow()
+	}
+	// set the request header Content-Type for json
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	resp, err := client.Do(req)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to delete rent"))
+		test.FailNow()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+		test.FailNow()
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read response body of rent cancellation"))
+		test.FailNow()
+	}
+	var responseMessage struct {
+		ResponseMessage domain.Cancellation `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &responseMessage)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	cancellation := responseMessage.ResponseMessage
+	// Pickup time of the rent has passed, so nothing is refunded
+	if cancellation.Fee != cancellation.Total || cancellation.Refund != 0 || cancellation.Total == 0 || cancellation.Reason != "Plans changed" {
+		test.Errorf("Cancellation is incorrect. Received %+v", cancellation)
+		test.FailNow()
+	}
+
+	rentFromDB, err := rentProcessor.GetRentFromDB(1)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Cancelled rent should be kept"))
+		test.FailNow()
+	}
+	if rentFromDB.Status != domain.CancelledRentStatus || !reflect.DeepEqual(rentFromDB.Cancellation, &cancellation) {
+		test.Errorf("Rent is not cancelled. Received %+v", rentFromDB)
+		test.FailNow()
+	}
+	test.Log("Rent cancelled sussesfully")
+
+	expectedCodes := map[int]int{
+		1:      http.StatusConflict,
+		100000: http.StatusNotFound,
+	}
+	for rentID, expectedCode := range expectedCodes {
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/api/rents/%d", restPort, rentID), nil)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to delete rent"))
+			test.FailNow()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to delete rent"))
+			test.FailNow()
+		}
+		if resp.StatusCode != expectedCode {
+			test.Error(fmt.Errorf("Status is incorrect for rent %d. Received %d, want %d", rentID, resp.StatusCode, expectedCode))
+			test.FailNow()
+		}
+	}
+}
+
+func TestAPIQuotes(test *testing.T) {
+	quotedRent := testRent
+	quotedRent.RentID = 0
+	quotedRent.FromDate = "2022-02-01T15:13:30Z"
+	quotedRent.ToDate = "2022-02-02T15:13:30Z"
+	quotedRent.Quote = nil
+	quotedRent.CarGroup = testRentError.CarGroup
+	jsonStr, err := json.Marshal(quotedRent)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to marshal rent"))
+		test.FailNow()
+	}
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/quotes", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to create quote"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusCreated {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+		test.FailNow()
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read response body of quote"))
+		test.FailNow()
+	}
+	var quoteResponse struct {
+		ResponseMessage domain.RentQuote `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &quoteResponse)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	quote := quoteResponse.ResponseMessage
+	if len(quote.QuoteID) == 0 || len(quote.ExpiresAt) == 0 {
+		test.Errorf("Quote should have ID and expiration time. Received %v", quote)
+		test.FailNow()
+	}
+	if !reflect.DeepEqual(quote.Rent.Quote, testRent.Quote) {
+		test.Errorf("Quote price is incorrect. Received %v, want %v", quote.Rent.Quote, testRent.Quote)
+		test.FailNow()
+	}
+	rentsFromDB, err := rentProcessor.GetRentsFromDB()
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to extract rents from DB"))
+		test.FailNow()
+	}
+	for _, rent := range rentsFromDB {
+		if rent.FromDate == quotedRent.FromDate {
+			test.Error("Quote should not create rent")
+			test.FailNow()
+		}
+	}
+
+	expectedCodes := map[string]int{
+		quote.QuoteID: http.StatusOK,
+		"unknown":     http.StatusNotFound,
+	}
+	for quoteID, expectedCode := range expectedCodes {
+		resp, err = http.Get(fmt.Sprintf("http://localhost:%d/api/quotes/%s", restPort, quoteID))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to request quote"))
+			test.FailNow()
+		}
+		if resp.StatusCode != expectedCode {
+			test.Error(fmt.Errorf("Status is incorrect for quote %s. Received %d, want %d", quoteID, resp.StatusCode, expectedCode))
+			test.FailNow()
+		}
+	}
+
+	rejectedRent := quotedRent
+	rejectedRent.FromDate = "2022-01-16T10:00:00Z"
+	rejectedRent.ToDate = "2022-01-17T10:00:00Z"
+	rejectedRent.CarGroup = testCar.CarGroup + 1
+	rejectedRent.CustomerID = youngCustomer.CustomerID
+	jsonStr, err = json.Marshal(rejectedRent)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to marshal rent"))
+		test.FailNow()
+	}
+	resp, err = http.Post(fmt.Sprintf("http://localhost:%d/api/quotes", restPort), "application/json; charset=utf-8", bytes.NewBuffer(jsonStr))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to create quote"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity))
+		test.FailNow()
+	}
+	violations := readViolations(test, resp)
+	expectedViolations := []string{"carGroup:mismatch", "customerID:mismatch", "fromDate:not_available"}
+	if !reflect.DeepEqual(violations, expectedViolations) {
+		test.Errorf("Rejection reasons are incorrect. Received %v, want %v", violations, expectedViolations)
+		test.FailNow()
+	}
+}
+
+func TestAPIRetireCarWithBookings(test *testing.T) {
+	retiringCar := testCar
+	retiringCar.AvailableLocations = []string{"Haifa"}
+	retiringCar.CarGroup = 9001
+	retiringCar.MinimumAge = 25
+	var carIDs []int
+	for i := 0; i < 2; i++ {
+		id, err := carProcessor.InsertCarInDB(retiringCar)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to insert car"))
+			test.FailNow()
+		}
+		carIDs = append(carIDs, int(id))
+	}
+	car, err := carProcessor.GetCarFromDB(carIDs[0])
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get car"))
+		test.FailNow()
+	}
+	booking := domain.RentInfo{
+		CarID:      carIDs[0],
+		FromDate:   "2099-03-01T10:00:00Z",
+		ToDate:     "2099-03-05T10:00:00Z",
+		Location:   "Haifa",
+		CustomerID: testCustomer.CustomerID,
+		CarGroup:   9001,
+	}
+	rentID, err := rentProcessor.InsertRentInDB(booking, car)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to insert rent"))
+		test.FailNow()
+	}
+
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/cars/%d/reassignments?dryRun=true", restPort, carIDs[0]), "application/json; charset=utf-8", bytes.NewBufferString(`{"reason":"Damaged"}`))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to plan reassignment"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+		test.FailNow()
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read response body of reassignment plan"))
+		test.FailNow()
+	}
+	var planMessage struct {
+		ResponseMessage domain.ReassignmentPlan `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &planMessage)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	plan := planMessage.ResponseMessage
+	if !plan.DryRun || len(plan.Reassigned) != 1 || plan.Reassigned[0].ToCarID != carIDs[1] || plan.Reassigned[0].Reason != "Damaged" {
+		test.Errorf("Reassignment plan is incorrect. Received %+v", plan)
+		test.FailNow()
+	}
+	plannedRent, err := rentProcessor.GetRentFromDB(int(rentID))
+	if err != nil || plannedRent.CarID != carIDs[0] {
+		test.Errorf("Rent should not be moved by dry run. Received %+v, %v", plannedRent, err)
+		test.FailNow()
+	}
+
+	client := &http.Client{}
+	expectedCodes := []struct {
+		query string
+		code  int
+	}{
+		{query: "?reassign=maybe", code: http.StatusBadRequest},
+		{query: "", code: http.StatusConflict},
+		{query: "?reassign=true", code: http.StatusOK},
+	}
+	for _, expected := range expectedCodes {
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/api/cars/%d%s", restPort, carIDs[0], expected.query), nil)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to retire car"))
+			test.FailNow()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to retire car"))
+			test.FailNow()
+		}
+		if resp.StatusCode != expected.code {
+			test.Error(fmt.Errorf("Status is incorrect for query [%s]. Received %d, want %d", expected.query, resp.StatusCode, expected.code))
+			test.FailNow()
+		}
+		body, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to read response body of car retirement"))
+			test.FailNow()
+		}
+	}
+	var responseMessage struct {
+		ResponseMessage domain.CarRetirement `json:"responseMessage"`
+	}
+	err = json.Unmarshal(body, &responseMessage)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	reassigned := responseMessage.ResponseMessage.Reassigned
+	if len(reassigned) != 1 || reassigned[0].RentID != int(rentID) || reassigned[0].ToCarID != carIDs[1] || reassigned[0].Kind != domain.LikeForLikeReassignment {
+		test.Errorf("Reassignment is incorrect. Received %+v", reassigned)
+		test.FailNow()
+	}
+	rentFromDB, err := rentProcessor.GetRentFromDB(int(rentID))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get rent"))
+		test.FailNow()
+	}
+	if rentFromDB.CarID != carIDs[1] {
+		test.Errorf("Rent should be moved to car %d. Received %d", carIDs[1], rentFromDB.CarID)
+		test.FailNow()
+	}
+	reassignments, err := rentProcessor.GetRentReassignmentsFromDB(int(rentID))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get rent reassignments"))
+		test.FailNow()
+	}
+	if len(reassignments) != 1 || reassignments[0].FromCarID != carIDs[0] || reassignments[0].Reason != "Car retired" {
+		test.Errorf("Reassignment is not recorded. Received %+v", reassignments)
+		test.FailNow()
+	}
+}
+
+func TestAPICarBlackouts(test *testing.T) {
+	blackoutCar := testCar
+	blackoutCar.AvailableLocations = []string{"Haifa"}
+	blackoutCar.CarGroup = 9002
+	blackoutCar.MinimumAge = 25
+	id, err := carProcessor.InsertCarInDB(blackoutCar)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to insert car"))
+		test.FailNow()
+	}
+	carID := int(id)
+	car, err := carProcessor.GetCarFromDB(carID)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get car"))
+		test.FailNow()
+	}
+	rentID, err := rentProcessor.InsertRentInDB(domain.RentInfo{
+		CarID:      carID,
+		FromDate:   "2099-04-01T10:00:00Z",
+		ToDate:     "2099-04-05T10:00:00Z",
+		Location:   "Haifa",
+		CustomerID: testCustomer.CustomerID,
+		CarGroup:   9002,
+	}, car)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to insert rent"))
+		test.FailNow()
+	}
+	blackoutsURL := fmt.Sprintf("http://localhost:%d/api/cars/%d/blackouts", restPort, carID)
+
+	requests := []struct {
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{blackoutsURL, `{"fromDate":"2099-04-03T10:00:00Z","toDate":"2099-04-07T10:00:00Z","reason":"service"}`, http.StatusConflict},
+		{blackoutsURL, `{"fromDate":"2099-05-03T10:00:00Z","toDate":"2099-05-01T10:00:00Z","reason":"service"}`, http.StatusBadRequest},
+		{blackoutsURL, `{"fromDate":"2099-05-01T10:00:00Z","toDate":"2099-05-03T10:00:00Z","reason":"washing"}`, http.StatusBadRequest},
+		{blackoutsURL, `{"fromDate":"2099-05-01T10:00:00Z","toDate":"2099-05-03T10:00:00Z","reason":"damage","description":"Broken mirror"}`, http.StatusCreated},
+		{fmt.Sprintf("http://localhost:%d/api/rents", restPort), fmt.Sprintf(`{"carID":%d,"fromDate":"2099-05-02T10:00:00Z","toDate":"2099-05-04T10:00:00Z","location":"Haifa","customerID":%d,"carGroup":9002}`, carID, testCustomer.CustomerID), http.StatusConflict},
+	}
+	var conflicts []domain.RentConflict
+	for _, blackoutRequest := range requests {
+		resp, err := http.Post(blackoutRequest.url, "application/json; charset=utf-8", bytes.NewBufferString(blackoutRequest.body))
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to post request"))
+			test.FailNow()
+		}
+		if resp.StatusCode != blackoutRequest.expectedCode {
+			test.Error(fmt.Errorf("Status is incorrect for %s. Received %d, want %d", blackoutRequest.body, resp.StatusCode, blackoutRequest.expectedCode))
+			test.FailNow()
+		}
+		if resp.StatusCode == http.StatusConflict {
+			var responseMessage struct {
+				ResponseMessage domain.RentConflict `json:"responseMessage"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&responseMessage); err != nil {
+				test.Error(errors.Wrap(err, "Faled to unpack response"))
+				test.FailNow()
+			}
+			conflicts = append(conflicts, responseMessage.ResponseMessage)
+		}
+	}
+
+	blackouts, err := blackoutProcessor.GetCarBlackoutsFromDB(carID)
+	if err != nil || len(blackouts) != 1 {
+		test.Errorf("Car should have one blackout. Received %+v, %v", blackouts, err)
+		test.FailNow()
+	}
+	blackoutID := blackouts[0].BlackoutID
+	if !reflect.DeepEqual(conflicts[0].ConflictingRentIDs, []int{int(rentID)}) {
+		test.Errorf("Blackout conflicts are incorrect. Received %+v", conflicts[0])
+		test.FailNow()
+	}
+	if !reflect.DeepEqual(conflicts[1].ConflictingBlackoutIDs, []int{blackoutID}) {
+		test.Errorf("Rent conflicts are incorrect. Received %+v", conflicts[1])
+		test.FailNow()
+	}
+
+	client := &http.Client{}
+	blackoutURL := fmt.Sprintf("%s/%d", blackoutsURL, blackoutID)
+	expectedCodes := []struct {
+		method string
+		code   int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodDelete, http.StatusOK},
+		{http.MethodGet, http.StatusNotFound},
+	}
+	for _, expected := range expectedCodes {
+		req, err := http.NewRequest(expected.method, blackoutURL, nil)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to request blackout"))
+			test.FailNow()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to request blackout"))
+			test.FailNow()
+		}
+		if resp.StatusCode != expected.code {
+			test.Error(fmt.Errorf("Status is incorrect for %s. Received %d, want %d", expected.method, resp.StatusCode, expected.code))
+			test.FailNow()
+		}
+	}
+}
+
+func TestAPIOneWayRent(test *testing.T) {
+	oneWayCar := testCar
+	oneWayCar.AvailableLocations = []string{"Haifa"}
+	oneWayCar.CarGroup = 9003
+	oneWayCar.MinimumAge = 25
+	id, err := carProcessor.InsertCarInDB(oneWayCar)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to insert car"))
+		test.FailNow()
+	}
+	body := fmt.Sprintf(`{"carID":%d,"fromDate":"2099-06-01T10:00:00Z","toDate":"2099-06-03T10:00:00Z","location":"Haifa","returnLocation":"Jerusalem","customerID":%d,"carGroup":9003}`, id, testCustomer.CustomerID)
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/rents", restPort), "application/json; charset=utf-8", bytes.NewBufferString(body))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to post rent"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusCreated {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+		test.FailNow()
+	}
+
+	resp, err = http.Get(fmt.Sprintf("http://localhost:%d/api/cars/%d/positions", restPort, id))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request car positions"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+		test.FailNow()
+	}
+	var responseMessage struct {
+		ResponseMessage []domain.CarPosition `json:"responseMessage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&responseMessage); err != nil {
+		test.Error(errors.Wrap(err, "Faled to unpack response"))
+		test.FailNow()
+	}
+	positions := responseMessage.ResponseMessage
+	if len(positions) != 1 || positions[0].Location != "Jerusalem" || positions[0].Since != "2099-06-03T10:00:00Z" {
+		test.Errorf("Car positions are incorrect. Received %+v", positions)
+		test.FailNow()
+	}
+	rent, err := rentProcessor.GetRentFromDB(positions[0].RentID)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get rent"))
+		test.FailNow()
+	}
+	oneWayCharged := false
+	for _, item := range rent.Quote.Items {
+		oneWayCharged = oneWayCharged || item.Kind == domain.OneWayPriceItem
+	}
+	if !oneWayCharged {
+		test.Errorf("One-way fee is not charged. Received %+v", rent.Quote)
+		test.FailNow()
+	}
+}
+
+func TestAPIRebalancePlan(test *testing.T) {
+	idleCar := testCar
+	idleCar.AvailableLocations = []string{"Haifa"}
+	idleCar.CarGroup = 9004
+	idleCar.MinimumAge = 25
+	idleID, err := carProcessor.InsertCarInDB(idleCar)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to insert car"))
+		test.FailNow()
+	}
+	bookedCar := idleCar
+	bookedCar.AvailableLocations = []string{"Tel Aviv"}
+	bookedID, err := carProcessor.InsertCarInDB(bookedCar)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to insert car"))
+		test.FailNow()
+	}
+	body := fmt.Sprintf(`{"carID":%d,"fromDate":"2099-07-02T10:00:00Z","toDate":"2099-07-03T10:00:00Z","location":"Tel Aviv","customerID":%d,"carGroup":9004}`, bookedID, testCustomer.CustomerID)
+	resp, err := http.Post(fmt.Sprintf("http://localhost:%d/api/rents", restPort), "application/json; charset=utf-8", bytes.NewBufferString(body))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to post rent"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusCreated {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+		test.FailNow()
+	}
+
+	resp, err = http.Get(fmt.Sprintf("http://localhost:%d/api/fleet/rebalance-plan?fromDate=2099-07-01", restPort))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request rebalance plan"))
+		test.FailNow()
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusBadRequest))
+		test.FailNow()
+	}
+
+	findTransfer := func(resp *http.Response) domain.Transfer {
+		if resp.StatusCode != http.StatusOK {
+			test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+			test.FailNow()
+		}
+		var responseMessage struct {
+			ResponseMessage domain.RebalancePlan `json:"responseMessage"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&responseMessage); err != nil {
+			test.Error(errors.Wrap(err, "Faled to unpack response"))
+			test.FailNow()
+		}
+		var found []domain.Transfer
+		for _, transfer := range responseMessage.ResponseMessage.Transfers {
+			if transfer.CarGroup == 9004 {
+				found = append(found, transfer)
+			}
+		}
+		if len(found) != 1 || found[0].CarID != int(idleID) || found[0].FromLocation != "Haifa" || found[0].ToLocation != "Tel Aviv" {
+			test.Errorf("Transfers are incorrect. Received %+v", responseMessage.ResponseMessage)
+			test.FailNow()
+		}
+		return found[0]
+	}
+	resp, err = http.Get(fmt.Sprintf("http://localhost:%d/api/fleet/rebalance-plan?fromDate=2099-07-01T00:00:00Z", restPort))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to request rebalance plan"))
+		test.FailNow()
+	}
+	if transfer := findTransfer(resp); transfer.BlackoutID != 0 {
+		test.Errorf("Planned transfer should not be stored. Received %+v", transfer)
+		test.FailNow()
+	}
+
+	resp, err = http.Post(fmt.Sprintf("http://localhost:%d/api/fleet/rebalance-plan/apply?fromDate=2099-07-01T00:00:00Z", restPort), "application/json; charset=utf-8", nil)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to apply rebalance plan"))
+		test.FailNow()
+	}
+	transfer := findTransfer(resp)
+	blackout, err := blackoutProcessor.GetBlackoutFromDB(int(idleID), transfer.BlackoutID)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get transfer blackout"))
+		test.FailNow()
+	}
+	if blackout.Reason != domain.TransferBlackoutReason || blackout.ToLocation != "Tel Aviv" {
+		test.Errorf("Transfer blackout is incorrect. Received %+v", blackout)
+		test.FailNow()
+	}
+	positions, err := carProcessor.GetCarPositionsFromDB(int(idleID))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get car positions"))
+		test.FailNow()
+	}
+	if len(positions) != 1 || positions[0].Location != "Tel Aviv" || positions[0].BlackoutID != transfer.BlackoutID {
+		test.Errorf("Car positions are incorrect. Received %+v", positions)
+		test.FailNow()
+	}
+}
+
+/*
+Send request with given credentials header only
+*/
+func sendWithCredentials(test *testing.T, method string, path string, header string, value string, body string) *http.Response {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d%s", restPort, path), bytes.NewBufferString(body))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to create request"))
+		test.FailNow()
+	}
+	if len(header) > 0 {
+		req.Header.Set(header, value)
+	}
+	resp, err := (&http.Client{Transport: plainTransport}).Do(req)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to send request"))
+		test.FailNow()
+	}
+	return resp
+}
+
+/*
+ID of created record from response message
+*/
+func createdID(test *testing.T, resp *http.Response) int {
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d: %s", resp.StatusCode, http.StatusCreated, body))
+		test.FailNow()
+	}
+	var response domain.RestResponse
+	json.Unmarshal(body, &response)
+	message, _ := response.ResponseMessage.(string)
+	id, _ := strconv.Atoi(regexp.MustCompile("[0-9]+").FindString(message))
+	return id
+}
+
+/*
+Issue token of the customer with admin API key
+*/
+func customerToken(test *testing.T, customerID int) string {
+	resp := sendWithCredentials(test, http.MethodPost, "/api/auth/token", auth.APIKeyHeader, testAPIKey, fmt.Sprintf(`{"customerID":%d}`, customerID))
+	if resp.StatusCode != http.StatusCreated {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+		test.FailNow()
+	}
+	var token domain.AuthToken
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &domain.RestResponse{ResponseMessage: &token}); err != nil {
+		test.Error(errors.Wrap(err, "Faled to parse token"))
+		test.FailNow()
+	}
+	return token.Token
+}
+
+func TestAPIAuthentication(test *testing.T) {
+	send := func(method string, path string, header string, value string, body string) *http.Response {
+		return sendWithCredentials(test, method, path, header, value, body)
+	}
+	tokenBody := fmt.Sprintf(`{"customerID":%d}`, testCustomer.CustomerID)
+
+	rejected := []struct {
+		method, path, header, value, body string
+	}{
+		{http.MethodGet, "/api/cars", "", "", ""},
+		{http.MethodDelete, "/api/cars/2", "", "", ""},
+		{http.MethodGet, "/api/cars", auth.APIKeyHeader, "wrong-key", ""},
+		{http.MethodGet, "/api/cars", "Authorization", "Bearer not.a.token", ""},
+		{http.MethodGet, "/api/cars", "Authorization", "Basic dGVzdHM6dGVzdHM=", ""},
+		{http.MethodPost, "/api/auth/token", "", "", tokenBody},
+	}
+	for _, request := range rejected {
+		resp := send(request.method, request.path, request.header, request.value, request.body)
+		if resp.StatusCode != http.StatusUnauthorized {
+			test.Error(fmt.Errorf("Status is incorrect for %s %s with %s. Received %d, want %d", request.method, request.path, request.header, resp.StatusCode, http.StatusUnauthorized))
+			test.FailNow()
+		}
+		var response domain.RestResponse
+		body, _ := ioutil.ReadAll(resp.Body)
+		if err := json.Unmarshal(body, &response); err != nil || response.ResponseError == nil {
+			test.Error(fmt.Errorf("Response of rejected request should have error, received %s", body))
+			test.FailNow()
+		}
+	}
+
+	resp := send(http.MethodPost, "/api/auth/token", auth.APIKeyHeader, testAPIKey, `{"customerID":100000}`)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		test.Error(fmt.Errorf("Token of unknown customer should not be issued. Received %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity))
+		test.FailNow()
+	}
+	resp = send(http.MethodPost, "/api/auth/token", auth.APIKeyHeader, testAPIKey, tokenBody)
+	if resp.StatusCode != http.StatusCreated {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusCreated))
+		test.FailNow()
+	}
+	var token domain.AuthToken
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &domain.RestResponse{ResponseMessage: &token}); err != nil {
+		test.Error(errors.Wrap(err, "Faled to parse token"))
+		test.FailNow()
+	}
+	if token.CustomerID != testCustomer.CustomerID || token.TokenType != "Bearer" || len(token.Token) == 0 || len(token.ExpiresAt) == 0 {
+		test.Error(fmt.Errorf("Token is incorrect: %+v", token))
+		test.FailNow()
+	}
+
+	resp = send(http.MethodGet, "/api/cars/2", "Authorization", "Bearer "+token.Token, "")
+	if resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Request with customer token should be accepted. Received %d, want %d", resp.StatusCode, http.StatusOK))
+		test.FailNow()
+	}
+	resp = send(http.MethodPost, "/api/auth/token", "Authorization", "Bearer "+token.Token, tokenBody)
+	if resp.StatusCode != http.StatusForbidden {
+		test.Error(fmt.Errorf("Customer should not issue tokens. Received %d, want %d", resp.StatusCode, http.StatusForbidden))
+		test.FailNow()
+	}
+}
+
+/*
+Car of one branch, generated cars are random and may miss the branch
+*/
+func insertCarAt(test *testing.T, location string) *domain.Car {
+	car := updateCar
+	car.MinimumAge = 21
+	car.AvailableLocations = []string{location}
+	id, err := carProcessor.InsertCarInDB(car)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to insert car"))
+		test.FailNow()
+	}
+	inserted, err := carProcessor.GetCarFromDB(int(id))
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get car"))
+		test.FailNow()
+	}
+	return inserted
+}
+
+func TestAPIAuthorization(test *testing.T) {
+	jerusalemCar := insertCarAt(test, "Jerusalem")
+	otherCar := insertCarAt(test, "Haifa")
+	rentBody := func(car *domain.Car) string {
+		return fmt.Sprintf(`{"carID":%d,"carGroup":%d,"customerID":%d,"location":"Jerusalem","fromDate":"2031-03-01T10:00:00Z","toDate":"2031-03-02T10:00:00Z"}`,
+			car.CarID, car.CarGroup, testCustomer.CustomerID)
+	}
+	resp := sendWithCredentials(test, http.MethodPost, "/api/rents", auth.APIKeyHeader, agentAPIKey, rentBody(jerusalemCar))
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		test.Error(fmt.Errorf("Agent should book car of own branch. Received %d, want %d: %s", resp.StatusCode, http.StatusCreated, body))
+		test.FailNow()
+	}
+	var response domain.RestResponse
+	body, _ := ioutil.ReadAll(resp.Body)
+	json.Unmarshal(body, &response)
+	message, _ := response.ResponseMessage.(string)
+	jerusalemRentID, _ := strconv.Atoi(regexp.MustCompile("[0-9]+").FindString(message))
+
+	// Customer renting only at branch of other car
+	otherBranch, err := branchProcessor.GetBranchFromDB(otherCar.BranchIDs[0])
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to get branch"))
+		test.FailNow()
+	}
+	otherCustomer := testCustomer
+	otherCustomer.Email = "other.driver@example.com"
+	otherCustomer.LicenceNumber = "D2345678"
+	otherCustomerBody, _ := json.Marshal(otherCustomer)
+	otherCustomerID := createdID(test, sendWithCredentials(test, http.MethodPost, "/api/customers", auth.APIKeyHeader, testAPIKey, string(otherCustomerBody)))
+	deskCustomer := testCustomer
+	deskCustomer.Email = "desk.driver@example.com"
+	deskCustomer.LicenceNumber = "D3456789"
+	deskCustomerBody, _ := json.Marshal(deskCustomer)
+	otherRentID := createdID(test, sendWithCredentials(test, http.MethodPost, "/api/rents", auth.APIKeyHeader, testAPIKey,
+		fmt.Sprintf(`{"carID":%d,"carGroup":%d,"customerID":%d,"location":"%s","fromDate":"2031-04-01T10:00:00Z","toDate":"2031-04-02T10:00:00Z"}`,
+			otherCar.CarID, otherCar.CarGroup, otherCustomerID, otherBranch.Name)))
+
+	customerKey := "Bearer " + customerToken(test, testCustomer.CustomerID)
+	youngKey := "Bearer " + customerToken(test, youngCustomer.CustomerID)
+	const admin, agent, customer, young = "admin", "agent", "customer", "young"
+	credentials := map[string][2]string{
+		admin:    {auth.APIKeyHeader, testAPIKey},
+		agent:    {auth.APIKeyHeader, agentAPIKey},
+		customer: {"Authorization", customerKey},
+		young:    {"Authorization", youngKey},
+	}
+	expectedCodes := []struct {
+		role   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{admin, http.MethodGet, "/api/fleet/rebalance-plan?fromDate=2031-03-01T10:00:00Z", "", http.StatusOK},
+		{admin, http.MethodGet, fmt.Sprintf("/api/rents/%d", otherRentID), "", http.StatusOK},
+		{agent, http.MethodGet, "/api/cars", "", http.StatusOK},
+		{agent, http.MethodPost, "/api/cars", "{}", http.StatusForbidden},
+		{agent, http.MethodDelete, fmt.Sprintf("/api/cars/%d", jerusalemCar.CarID), "", http.StatusForbidden},
+		{agent, http.MethodGet, fmt.Sprintf("/api/cars/%d/blackouts", jerusalemCar.CarID), "", http.StatusOK},
+		{agent, http.MethodGet, fmt.Sprintf("/api/cars/%d/blackouts", otherCar.CarID), "", http.StatusForbidden},
+		{agent, http.MethodPost, "/api/rents", rentBody(otherCar), http.StatusForbidden},
+		{agent, http.MethodGet, fmt.Sprintf("/api/rents/%d", jerusalemRentID), "", http.StatusOK},
+		{agent, http.MethodGet, fmt.Sprintf("/api/rents/%d", otherRentID), "", http.StatusForbidden},
+		{agent, http.MethodPost, fmt.Sprintf("/api/rents/%d/pickup", otherRentID), "{}", http.StatusForbidden},
+		{agent, http.MethodGet, "/api/customers", "", http.StatusOK},
+		{agent, http.MethodPost, "/api/customers", string(deskCustomerBody), http.StatusCreated},
+		{agent, http.MethodGet, fmt.Sprintf("/api/customers/%d", testCustomer.CustomerID), "", http.StatusOK},
+		{agent, http.MethodGet, fmt.Sprintf("/api/customers/%d", otherCustomerID), "", http.StatusForbidden},
+		{agent, http.MethodPut, fmt.Sprintf("/api/customers/%d", otherCustomerID), string(otherCustomerBody), http.StatusForbidden},
+		{agent, http.MethodGet, "/api/customers/100000", "", http.StatusNotFound},
+		{agent, http.MethodDelete, "/api/branches/1", "", http.StatusForbidden},
+		{agent, http.MethodGet, "/api/fleet/rebalance-plan", "", http.StatusForbidden},
+		{agent, http.MethodPost, "/api/auth/token", fmt.Sprintf(`{"customerID":%d}`, testCustomer.CustomerID), http.StatusCreated},
+		{agent, http.MethodPost, "/api/auth/token", fmt.Sprintf(`{"customerID":%d}`, otherCustomerID), http.StatusForbidden},
+		{admin, http.MethodPost, "/api/auth/token", fmt.Sprintf(`{"customerID":%d}`, otherCustomerID), http.StatusCreated},
+		{customer, http.MethodGet, "/api/cars", "", http.StatusOK},
+		{customer, http.MethodPost, "/api/cars", "{}", http.StatusForbidden},
+		{customer, http.MethodPost, "/api/rents", rentBody(jerusalemCar), http.StatusForbidden},
+		{customer, http.MethodGet, fmt.Sprintf("/api/rents/%d", jerusalemRentID), "", http.StatusOK},
+		{customer, http.MethodGet, fmt.Sprintf("/api/rents/%d/history", jerusalemRentID), "", http.StatusOK},
+		{customer, http.MethodPatch, fmt.Sprintf("/api/rents/%d", jerusalemRentID), `{"location":"Jerusalem"}`, http.StatusForbidden},
+		{customer, http.MethodPost, fmt.Sprintf("/api/rents/%d/pickup", jerusalemRentID), "{}", http.StatusForbidden},
+		{customer, http.MethodGet, "/api/customers", "", http.StatusForbidden},
+		{customer, http.MethodGet, fmt.Sprintf("/api/customers/%d", testCustomer.CustomerID), "", http.StatusOK},
+		{customer, http.MethodGet, fmt.Sprintf("/api/customers/%d", youngCustomer.CustomerID), "", http.StatusForbidden},
+		{customer, http.MethodPost, "/api/auth/token", fmt.Sprintf(`{"customerID":%d}`, youngCustomer.CustomerID), http.StatusForbidden},
+		{young, http.MethodGet, fmt.Sprintf("/api/rents/%d", jerusalemRentID), "", http.StatusForbidden},
+		{young, http.MethodPost, fmt.Sprintf("/api/rents/%d/cancel", jerusalemRentID), "{}", http.StatusForbidden},
+		{customer, http.MethodPost, fmt.Sprintf("/api/rents/%d/cancel", jerusalemRentID), "{}", http.StatusOK},
+	}
+	for _, expected := range expectedCodes {
+		credential := credentials[expected.role]
+		resp := sendWithCredentials(test, expected.method, expected.path, credential[0], credential[1], expected.body)
+		if resp.StatusCode != expected.code {
+			body, _ := ioutil.ReadAll(resp.Body)
+			test.Error(fmt.Errorf("Status is incorrect for %s %s %s. Received %d, want %d: %s", expected.role, expected.method, expected.path, resp.StatusCode, expected.code, body))
+			test.FailNow()
+		}
+		if expected.code == http.StatusForbidden {
+			var response domain.RestResponse
+			body, _ := ioutil.ReadAll(resp.Body)
+			if err := json.Unmarshal(body, &response); err != nil || response.ResponseError == nil {
+				test.Error(fmt.Errorf("Forbidden response should have error, received %s", body))
+				test.FailNow()
+			}
+		}
+	}
+
+	listed := map[string]func(rent domain.RentInfo) bool{
+		agent:    func(rent domain.RentInfo) bool { return rent.BranchID == 1 || rent.ReturnBranchID == 1 },
+		customer: func(rent domain.RentInfo) bool { return rent.CustomerID == testCustomer.CustomerID },
+		young:    func(rent domain.RentInfo) bool { return false },
+	}
+	for role, inScope := range listed {
+		credential := credentials[role]
+		resp := sendWithCredentials(test, http.MethodGet, "/api/rents", credential[0], credential[1], "")
+		var rents []domain.RentInfo
+		body, _ := ioutil.ReadAll(resp.Body)
+		if err := json.Unmarshal(body, &domain.RestResponse{ResponseMessage: &rents}); err != nil || resp.StatusCode != http.StatusOK {
+			test.Error(fmt.Errorf("Failed to list rents of %s: %d %s", role, resp.StatusCode, body))
+			test.FailNow()
+		}
+		if role != young && len(rents) == 0 {
+			test.Error(fmt.Errorf("Rents of %s should be listed", role))
+		}
+		for _, rent := range rents {
+			if !inScope(rent) {
+				test.Error(fmt.Errorf("Rent %d should not be listed for %s", rent.RentID, role))
+			}
+		}
+	}
+
+	// Agent lists only customers who rent at own branches
+	resp = sendWithCredentials(test, http.MethodGet, "/api/customers", auth.APIKeyHeader, agentAPIKey, "")
+	var customers []domain.Customer
+	body, _ = ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &domain.RestResponse{ResponseMessage: &customers}); err != nil || resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Failed to list customers of agent: %d %s", resp.StatusCode, body))
+		test.FailNow()
+	}
+	var customerIDs []int
+	for _, listedCustomer := range customers {
+		customerIDs = append(customerIDs, listedCustomer.CustomerID)
+	}
+	if !reflect.DeepEqual(customerIDs, []int{testCustomer.CustomerID}) {
+		test.Errorf("Customers of agent are incorrect. Received %v, want %v", customerIDs, []int{testCustomer.CustomerID})
+	}
+}
+
+func TestAPIRequestID(test *testing.T) {
+	expected := map[string]string{"edge-7f3a.1": "edge-7f3a.1", "": "", "has space": ""}
+	for sent, returned := range expected {
+		resp := sendWithCredentials(test, http.MethodGet, "/api/branches/1", domain.RequestIDHeader, sent, "")
+		requestID := resp.Header.Get(domain.RequestIDHeader)
+		if len(returned) > 0 && requestID != returned {
+			test.Error(fmt.Errorf("Request ID [%s] should be returned, received [%s]", sent, requestID))
+		}
+		if len(returned) == 0 && !regexp.MustCompile("^[0-9a-f]{16}$").MatchString(requestID) {
+			test.Error(fmt.Errorf("Request ID should be generated instead of [%s], received [%s]", sent, requestID))
+		}
+	}
+}
+
+/*
+Metrics of earlier tests are scraped, counters only grow so they should not be zero
+*/
+func TestAPIMetrics(test *testing.T) {
+	resp := sendWithCredentials(test, http.MethodGet, "/metrics", auth.APIKeyHeader, agentAPIKey, "")
+	if resp.StatusCode != http.StatusForbidden {
+		test.Error(fmt.Errorf("Status is incorrect for agent. Received %d, want %d", resp.StatusCode, http.StatusForbidden))
+	}
+	resp = sendWithCredentials(test, http.MethodGet, "/metrics", auth.APIKeyHeader, testAPIKey, "")
+	if resp.StatusCode != http.StatusOK {
+		test.Error(fmt.Errorf("Status is incorrect. Received %d, want %d", resp.StatusCode, http.StatusOK))
+		test.FailNow()
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != metrics.ContentType {
+		test.Error(fmt.Errorf("Content type is incorrect. Received %s, want %s", contentType, metrics.ContentType))
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		test.Error(errors.Wrap(err, "Faled to read metrics"))
+		test.FailNow()
+	}
+	samples := make(map[string]float64)
+	for _, line := range strings.Split(string(body), "\n") {
+		separator := strings.LastIndex(line, " ")
+		if len(line) == 0 || strings.HasPrefix(line, "#") || separator < 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[separator+1:], 64)
+		if err != nil {
+			test.Error(fmt.Errorf("Sample [%s] has no value", line))
+			continue
+		}
+		samples[line[:separator]] = value
+	}
+	for _, name := range []string{
+		`car_rental_http_requests_total{method="GET",route="/api/cars",status="200"}`,
+		fmt.Sprintf(`car_rental_http_request_duration_seconds_count{method="GET",route="/api/branches/{%s}"}`, domain.BranchIDPathParam),
+		`car_rental_http_requests_total{method="POST",route="/api/rents",status="409"}`,
+		`car_rental_db_query_duration_seconds_count{statement="InsertIntoRentTable"}`,
+		`car_rental_db_query_duration_seconds_count{statement="SelectRents"}`,
+		`car_rental_rents_created_total`,
+		`car_rental_rents_cancelled_total`,
+		`car_rental_rent_conflicts_total`,
+		`car_rental_fleet_cars`,
+	} {
+		if samples[name] <= 0 {
+			test.Error(fmt.Errorf("Metric %s should be positive, received %v", name, samples[name]))
+		}
+	}
+	if _, ok := samples["car_rental_rented_cars"]; !ok {
+		test.Error(fmt.Errorf("Metric car_rental_rented_cars is missing"))
+	}
+}
+
+func TestAPIDeleteCustomer(test *testing.T) {
+	client := &http.Client{}
+	expectedCodes := []struct {
+		customerID int
+		code       int
+	}{
+		{testCustomer.CustomerID, http.StatusConflict},
+		{youngCustomer.CustomerID, http.StatusOK},
+		{youngCustomer.CustomerID, http.StatusNotFound},
+	}
+	for _, expected := range expectedCodes {
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/api/customers/%d", restPort, expected.customerID), nil)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to delete customer"))
+			test.FailNow()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to delete customer"))
+			test.FailNow()
+		}
+		if resp.StatusCode != expected.code {
+			test.Error(fmt.Errorf("Status is incorrect for customer %d. Received %d, want %d", expected.customerID, resp.StatusCode, expected.code))
+			test.FailNow()
+		}
+	}
+}
+
+func TestAPIDeleteBranch(test *testing.T) {
+	client := &http.Client{}
+	expectedCodes := map[int]int{
+		testBranches[0].BranchID: http.StatusConflict,
+		testBranches[1].BranchID: http.StatusOK,
+	}
+	for branchID, expectedCode := range expectedCodes {
+		req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("http://localhost:%d/api/branches/%d", restPort, branchID), nil)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to delete branch"))
+			test.FailNow()
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			test.Error(errors.Wrap(err, "Faled to delete branch"))
+			test.FailNow()
+		}
+		if resp.StatusCode != expectedCode {
+			test.Error(fmt.Errorf("Status is incorrect for branch %d. Received %d, want %d", branchID, resp.StatusCode, expectedCode))
+			test.FailNow()
+		}
 	}
 	ShutDown()
 }
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"car-rental/internal/server/cars"
+	"car-rental/internal/server/cmds"
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/db"
+	"car-rental/internal/server/db/migrate"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/storage/postgres"
+	"car-rental/internal/server/storage/sqlite"
+	"database/sql"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+Opens storage backend selected by DSN
+*/
+func openRepositories(cfg config.DBConfig) (storage.Repositories, error) {
+	switch cfg.Driver() {
+	case config.PostgresDriver:
+		internalDB, err := postgres.Open(cfg.DSN)
+		if err != nil {
+			return storage.Repositories{}, err
+		}
+		return postgres.NewRepositories(internalDB), nil
+	default:
+		dbStruct, err := db.NewDBStruct(cfg)
+		if err != nil {
+			return storage.Repositories{}, err
+		}
+		return sqlite.NewRepositories(dbStruct), nil
+	}
+}
+
+/*
+Opens DB selected by DSN without applying migrations
+*/
+func openMigrationRunner(cfg config.DBConfig) (*migrate.Runner, *sql.DB, error) {
+	switch cfg.Driver() {
+	case config.PostgresDriver:
+		internalDB, err := sql.Open("postgres", cfg.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		runner, err := postgres.NewMigrationRunner(internalDB)
+		return runner, internalDB, err
+	default:
+		internalDB, err := db.Open(cfg.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		runner, err := db.NewMigrationRunner(internalDB)
+		return runner, internalDB, err
+	}
+}
+
+/*
+Branches of cities where generated cars are located
+*/
+var defaultBranches = []domain.Branch{
+	{Name: "Jerusalem", Latitude: 31.7683, Longitude: 35.2137},
+	{Name: "Tel Aviv", Latitude: 32.0853, Longitude: 34.7818},
+	{Name: "Haifa", Latitude: 32.7940, Longitude: 34.9896},
+	{Name: "Ashdod", Latitude: 31.8014, Longitude: 34.6435},
+	{Name: "Rishon LeZiyyon", Latitude: 31.9730, Longitude: 34.7925},
+	{Name: "Petah Tikva", Latitude: 32.0840, Longitude: 34.8878},
+	{Name: "Beersheba", Latitude: 31.2520, Longitude: 34.7915},
+	{Name: "Netanya", Latitude: 32.3215, Longitude: 34.8532},
+	{Name: "Holon", Latitude: 32.0158, Longitude: 34.7874},
+	{Name: "Bnei Brak", Latitude: 32.0807, Longitude: 34.8338},
+	{Name: "Rehovot", Latitude: 31.8928, Longitude: 34.8113},
+	{Name: "Bat Yam", Latitude: 32.0132, Longitude: 34.7480},
+}
+
+/*
+Creates default branches when storage has no branches, branches kept by admin are not touched
+*/
+func seedBranches(repos storage.Repositories) error {
+	branchProcessor := cmds.NewBranchProcessor(repos)
+	branches, err := branchProcessor.GetBranchesFromDB()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get branches from DB")
+	}
+	if len(branches) > 0 {
+		log.Info("Branches exist, default branches are not created")
+		return nil
+	}
+	for _, branch := range defaultBranches {
+		branch.Timezone = "Asia/Jerusalem"
+		if _, err := branchProcessor.InsertBranchInDB(branch); err != nil {
+			return errors.Wrapf(err, "Failed to insert branch %s into DB", branch.Name)
+		}
+	}
+	log.Info("Default branches created sussesfully")
+	return nil
+}
+
+/*
+Fills storage with random cars
+*/
+func prefillCars(repos storage.Repositories) error {
+	carProcessor := cmds.NewCarProcessor(repos)
+	log.Info("Generating Cars data")
+	numberOfCars := rand.Intn(50)
+	for i := 0; i < numberOfCars; i++ {
+		mockCar := cars.GenerateNewCar(time.Now().UTC().UnixNano())
+		if _, err := carProcessor.InsertCarInDB(mockCar); err != nil {
+			return errors.Wrap(err, "Failed to insert cars into DB")
+		}
+	}
+	log.Info("Cars data generated sussesfully")
+	return nil
+}
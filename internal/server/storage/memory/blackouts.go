@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+type BlackoutRepository struct {
+	store *store
+}
+
+func (repo *BlackoutRepository) InsertBlackout(blackout domain.Blackout) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.cars[blackout.CarID]; !ok {
+		return 0, errors.Wrapf(storage.ErrNotFound, "Car %d", blackout.CarID)
+	}
+	repo.store.lastBlackoutID++
+	blackout.BlackoutID = repo.store.lastBlackoutID
+	repo.store.blackouts[blackout.BlackoutID] = blackout
+	return int64(blackout.BlackoutID), nil
+}
+
+func (repo *BlackoutRepository) GetBlackouts() ([]domain.Blackout, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.Blackout
+	for _, blackout := range repo.store.blackouts {
+		result = append(result, blackout)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CarID != result[j].CarID {
+			return result[i].CarID < result[j].CarID
+		}
+		if result[i].FromDate != result[j].FromDate {
+			return result[i].FromDate < result[j].FromDate
+		}
+		return result[i].BlackoutID < result[j].BlackoutID
+	})
+	return result, nil
+}
+
+func (repo *BlackoutRepository) GetCarBlackouts(carID int) ([]domain.Blackout, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	return repo.store.carBlackouts(carID), nil
+}
+
+func (repo *BlackoutRepository) GetBlackout(blackoutID int) (*domain.Blackout, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	blackout, ok := repo.store.blackouts[blackoutID]
+	if !ok {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Blackout %d", blackoutID)
+	}
+	return &blackout, nil
+}
+
+func (repo *BlackoutRepository) UpdateBlackout(blackout domain.Blackout, blackoutID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	stored, ok := repo.store.blackouts[blackoutID]
+	if !ok {
+		return 0, nil
+	}
+	blackout.BlackoutID = blackoutID
+	blackout.CarID = stored.CarID
+	blackout.ToLocation = stored.ToLocation
+	blackout.ToBranchID = stored.ToBranchID
+	repo.store.blackouts[blackoutID] = blackout
+	return 1, nil
+}
+
+func (repo *BlackoutRepository) RemoveBlackout(blackoutID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.blackouts[blackoutID]; !ok {
+		return 0, nil
+	}
+	delete(repo.store.blackouts, blackoutID)
+	return 1, nil
+}
+
+/*
+Blackouts of the car ordered by from date, caller holds the lock
+*/
+func (data *store) carBlackouts(carID int) []domain.Blackout {
+	var result []domain.Blackout
+	for _, blackout := range data.blackouts {
+		if blackout.CarID == carID {
+			result = append(result, blackout)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].FromDate != result[j].FromDate {
+			return result[i].FromDate < result[j].FromDate
+		}
+		return result[i].BlackoutID < result[j].BlackoutID
+	})
+	return result
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+
+	"github.com/pkg/errors"
+)
+
+type BranchRepository struct {
+	store *store
+}
+
+func (repo *BranchRepository) InsertBranch(branch domain.Branch) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if err := repo.store.checkUniqueName(branch.Name, 0); err != nil {
+		return 0, err
+	}
+	repo.store.lastBranchID++
+	branch.BranchID = repo.store.lastBranchID
+	repo.store.branches[branch.BranchID] = copyBranch(branch)
+	return int64(branch.BranchID), nil
+}
+
+func (repo *BranchRepository) GetBranches() ([]domain.Branch, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.Branch
+	for _, branchID := range sortedBranchIDs(repo.store.branches) {
+		result = append(result, copyBranch(repo.store.branches[branchID]))
+	}
+	return result, nil
+}
+
+func (repo *BranchRepository) GetBranch(branchID int) (*domain.Branch, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	branch, ok := repo.store.branches[branchID]
+	if !ok {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Branch %d", branchID)
+	}
+	branch = copyBranch(branch)
+	return &branch, nil
+}
+
+func (repo *BranchRepository) GetBranchByName(name string) (*domain.Branch, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	for _, branch := range repo.store.branches {
+		if branch.Name == name {
+			branch = copyBranch(branch)
+			return &branch, nil
+		}
+	}
+	return nil, errors.Wrapf(storage.ErrNotFound, "Branch %s", name)
+}
+
+func (repo *BranchRepository) UpdateBranch(branch domain.Branch, branchID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.branches[branchID]; !ok {
+		return 0, nil
+	}
+	if err := repo.store.checkUniqueName(branch.Name, branchID); err != nil {
+		return 0, err
+	}
+	branch.BranchID = branchID
+	repo.store.branches[branchID] = copyBranch(branch)
+	return 1, nil
+}
+
+func (repo *BranchRepository) RemoveBranch(branchID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.branches[branchID]; !ok {
+		return 0, nil
+	}
+	for _, rent := range repo.store.rents {
+		if rent.BranchID == branchID || rent.ReturnBranchID == branchID {
+			return 0, errors.Wrapf(storage.ErrInUse, "Branch %d has rents", branchID)
+		}
+	}
+	for _, blackout := range repo.store.blackouts {
+		if blackout.ToBranchID == branchID {
+			return 0, errors.Wrapf(storage.ErrInUse, "Branch %d has transfers", branchID)
+		}
+	}
+	for carID, car := range repo.store.cars {
+		var branchIDs []int
+		for _, carBranchID := range car.BranchIDs {
+			if carBranchID != branchID {
+				branchIDs = append(branchIDs, carBranchID)
+			}
+		}
+		car.BranchIDs = branchIDs
+		repo.store.cars[carID] = car
+	}
+	delete(repo.store.branches, branchID)
+	return 1, nil
+}
+
+func (data *store) checkUniqueName(name string, branchID int) error {
+	for _, branch := range data.branches {
+		if branch.Name == name && branch.BranchID != branchID {
+			return errors.Errorf("Branch with name %s already exists", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type CarRepository struct {
+	store *store
+}
+
+func (repo *CarRepository) InsertCar(car domain.Car) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if err := repo.store.checkBranches(car.BranchIDs); err != nil {
+		return 0, err
+	}
+	repo.store.lastCarID++
+	car.CarID = repo.store.lastCarID
+	car.RetiredAt = ""
+	repo.store.cars[car.CarID] = copyCar(car)
+	return int64(car.CarID), nil
+}
+
+func (repo *CarRepository) GetCars() ([]domain.Car, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.Car
+	for _, carID := range sortedCarIDs(repo.store.cars) {
+		if car := repo.store.cars[carID]; len(car.RetiredAt) == 0 {
+			result = append(result, copyCar(car))
+		}
+	}
+	return result, nil
+}
+
+func (repo *CarRepository) GetCar(carID int) (*domain.Car, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	car, ok := repo.store.cars[carID]
+	if !ok {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Car %d", carID)
+	}
+	car = copyCar(car)
+	return &car, nil
+}
+
+/*
+Same semantics as SQLite search: every car is joined with each of its rents, car is left out when any rent conflicts with filter dates
+*/
+func (repo *CarRepository) SearchCars(filter storage.CarFilter) ([]domain.CombinedRentInfo, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.CombinedRentInfo
+	for _, carID := range sortedCarIDs(repo.store.cars) {
+		car := repo.store.cars[carID]
+		if len(car.RetiredAt) > 0 || !repo.store.matchesCarFilter(car, filter) || repo.store.blackedOut(carID, filter.Window()) {
+			continue
+		}
+		var carRents []domain.RentInfo
+		for _, rentID := range sortedRentIDs(repo.store.rents) {
+			if rent := repo.store.rents[rentID]; rent.CarID == carID {
+				carRents = append(carRents, rent)
+			}
+		}
+		if window := filter.Window(); window != nil && rentsConflict(carRents, *window) {
+			continue
+		}
+		if len(carRents) == 0 {
+			result = append(result, domain.CombinedRentInfo{Car: copyCar(car)})
+			continue
+		}
+		for _, rent := range carRents {
+			result = append(result, domain.CombinedRentInfo{
+				Car:             copyCar(car),
+				RentID:          int32(rent.RentID),
+				FromDate:        rent.FromDate,
+				ToDate:          rent.ToDate,
+				Location:        rent.Location,
+				AvailableExtras: copyStrings(rent.AvailableExtras),
+				Discounts:       copyStrings(rent.Discounts),
+				CarDetails:      rent.CarDetails,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (repo *CarRepository) UpdateCar(car domain.Car, carID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	stored, ok := repo.store.cars[carID]
+	if !ok {
+		return 0, nil
+	}
+	if err := repo.store.checkBranches(car.BranchIDs); err != nil {
+		return 0, err
+	}
+	car.CarID = carID
+	car.RetiredAt = stored.RetiredAt
+	repo.store.cars[carID] = copyCar(car)
+	return 1, nil
+}
+
+func (repo *CarRepository) RetireCar(carID int, retiredAt string) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	car, ok := repo.store.cars[carID]
+	if !ok || len(car.RetiredAt) > 0 {
+		return 0, nil
+	}
+	for _, rent := range repo.store.rents {
+		active := rent.Status == domain.ReservedRentStatus || rent.Status == domain.PickedUpRentStatus
+		if rent.CarID == carID && active && rent.ToDate > retiredAt {
+			return 0, errors.Wrapf(storage.ErrInUse, "Car %d has active rent %d", carID, rent.RentID)
+		}
+	}
+	car.RetiredAt = retiredAt
+	repo.store.cars[carID] = car
+	return 1, nil
+}
+
+func (repo *CarRepository) RemoveCar(carID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.cars[carID]; !ok {
+		return 0, nil
+	}
+	for _, rent := range repo.store.rents {
+		if rent.CarID == carID {
+			return 0, errors.Errorf("Car %d has rents and can not be removed", carID)
+		}
+	}
+	delete(repo.store.cars, carID)
+	for _, blackout := range repo.store.carBlackouts(carID) {
+		delete(repo.store.blackouts, blackout.BlackoutID)
+	}
+	return 1, nil
+}
+
+/*
+Blackout overlapping search dates hides the car the same way as rent does
+*/
+/*
+Any rent which holds the car conflicts with the window, cancelled rents are skipped
+*/
+func rentsConflict(carRents []domain.RentInfo, window availability.Window) bool {
+	for _, rent := range carRents {
+		if rent.Status == domain.CancelledRentStatus {
+			continue
+		}
+		if booked, ok := storedInterval(rent.FromDate, rent.ToDate); ok && window.Conflicts(booked) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Interval of stored dates, false when dates are broken
+*/
+func storedInterval(from string, to string) (availability.Interval, bool) {
+	fromTime, err := time.Parse(domain.TimeLayout, from)
+	if err != nil {
+		return availability.Interval{}, false
+	}
+	toTime, err := time.Parse(domain.TimeLayout, to)
+	if err != nil {
+		return availability.Interval{}, false
+	}
+	return availability.Interval{From: fromTime, To: toTime}, true
+}
+
+/*
+Any blackout of the car conflicts with the window, blackouts are checked by the same rules as rents
+*/
+func (data *store) blackedOut(carID int, window *availability.Window) bool {
+	if window == nil {
+		return false
+	}
+	for _, blackout := range data.carBlackouts(carID) {
+		if blocked, ok := storedInterval(blackout.FromDate, blackout.ToDate); ok && window.Conflicts(blocked) {
+			return true
+		}
+	}
+	return false
+}
+
+func (data *store) matchesCarFilter(car domain.Car, filter storage.CarFilter) bool {
+	if len(filter.Locations) > 0 {
+		found := false
+		for _, branchID := range car.BranchIDs {
+			for _, loc := range filter.Locations {
+				if data.branches[branchID].Name == loc {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.Age != nil {
+		if car.MinimumAge < filter.Age.Min || (filter.Age.Bounded && car.MinimumAge > filter.Age.Max) {
+			return false
+		}
+	}
+	if filter.CarGroup != nil && car.CarGroup != *filter.CarGroup {
+		return false
+	}
+	return true
+}
+
+func (data *store) checkBranches(branchIDs []int) error {
+	for _, branchID := range branchIDs {
+		if _, ok := data.branches[branchID]; !ok {
+			return errors.Wrapf(storage.ErrNotFound, "Branch %d", branchID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+
+	"github.com/pkg/errors"
+)
+
+type CustomerRepository struct {
+	store *store
+}
+
+func (repo *CustomerRepository) InsertCustomer(customer domain.Customer) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if err := repo.store.checkUniqueLicence(customer, 0); err != nil {
+		return 0, err
+	}
+	repo.store.lastCustomerID++
+	customer.CustomerID = repo.store.lastCustomerID
+	repo.store.customers[customer.CustomerID] = customer
+	return int64(customer.CustomerID), nil
+}
+
+func (repo *CustomerRepository) GetCustomers() ([]domain.Customer, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.Customer
+	for customerID := 1; customerID <= repo.store.lastCustomerID; customerID++ {
+		if customer, ok := repo.store.customers[customerID]; ok {
+			result = append(result, customer)
+		}
+	}
+	return result, nil
+}
+
+func (repo *CustomerRepository) GetCustomer(customerID int) (*domain.Customer, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	customer, ok := repo.store.customers[customerID]
+	if !ok {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Customer %d", customerID)
+	}
+	return &customer, nil
+}
+
+func (repo *CustomerRepository) GetCustomerByLicence(country string, number string) (*domain.Customer, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	for _, customer := range repo.store.customers {
+		if customer.LicenceCountry == country && customer.LicenceNumber == number {
+			return &customer, nil
+		}
+	}
+	return nil, errors.Wrapf(storage.ErrNotFound, "Customer with licence %s %s", country, number)
+}
+
+func (repo *CustomerRepository) UpdateCustomer(customer domain.Customer, customerID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.customers[customerID]; !ok {
+		return 0, nil
+	}
+	if err := repo.store.checkUniqueLicence(customer, customerID); err != nil {
+		return 0, err
+	}
+	customer.CustomerID = customerID
+	repo.store.customers[customerID] = customer
+	return 1, nil
+}
+
+func (repo *CustomerRepository) RemoveCustomer(customerID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.customers[customerID]; !ok {
+		return 0, nil
+	}
+	for _, rent := range repo.store.rents {
+		if rent.CustomerID == customerID {
+			return 0, errors.Wrapf(storage.ErrInUse, "Customer %d has rents", customerID)
+		}
+	}
+	delete(repo.store.customers, customerID)
+	return 1, nil
+}
+
+func (data *store) checkUniqueLicence(customer domain.Customer, customerID int) error {
+	for _, stored := range data.customers {
+		if stored.LicenceCountry == customer.LicenceCountry && stored.LicenceNumber == customer.LicenceNumber && stored.CustomerID != customerID {
+			return errors.Errorf("Customer with licence %s %s already exists", customer.LicenceCountry, customer.LicenceNumber)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"sort"
+	"sync"
+)
+
+/*
+Data shared by in-memory repositories, plays role of the DB
+*/
+type store struct {
+	mutex          sync.RWMutex
+	cars           map[int]domain.Car
+	rents          map[int]domain.RentInfo
+	branches       map[int]domain.Branch
+	blackouts      map[int]domain.Blackout
+	customers      map[int]domain.Customer
+	transitions    []domain.RentTransition
+	reassignments  []domain.Reassignment
+	lastCarID      int
+	lastRentID     int
+	lastBranchID   int
+	lastBlackoutID int
+	lastCustomerID int
+	// Transitions and reassignments are numbered across all rents
+	lastTransitionID   int
+	lastReassignmentID int
+}
+
+/*
+Creates in-memory repositories sharing one empty store
+*/
+func NewRepositories() storage.Repositories {
+	data := &store{
+		cars:      make(map[int]domain.Car),
+		rents:     make(map[int]domain.RentInfo),
+		branches:  make(map[int]domain.Branch),
+		blackouts: make(map[int]domain.Blackout),
+		customers: make(map[int]domain.Customer),
+	}
+	return storage.Repositories{
+		Cars:      &CarRepository{store: data},
+		Rents:     &RentRepository{store: data},
+		Branches:  &BranchRepository{store: data},
+		Blackouts: &BlackoutRepository{store: data},
+		Customers: &CustomerRepository{store: data},
+	}
+}
+
+func sortedCarIDs(cars map[int]domain.Car) []int {
+	var keys []int
+	for key := range cars {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func sortedRentIDs(rents map[int]domain.RentInfo) []int {
+	var keys []int
+	for key := range rents {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func sortedBranchIDs(branches map[int]domain.Branch) []int {
+	var keys []int
+	for key := range branches {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func copyCar(car domain.Car) domain.Car {
+	car.AvailableLocations = copyStrings(car.AvailableLocations)
+	if len(car.BranchIDs) == 0 {
+		car.BranchIDs = nil
+	} else {
+		car.BranchIDs = append([]int{}, car.BranchIDs...)
+		sort.Ints(car.BranchIDs)
+	}
+	return car
+}
+
+func copyBranch(branch domain.Branch) domain.Branch {
+	if len(branch.OpeningHours) == 0 {
+		branch.OpeningHours = nil
+	} else {
+		branch.OpeningHours = append([]domain.OpeningHours{}, branch.OpeningHours...)
+	}
+	return branch
+}
+
+func copyRent(rent domain.RentInfo) domain.RentInfo {
+	rent.AvailableExtras = copyStrings(rent.AvailableExtras)
+	rent.Discounts = copyStrings(rent.Discounts)
+	if rent.Quote != nil {
+		quote := *rent.Quote
+		quote.Items = append([]domain.PriceItem{}, quote.Items...)
+		rent.Quote = &quote
+	}
+	if rent.Cancellation != nil {
+		cancellation := *rent.Cancellation
+		rent.Cancellation = &cancellation
+	}
+	return rent
+}
+
+func copyTransition(transition domain.RentTransition) domain.RentTransition {
+	transition.Odometer = copyInt(transition.Odometer)
+	transition.FuelLevel = copyInt(transition.FuelLevel)
+	return transition
+}
+
+func copyInt(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	result := *value
+	return &result
+}
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
@@ -0,0 +1,13 @@
+package memory
+
+import (
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/storage/storagetest"
+	"testing"
+)
+
+func TestRepositories(test *testing.T) {
+	storagetest.RunRepositoryTests(test, func(test *testing.T) storage.Repositories {
+		return NewRepositories()
+	})
+}
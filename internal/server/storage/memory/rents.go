@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+
+	"github.com/pkg/errors"
+)
+
+type RentRepository struct {
+	store *store
+}
+
+func (repo *RentRepository) InsertRent(rent domain.RentInfo, check storage.RentCheck) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.cars[rent.CarID]; !ok {
+		return 0, errors.Wrapf(storage.ErrNotFound, "Car %d", rent.CarID)
+	}
+	if err := repo.store.checkRent(rent.CarID, 0, check); err != nil {
+		return 0, err
+	}
+	repo.store.lastRentID++
+	rent.RentID = repo.store.lastRentID
+	rent.Status = domain.ReservedRentStatus
+	repo.store.rents[rent.RentID] = copyRent(rent)
+	return int64(rent.RentID), nil
+}
+
+func (repo *RentRepository) GetRents() ([]domain.RentInfo, error) {
+	return repo.filterRents(func(domain.RentInfo) bool { return true }), nil
+}
+
+func (repo *RentRepository) GetCarRents(carID int) ([]domain.RentInfo, error) {
+	return repo.filterRents(func(rent domain.RentInfo) bool { return rent.CarID == carID }), nil
+}
+
+func (repo *RentRepository) GetRent(rentID int) (*domain.RentInfo, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	rent, ok := repo.store.rents[rentID]
+	if !ok {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Rent %d", rentID)
+	}
+	rent = copyRent(rent)
+	return &rent, nil
+}
+
+func (repo *RentRepository) UpdateRent(rent domain.RentInfo, rentID int, check storage.RentCheck) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	stored, ok := repo.store.rents[rentID]
+	if !ok || (stored.Status != domain.ReservedRentStatus && stored.Status != domain.PickedUpRentStatus) {
+		return 0, nil
+	}
+	if _, ok := repo.store.cars[rent.CarID]; !ok {
+		return 0, errors.Wrapf(storage.ErrNotFound, "Car %d", rent.CarID)
+	}
+	if err := repo.store.checkRent(rent.CarID, rentID, check); err != nil {
+		return 0, err
+	}
+	rent.RentID = rentID
+	rent.Status = stored.Status
+	repo.store.rents[rentID] = copyRent(rent)
+	return 1, nil
+}
+
+func (repo *RentRepository) RemoveRent(rentID int) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	if _, ok := repo.store.rents[rentID]; !ok {
+		return 0, nil
+	}
+	delete(repo.store.rents, rentID)
+	var transitions []domain.RentTransition
+	for _, transition := range repo.store.transitions {
+		if transition.RentID != rentID {
+			transitions = append(transitions, transition)
+		}
+	}
+	repo.store.transitions = transitions
+	var reassignments []domain.Reassignment
+	for _, reassignment := range repo.store.reassignments {
+		if reassignment.RentID != rentID {
+			reassignments = append(reassignments, reassignment)
+		}
+	}
+	repo.store.reassignments = reassignments
+	return 1, nil
+}
+
+func (repo *RentRepository) TransitRent(transition domain.RentTransition, cancellation *domain.Cancellation) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	rent, ok := repo.store.rents[transition.RentID]
+	if !ok || rent.Status != transition.FromStatus {
+		return 0, nil
+	}
+	rent.Status = transition.ToStatus
+	rent.Cancellation = cancellation
+	repo.store.rents[transition.RentID] = copyRent(rent)
+	repo.store.lastTransitionID++
+	transition.TransitionID = repo.store.lastTransitionID
+	repo.store.transitions = append(repo.store.transitions, copyTransition(transition))
+	return int64(transition.TransitionID), nil
+}
+
+func (repo *RentRepository) GetRentTransitions(rentID int) ([]domain.RentTransition, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.RentTransition
+	for _, transition := range repo.store.transitions {
+		if transition.RentID == rentID {
+			result = append(result, copyTransition(transition))
+		}
+	}
+	return result, nil
+}
+
+func (repo *RentRepository) ReassignRent(reassignment domain.Reassignment, carDetails string, check func(carRents []domain.RentInfo) error) (int64, error) {
+	repo.store.mutex.Lock()
+	defer repo.store.mutex.Unlock()
+	rent, ok := repo.store.rents[reassignment.RentID]
+	if !ok || rent.CarID != reassignment.FromCarID || rent.Status != domain.ReservedRentStatus {
+		return 0, nil
+	}
+	if _, ok := repo.store.cars[reassignment.ToCarID]; !ok {
+		return 0, errors.Wrapf(storage.ErrNotFound, "Car %d", reassignment.ToCarID)
+	}
+	var carRents []domain.RentInfo
+	for _, otherRentID := range sortedRentIDs(repo.store.rents) {
+		if otherRent := repo.store.rents[otherRentID]; otherRent.CarID == reassignment.ToCarID {
+			carRents = append(carRents, copyRent(otherRent))
+		}
+	}
+	if err := check(carRents); err != nil {
+		return 0, err
+	}
+	rent.CarID = reassignment.ToCarID
+	rent.CarDetails = carDetails
+	repo.store.rents[rent.RentID] = rent
+	repo.store.lastReassignmentID++
+	reassignment.ReassignmentID = repo.store.lastReassignmentID
+	repo.store.reassignments = append(repo.store.reassignments, reassignment)
+	return int64(reassignment.ReassignmentID), nil
+}
+
+func (repo *RentRepository) GetRentReassignments(rentID int) ([]domain.Reassignment, error) {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.Reassignment
+	for _, reassignment := range repo.store.reassignments {
+		if reassignment.RentID == rentID {
+			result = append(result, reassignment)
+		}
+	}
+	return result, nil
+}
+
+func (repo *RentRepository) filterRents(keep func(domain.RentInfo) bool) []domain.RentInfo {
+	repo.store.mutex.RLock()
+	defer repo.store.mutex.RUnlock()
+	var result []domain.RentInfo
+	for _, rentID := range sortedRentIDs(repo.store.rents) {
+		if rent := repo.store.rents[rentID]; keep(rent) {
+			result = append(result, copyRent(rent))
+		}
+	}
+	return result
+}
+
+/*
+Pass rents other than rentID and blackouts of car to check, caller holds the lock
+*/
+func (data *store) checkRent(carID int, rentID int, check storage.RentCheck) error {
+	if len(data.cars[carID].RetiredAt) > 0 {
+		return errors.Wrapf(storage.ErrRetired, "Car %d", carID)
+	}
+	if check == nil {
+		return nil
+	}
+	var carRents []domain.RentInfo
+	for _, otherRentID := range sortedRentIDs(data.rents) {
+		if otherRent := data.rents[otherRentID]; otherRent.CarID == carID && otherRentID != rentID {
+			carRents = append(carRents, copyRent(otherRent))
+		}
+	}
+	return check(carRents, data.carBlackouts(carID))
+}
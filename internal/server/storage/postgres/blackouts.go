@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+type BlackoutRepository struct {
+	internalDB *sql.DB
+}
+
+func NewBlackoutRepository(internalDB *sql.DB) *BlackoutRepository {
+	return &BlackoutRepository{internalDB: internalDB}
+}
+
+/*
+Insert blackout into DB
+*/
+func (repo *BlackoutRepository) InsertBlackout(blackout domain.Blackout) (int64, error) {
+	var id int64
+	err := repo.internalDB.QueryRow(InsertIntoBlackoutTable,
+		blackout.CarID,
+		blackout.FromDate,
+		blackout.ToDate,
+		blackout.Reason,
+		blackout.Description,
+		blackout.ToLocation,
+		sql.NullInt64{Int64: int64(blackout.ToBranchID), Valid: blackout.ToBranchID != 0}).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to insert blackout")
+	}
+	return id, nil
+}
+
+/*
+Get blackouts of all cars from DB
+*/
+func (repo *BlackoutRepository) GetBlackouts() ([]domain.Blackout, error) {
+	return repo.selectBlackouts(SelectBlackouts + " ORDER BY car_id, from_time, blackout_id")
+}
+
+/*
+Get blackouts of car from DB
+*/
+func (repo *BlackoutRepository) GetCarBlackouts(carID int) ([]domain.Blackout, error) {
+	return repo.selectBlackouts(SelectBlackouts+" WHERE car_id=$1 ORDER BY from_time, blackout_id", carID)
+}
+
+func (repo *BlackoutRepository) selectBlackouts(selectQuery string, args ...interface{}) ([]domain.Blackout, error) {
+	rows, err := repo.internalDB.Query(selectQuery, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	return collectBlackouts(rows)
+}
+
+func collectBlackouts(rows *sql.Rows) ([]domain.Blackout, error) {
+	defer rows.Close()
+	var result []domain.Blackout
+	for rows.Next() {
+		receivedRow, err := scanBlackout(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to scan blackout")
+		}
+		result = append(result, receivedRow)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to read blackouts")
+	}
+	return result, nil
+}
+
+/*
+Get blackout from DB upon blackout ID
+*/
+func (repo *BlackoutRepository) GetBlackout(blackoutID int) (*domain.Blackout, error) {
+	receivedRow, err := scanBlackout(repo.internalDB.QueryRow(SelectBlackouts+" WHERE blackout_id=$1", blackoutID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Blackout %d", blackoutID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query blackout")
+	}
+	return &receivedRow, nil
+}
+
+/*
+Update blackout in DB
+*/
+func (repo *BlackoutRepository) UpdateBlackout(blackout domain.Blackout, blackoutID int) (int64, error) {
+	res, err := repo.internalDB.Exec(UpdateBlackout,
+		blackout.FromDate,
+		blackout.ToDate,
+		blackout.Reason,
+		blackout.Description,
+		blackoutID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute blackout update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	return affect, nil
+}
+
+/*
+Remove blackout from DB
+*/
+func (repo *BlackoutRepository) RemoveBlackout(blackoutID int) (int64, error) {
+	res, err := repo.internalDB.Exec(RemoveBlackout, blackoutID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute blackout delete")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	return affect, nil
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type BranchRepository struct {
+	internalDB *sql.DB
+}
+
+func NewBranchRepository(internalDB *sql.DB) *BranchRepository {
+	return &BranchRepository{internalDB: internalDB}
+}
+
+/*
+Insert branch into DB
+*/
+func (repo *BranchRepository) InsertBranch(branch domain.Branch) (int64, error) {
+	openingHours, err := marshalOpeningHours(branch.OpeningHours)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = repo.internalDB.QueryRow(InsertIntoBranchTable,
+		branch.Name,
+		branch.Address,
+		branch.Latitude,
+		branch.Longitude,
+		branch.Timezone,
+		openingHours).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to insert branch")
+	}
+	return id, nil
+}
+
+/*
+Get branches from DB
+*/
+func (repo *BranchRepository) GetBranches() ([]domain.Branch, error) {
+	rows, err := repo.internalDB.Query(SelectBranches + " ORDER BY branch_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+
+	var result []domain.Branch
+	for rows.Next() {
+		receivedRow, err := scanBranch(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Get branch from DB upon branch ID
+*/
+func (repo *BranchRepository) GetBranch(branchID int) (*domain.Branch, error) {
+	return repo.getBranch(SelectBranches+" WHERE branch_id=$1", branchID)
+}
+
+/*
+Get branch from DB upon branch name
+*/
+func (repo *BranchRepository) GetBranchByName(name string) (*domain.Branch, error) {
+	return repo.getBranch(SelectBranches+" WHERE name=$1", name)
+}
+
+/*
+Update branch in DB
+*/
+func (repo *BranchRepository) UpdateBranch(branch domain.Branch, branchID int) (int64, error) {
+	openingHours, err := marshalOpeningHours(branch.OpeningHours)
+	if err != nil {
+		return 0, err
+	}
+	res, err := repo.internalDB.Exec(UpdateBranch,
+		branch.Name,
+		branch.Address,
+		branch.Latitude,
+		branch.Longitude,
+		branch.Timezone,
+		openingHours,
+		branchID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute branch update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	return affect, nil
+}
+
+/*
+Remove branch from DB, branch with rents is protected by foreign key
+*/
+func (repo *BranchRepository) RemoveBranch(branchID int) (int64, error) {
+	res, err := repo.internalDB.Exec(RemoveBranch, branchID)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to execute branch delete")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	return affect, nil
+}
+
+func (repo *BranchRepository) getBranch(selectQuery string, arg interface{}) (*domain.Branch, error) {
+	receivedRow, err := scanBranch(repo.internalDB.QueryRow(selectQuery, arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Branch %v", arg)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query branch")
+	}
+	return &receivedRow, nil
+}
+
+func marshalOpeningHours(openingHours []domain.OpeningHours) (string, error) {
+	if openingHours == nil {
+		openingHours = []domain.OpeningHours{}
+	}
+	encoded, err := json.Marshal(openingHours)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to encode opening hours")
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,316 @@
+package postgres
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/db/query"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type CarRepository struct {
+	internalDB *sql.DB
+}
+
+func NewCarRepository(internalDB *sql.DB) *CarRepository {
+	return &CarRepository{internalDB: internalDB}
+}
+
+/*
+Insert car into DB
+*/
+func (repo *CarRepository) InsertCar(car domain.Car) (int64, error) {
+	tx, err := repo.internalDB.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	var id int64
+	err = tx.QueryRow(InsertIntoCarTable,
+		car.CarCompanyName,
+		car.Doors,
+		car.BigLuggage,
+		car.SmallLuggage,
+		car.AdultPlaces,
+		car.AirConditioner,
+		car.MinimumAge,
+		pq.Array(car.AvailableLocations),
+		car.CarGroup,
+		car.Description,
+		car.Price).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to insert car")
+	}
+	if err := insertCarBranches(tx, int(id), car.BranchIDs); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return id, nil
+}
+
+/*
+Get cars from DB
+*/
+func (repo *CarRepository) GetCars() ([]domain.Car, error) {
+	rows, err := repo.internalDB.Query(SelectCars + " WHERE retired_at IS NULL ORDER BY car_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+
+	var result []domain.Car
+	for rows.Next() {
+		receivedRow, err := scanCar(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	carBranches, err := repo.selectCarBranches(query.Condition{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range result {
+		result[index].BranchIDs = carBranches[result[index].CarID]
+	}
+	return result, nil
+}
+
+/*
+Get filtered cars joined with their rents from DB
+*/
+func (repo *CarRepository) SearchCars(filter storage.CarFilter) ([]domain.CombinedRentInfo, error) {
+	searchQuery, args := buildCarSearchQuery(filter).Build()
+	searchQuery = query.Rebind(searchQuery)
+	log.Debugln(searchQuery, args)
+	rows, err := repo.internalDB.Query(searchQuery, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+
+	var result []domain.CombinedRentInfo
+	for rows.Next() {
+		receivedRow, err := scanCombinedRentInfo(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	carBranches, err := repo.selectCarBranches(query.Condition{})
+	if err != nil {
+		return nil, err
+	}
+	for index := range result {
+		result[index].BranchIDs = carBranches[result[index].CarID]
+	}
+	return result, nil
+}
+
+/*
+Get car from DB upon car ID
+*/
+func (repo *CarRepository) GetCar(carID int) (*domain.Car, error) {
+	receivedRow, err := scanCar(repo.internalDB.QueryRow(SelectCars+" WHERE car_id=$1", carID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Car %d", carID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query car")
+	}
+	carBranches, err := repo.selectCarBranches(query.Cond("car_id=?", carID))
+	if err != nil {
+		return nil, err
+	}
+	receivedRow.BranchIDs = carBranches[carID]
+	return &receivedRow, nil
+}
+
+/*
+Update car in DB
+*/
+func (repo *CarRepository) UpdateCar(car domain.Car, carID int) (int64, error) {
+	tx, err := repo.internalDB.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(UpdateCar,
+		car.CarCompanyName,
+		car.Doors,
+		car.BigLuggage,
+		car.SmallLuggage,
+		car.AdultPlaces,
+		car.AirConditioner,
+		car.MinimumAge,
+		pq.Array(car.AvailableLocations),
+		car.CarGroup,
+		car.Description,
+		car.Price,
+		carID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute car update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if affect > 0 {
+		if _, err := tx.Exec(RemoveCarBranches, carID); err != nil {
+			return 0, errors.Wrap(err, "Failed to remove car branches")
+		}
+		if err := insertCarBranches(tx, carID, car.BranchIDs); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return affect, nil
+}
+
+/*
+Mark car as retired, retired car stays in DB together with its rents.
+Car row is locked while active rents are counted, so rent can not be written for the car meanwhile
+*/
+func (repo *CarRepository) RetireCar(carID int, retiredAt string) (int64, error) {
+	tx, err := repo.internalDB.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	var storedRetiredAt sql.NullTime
+	err = tx.QueryRow(LockCar, carID).Scan(&storedRetiredAt)
+	if errors.Is(err, sql.ErrNoRows) || storedRetiredAt.Valid {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to lock car")
+	}
+	var rents int
+	if err := tx.QueryRow(CountActiveCarRents, carID, domain.ReservedRentStatus, domain.PickedUpRentStatus, retiredAt).Scan(&rents); err != nil {
+		return 0, errors.Wrap(err, "Failed to count car rents")
+	}
+	if rents > 0 {
+		return 0, errors.Wrapf(storage.ErrInUse, "Car %d has %d active rents", carID, rents)
+	}
+	res, err := tx.Exec(RetireCar, retiredAt, carID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute car retirement")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return affect, nil
+}
+
+/*
+Remove car from DB
+*/
+func (repo *CarRepository) RemoveCar(carID int) (int64, error) {
+	res, err := repo.internalDB.Exec(RemoveCar, carID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute car delete")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	return affect, nil
+}
+
+/*
+Select car to branch relations as map of car ID to branch IDs
+*/
+func (repo *CarRepository) selectCarBranches(condition query.Condition) (map[int][]int, error) {
+	selectQuery, args := query.NewBuilder(SelectCarBranches).Where(condition).Suffix("ORDER BY car_id, branch_id").Build()
+	rows, err := repo.internalDB.Query(query.Rebind(selectQuery), args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query car branches")
+	}
+	defer rows.Close()
+	result := make(map[int][]int)
+	for rows.Next() {
+		var carID, branchID int
+		if err := rows.Scan(&carID, &branchID); err != nil {
+			return nil, errors.Wrap(err, "Failed to scan car branch")
+		}
+		result[carID] = append(result[carID], branchID)
+	}
+	return result, nil
+}
+
+func insertCarBranches(tx *sql.Tx, carID int, branchIDs []int) error {
+	for _, branchID := range branchIDs {
+		if _, err := tx.Exec(InsertIntoCarBranchTable, carID, branchID); err != nil {
+			return errors.Wrapf(err, "Failed to link car %d to branch %d", carID, branchID)
+		}
+	}
+	return nil
+}
+
+/*
+Build search query, locations are matched against names of car branches
+*/
+func buildCarSearchQuery(filter storage.CarFilter) *query.Builder {
+	builder := query.NewBuilder(SelectCarsRents).Where(query.Cond("retired_at IS NULL")).Suffix("ORDER BY car_id, rent_id")
+	if window := filter.Window(); window != nil {
+		builder.Where(buildFromToFilter(*window))
+	}
+	if len(filter.Locations) > 0 {
+		builder.Where(query.Cond("car_id IN ("+SelectCarIDsByBranchName+" WHERE name = ANY(?::text[]))", pq.Array(filter.Locations)))
+	}
+	if filter.Age != nil {
+		if filter.Age.Bounded {
+			builder.Where(query.Cond("min_age between ? and ?", filter.Age.Min, filter.Age.Max))
+		} else {
+			builder.Where(query.Cond("min_age>=?", filter.Age.Min))
+		}
+	}
+	if filter.CarGroup != nil {
+		builder.Where(query.Cond("car_group=?", *filter.CarGroup))
+	}
+	return builder
+}
+
+/*
+Build time frame for rents search, car is left out when any of its rents or blackouts conflicts with the window.
+Cancelled rents do not hold the car and blackouts hold it the same way as rents
+*/
+func buildFromToFilter(window availability.Window) query.Condition {
+	rentConflict := conflictCondition("r", window)
+	blackoutConflict := conflictCondition("b", window)
+	return query.And(
+		query.Cond("NOT EXISTS (SELECT 1 FROM rents r WHERE r.car_id = cars.car_id AND r.status<>? AND "+rentConflict.Clause+")",
+			append([]interface{}{domain.CancelledRentStatus}, rentConflict.Args...)...),
+		query.Cond("NOT EXISTS (SELECT 1 FROM car_blackouts b WHERE b.car_id = cars.car_id AND "+blackoutConflict.Clause+")", blackoutConflict.Args...),
+	)
+}
+
+/*
+Stored interval of the table alias conflicts with the window
+*/
+func conflictCondition(alias string, window availability.Window) query.Condition {
+	conditions := []query.Condition{
+		query.Cond("("+alias+".from_time<? AND "+alias+".to_time>?)", window.Overlap.To, window.Overlap.From),
+	}
+	if window.ReturnDay != nil {
+		conditions = append(conditions, query.Cond("("+alias+".to_time>=? AND "+alias+".to_time<?)", window.ReturnDay.From, window.ReturnDay.To))
+	}
+	if window.PickupDay != nil {
+		conditions = append(conditions, query.Cond("("+alias+".from_time>=? AND "+alias+".from_time<?)", window.PickupDay.From, window.PickupDay.To))
+	}
+	return query.Or(conditions...)
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type CustomerRepository struct {
+	internalDB *sql.DB
+}
+
+func NewCustomerRepository(internalDB *sql.DB) *CustomerRepository {
+	return &CustomerRepository{internalDB: internalDB}
+}
+
+/*
+Insert customer into DB
+*/
+func (repo *CustomerRepository) InsertCustomer(customer domain.Customer) (int64, error) {
+	var id int64
+	err := repo.internalDB.QueryRow(InsertIntoCustomerTable,
+		customer.Name,
+		customer.Email,
+		customer.Phone,
+		customer.DateOfBirth,
+		customer.LicenceNumber,
+		customer.LicenceCountry,
+		customer.LicenceExpiry,
+		sql.NullString{String: customer.LicenceIssued, Valid: len(customer.LicenceIssued) > 0},
+		pq.Array(licenceCategories(customer))).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to insert customer")
+	}
+	return id, nil
+}
+
+/*
+Get customers from DB
+*/
+func (repo *CustomerRepository) GetCustomers() ([]domain.Customer, error) {
+	rows, err := repo.internalDB.Query(SelectCustomers + " ORDER BY customer_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+
+	var result []domain.Customer
+	for rows.Next() {
+		receivedRow, err := scanCustomer(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Get customer from DB upon customer ID
+*/
+func (repo *CustomerRepository) GetCustomer(customerID int) (*domain.Customer, error) {
+	return repo.getCustomer(SelectCustomers+" WHERE customer_id=$1", customerID)
+}
+
+/*
+Get customer from DB upon driver licence
+*/
+func (repo *CustomerRepository) GetCustomerByLicence(country string, number string) (*domain.Customer, error) {
+	return repo.getCustomer(SelectCustomers+" WHERE licence_country=$1 AND licence_number=$2", country, number)
+}
+
+/*
+Update customer in DB
+*/
+func (repo *CustomerRepository) UpdateCustomer(customer domain.Customer, customerID int) (int64, error) {
+	res, err := repo.internalDB.Exec(UpdateCustomer,
+		customer.Name,
+		customer.Email,
+		customer.Phone,
+		customer.DateOfBirth,
+		customer.LicenceNumber,
+		customer.LicenceCountry,
+		customer.LicenceExpiry,
+		sql.NullString{String: customer.LicenceIssued, Valid: len(customer.LicenceIssued) > 0},
+		pq.Array(licenceCategories(customer)),
+		customerID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute customer update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	return affect, nil
+}
+
+/*
+Remove customer from DB, customer with rents is protected by foreign key
+*/
+func (repo *CustomerRepository) RemoveCustomer(customerID int) (int64, error) {
+	res, err := repo.internalDB.Exec(RemoveCustomer, customerID)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to execute customer delete")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	return affect, nil
+}
+
+func (repo *CustomerRepository) getCustomer(selectQuery string, args ...interface{}) (*domain.Customer, error) {
+	receivedRow, err := scanCustomer(repo.internalDB.QueryRow(selectQuery, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Customer %v", args)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query customer")
+	}
+	return &receivedRow, nil
+}
+
+/*
+Column is not nullable, customer without categories is stored with empty array
+*/
+func licenceCategories(customer domain.Customer) []string {
+	if customer.LicenceCategories == nil {
+		return []string{}
+	}
+	return customer.LicenceCategories
+}
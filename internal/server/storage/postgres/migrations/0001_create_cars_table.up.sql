@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS cars(car_id SERIAL PRIMARY KEY,
+					car_comp_name TEXT,
+					doors INTEGER,
+					big_lag INTEGER,
+					small_lag INTEGER,
+					adult_place INTEGER,
+					condition BOOLEAN,
+					min_age INTEGER,
+					locations TEXT[],
+					car_group INTEGER,
+					description TEXT,
+					price INTEGER);
+CREATE INDEX IF NOT EXISTS cars_locations_idx ON cars USING gin (locations);
@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+CREATE TABLE IF NOT EXISTS rents(rent_id SERIAL PRIMARY KEY,
+					car_id INTEGER NOT NULL REFERENCES cars(car_id) ON DELETE RESTRICT,
+					from_time TIMESTAMPTZ NOT NULL,
+					to_time TIMESTAMPTZ NOT NULL,
+					location TEXT,
+					extras TEXT[],
+					discounts TEXT[],
+					rent_detail TEXT,
+					CONSTRAINT rents_dates_check CHECK (from_time < to_time),
+					CONSTRAINT rents_no_overlap EXCLUDE USING gist (car_id WITH =, tstzrange(from_time, to_time, '[)') WITH &&)
+					);
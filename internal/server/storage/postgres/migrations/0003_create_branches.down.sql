@@ -0,0 +1,3 @@
+ALTER TABLE rents DROP COLUMN branch_id;
+DROP TABLE car_branches;
+DROP TABLE branches;
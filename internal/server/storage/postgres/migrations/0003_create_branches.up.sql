@@ -0,0 +1,23 @@
+CREATE TABLE branches(branch_id SERIAL PRIMARY KEY,
+					name TEXT NOT NULL UNIQUE,
+					address TEXT,
+					latitude DOUBLE PRECISION,
+					longitude DOUBLE PRECISION,
+					timezone TEXT,
+					opening_hours JSONB);
+CREATE TABLE car_branches(car_id INTEGER NOT NULL REFERENCES cars(car_id) ON DELETE CASCADE,
+					branch_id INTEGER NOT NULL REFERENCES branches(branch_id) ON DELETE CASCADE,
+					PRIMARY KEY(car_id, branch_id)
+					);
+ALTER TABLE rents ADD COLUMN branch_id INTEGER REFERENCES branches(branch_id) ON DELETE RESTRICT;
+-- Branches are created only for names already used by cars and rents, their details are filled by admin
+INSERT INTO branches(name, latitude, longitude)
+	SELECT name, 0, 0 FROM (
+		SELECT unnest(locations) AS name FROM cars
+		UNION
+		SELECT location FROM rents) AS used_names
+	WHERE name <> '';
+INSERT INTO car_branches(car_id, branch_id)
+	SELECT cars.car_id, branches.branch_id FROM cars
+	JOIN branches ON branches.name = ANY(cars.locations);
+UPDATE rents SET branch_id = branches.branch_id FROM branches WHERE branches.name = rents.location;
@@ -0,0 +1,2 @@
+ALTER TABLE rents ADD COLUMN driver_age_group TEXT NOT NULL DEFAULT '';
+ALTER TABLE rents ADD COLUMN requested_car_group INTEGER NOT NULL DEFAULT 0;
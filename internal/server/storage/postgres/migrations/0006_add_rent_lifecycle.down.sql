@@ -0,0 +1,4 @@
+DROP TABLE rent_transitions;
+ALTER TABLE rents DROP CONSTRAINT rents_no_overlap;
+ALTER TABLE rents ADD CONSTRAINT rents_no_overlap EXCLUDE USING gist (car_id WITH =, tstzrange(from_time, to_time, '[)') WITH &&);
+ALTER TABLE rents DROP COLUMN status;
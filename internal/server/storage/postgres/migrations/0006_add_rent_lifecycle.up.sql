@@ -0,0 +1,15 @@
+ALTER TABLE rents ADD COLUMN status TEXT NOT NULL DEFAULT 'reserved';
+-- Cancelled rent keeps its dates, but does not hold the car
+ALTER TABLE rents DROP CONSTRAINT rents_no_overlap;
+ALTER TABLE rents ADD CONSTRAINT rents_no_overlap EXCLUDE USING gist (car_id WITH =, tstzrange(from_time, to_time, '[)') WITH &&)
+	WHERE (status <> 'cancelled');
+CREATE TABLE rent_transitions(transition_id SERIAL PRIMARY KEY,
+					rent_id INTEGER NOT NULL REFERENCES rents(rent_id) ON DELETE CASCADE,
+					from_status TEXT NOT NULL,
+					to_status TEXT NOT NULL,
+					odometer INTEGER,
+					fuel_level INTEGER,
+					reason TEXT,
+					created_at TIMESTAMPTZ NOT NULL
+					);
+CREATE INDEX rent_transitions_rent_id ON rent_transitions(rent_id);
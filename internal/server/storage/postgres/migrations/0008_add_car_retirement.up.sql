@@ -0,0 +1 @@
+ALTER TABLE cars ADD COLUMN retired_at TIMESTAMPTZ;
@@ -0,0 +1 @@
+DROP TABLE rent_reassignments;
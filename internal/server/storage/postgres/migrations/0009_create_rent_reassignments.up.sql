@@ -0,0 +1,9 @@
+CREATE TABLE rent_reassignments(reassignment_id SERIAL PRIMARY KEY,
+					rent_id INTEGER NOT NULL REFERENCES rents(rent_id) ON DELETE CASCADE,
+					from_car_id INTEGER NOT NULL,
+					to_car_id INTEGER NOT NULL,
+					kind TEXT NOT NULL,
+					reason TEXT,
+					created_at TIMESTAMPTZ NOT NULL
+					);
+CREATE INDEX rent_reassignments_rent_id ON rent_reassignments(rent_id);
@@ -0,0 +1,9 @@
+CREATE TABLE car_blackouts(blackout_id SERIAL PRIMARY KEY,
+					car_id INTEGER NOT NULL REFERENCES cars(car_id) ON DELETE CASCADE,
+					from_time TIMESTAMPTZ NOT NULL,
+					to_time TIMESTAMPTZ NOT NULL,
+					reason TEXT NOT NULL,
+					description TEXT,
+					CONSTRAINT car_blackouts_dates_check CHECK (from_time < to_time)
+					);
+CREATE INDEX car_blackouts_car_id ON car_blackouts(car_id);
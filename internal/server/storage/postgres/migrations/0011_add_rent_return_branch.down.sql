@@ -0,0 +1,2 @@
+ALTER TABLE rents DROP COLUMN return_branch_id;
+ALTER TABLE rents DROP COLUMN return_location;
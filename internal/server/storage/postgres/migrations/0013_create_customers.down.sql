@@ -0,0 +1,3 @@
+DROP INDEX rents_customer_id;
+ALTER TABLE rents DROP COLUMN customer_id;
+DROP TABLE customers;
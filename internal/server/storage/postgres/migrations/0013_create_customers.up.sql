@@ -0,0 +1,13 @@
+CREATE TABLE customers(customer_id SERIAL PRIMARY KEY,
+					name TEXT NOT NULL,
+					email TEXT NOT NULL,
+					phone TEXT,
+					date_of_birth DATE NOT NULL,
+					licence_number TEXT NOT NULL,
+					licence_country TEXT NOT NULL,
+					licence_expiry DATE NOT NULL,
+					CONSTRAINT customers_licence_unique UNIQUE (licence_country, licence_number)
+					);
+-- Rents booked before customers were introduced have no customer
+ALTER TABLE rents ADD COLUMN customer_id INTEGER REFERENCES customers(customer_id) ON DELETE RESTRICT;
+CREATE INDEX rents_customer_id ON rents(customer_id);
@@ -0,0 +1,2 @@
+ALTER TABLE customers DROP COLUMN licence_categories;
+ALTER TABLE customers DROP COLUMN licence_issued;
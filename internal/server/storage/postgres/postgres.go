@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"car-rental/internal/server/db/instrument"
+	"car-rental/internal/server/db/migrate"
+	"car-rental/internal/server/db/query"
+	"car-rental/internal/server/storage"
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+PostgreSQL driver which reports query durations to metrics
+*/
+const instrumentedDriver = "postgres-instrumented"
+
+const (
+	exclusionViolation  = "23P01"
+	foreignKeyViolation = "23503"
+)
+
+/*
+PostgreSQL dialect of service schema
+*/
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+func init() {
+	instrument.Register(instrumentedDriver, &pq.Driver{}, statementNames)
+}
+
+/*
+Opens PostgreSQL DB and applies migrations
+*/
+func Open(dsn string) (*sql.DB, error) {
+	internalDB, err := sql.Open(instrumentedDriver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := internalDB.Ping(); err != nil {
+		internalDB.Close()
+		return nil, errors.Wrap(err, "Failed to connect to PostgreSQL")
+	}
+	runner, err := NewMigrationRunner(internalDB)
+	if err != nil {
+		internalDB.Close()
+		return nil, errors.Wrap(err, "Failed to prepare migrations")
+	}
+	applied, err := runner.Up()
+	if err != nil {
+		internalDB.Close()
+		return nil, errors.Wrap(err, "Failed to migrate DB")
+	}
+	log.Infof("DB schema is up to date, %d migrations applied", applied)
+	return internalDB, nil
+}
+
+/*
+Creates migration runner with PostgreSQL migrations
+*/
+func NewMigrationRunner(internalDB *sql.DB) (*migrate.Runner, error) {
+	source, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewRunnerWithRebind(internalDB, source, query.Rebind)
+}
+
+/*
+Creates PostgreSQL repositories sharing one DB
+*/
+func NewRepositories(internalDB *sql.DB) storage.Repositories {
+	return storage.Repositories{
+		Cars:      NewCarRepository(internalDB),
+		Rents:     NewRentRepository(internalDB),
+		Branches:  NewBranchRepository(internalDB),
+		Blackouts: NewBlackoutRepository(internalDB),
+		Customers: NewCustomerRepository(internalDB),
+	}
+}
+
+/*
+Translates PostgreSQL specific errors into storage errors
+*/
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case exclusionViolation:
+		return errors.Wrap(storage.ErrOverlap, pqErr.Message)
+	case foreignKeyViolation:
+		return errors.Wrap(storage.ErrInUse, pqErr.Message)
+	}
+	return err
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"car-rental/internal/server/db/instrument"
+	"car-rental/internal/server/db/query"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/storage/storagetest"
+	"database/sql"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+DSN of local PostgreSQL used by integration tests, for example postgres://postgres@localhost:5432/rental_test?sslmode=disable
+*/
+const testDSNEnv = "CAR_RENTAL_TEST_POSTGRES_DSN"
+
+/*
+Set in CI, so missing PostgreSQL fails the tests instead of skipping them
+*/
+const testRequiredEnv = "CAR_RENTAL_TEST_POSTGRES_REQUIRED"
+
+/*
+Opens test DB with clean schema, test is skipped when PostgreSQL is not configured and not required
+*/
+func openTestDB(test *testing.T) *sql.DB {
+	dsn, ok := os.LookupEnv(testDSNEnv)
+	if !ok || len(dsn) == 0 {
+		if required, _ := strconv.ParseBool(os.Getenv(testRequiredEnv)); required {
+			test.Fatalf("%s is set, but %s is not", testRequiredEnv, testDSNEnv)
+		}
+		test.Skipf("%s is not set", testDSNEnv)
+	}
+	internalDB, err := Open(dsn)
+	require.NoError(test, err)
+	runner, err := NewMigrationRunner(internalDB)
+	require.NoError(test, err)
+	_, err = runner.Down(len(migrationFiles(test)))
+	require.NoError(test, err)
+	_, err = runner.Up()
+	require.NoError(test, err)
+	test.Cleanup(func() { internalDB.Close() })
+	return internalDB
+}
+
+func migrationFiles(test *testing.T) []string {
+	entries, err := migrationsFS.ReadDir("migrations")
+	require.NoError(test, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestBuildCarSearchQuery(test *testing.T) {
+	carGroup := 2
+	searchQuery, args := buildCarSearchQuery(storage.CarFilter{
+		Locations: []string{"Holon", "Haifa"},
+		Age:       &query.IntRange{Min: 30, Max: 45, Bounded: true},
+		CarGroup:  &carGroup,
+	}).Build()
+	searchQuery = query.Rebind(searchQuery)
+	assert.Contains(test, searchQuery, "WHERE (retired_at IS NULL AND car_id IN (SELECT car_id FROM car_branches JOIN branches using (branch_id) WHERE name = ANY($1::text[])) AND min_age between $2 and $3 AND car_group=$4) ORDER BY car_id, rent_id")
+	assert.Len(test, args, 4)
+	assert.NotContains(test, searchQuery, "like")
+}
+
+func TestBuildCarSearchQueryWithDates(test *testing.T) {
+	dates := query.DateRange{From: time.Date(2022, 1, 15, 10, 0, 0, 0, time.UTC), To: time.Date(2022, 1, 16, 10, 0, 0, 0, time.UTC)}
+	searchQuery, args := buildCarSearchQuery(storage.CarFilter{Dates: &dates}).Build()
+	searchQuery = query.Rebind(searchQuery)
+	assert.Contains(test, searchQuery, "NOT EXISTS (SELECT 1 FROM rents r WHERE r.car_id = cars.car_id AND r.status<>$1 AND ((r.from_time<$2 AND r.to_time>$3)))")
+	assert.Contains(test, searchQuery, "AND NOT EXISTS (SELECT 1 FROM car_blackouts b WHERE b.car_id = cars.car_id AND ((b.from_time<$4 AND b.to_time>$5)))")
+	assert.Equal(test, []interface{}{domain.CancelledRentStatus, dates.To, dates.From, dates.To, dates.From}, args)
+}
+
+/*
+Test that PostgreSQL queries are timed per statement of sql.go
+*/
+func TestStatementNames(test *testing.T) {
+	assert.Equal(test, "SelectRents", instrument.StatementName(statementNames, SelectRents+" WHERE car_id=$1 ORDER BY rent_id"))
+	assert.Equal(test, "LockCar", instrument.StatementName(statementNames, LockCar))
+	assert.Equal(test, instrument.OtherStatement, instrument.StatementName(statementNames, "SELECT version()"))
+}
+
+func TestRepositories(test *testing.T) {
+	storagetest.RunRepositoryTests(test, func(test *testing.T) storage.Repositories {
+		return NewRepositories(openTestDB(test))
+	})
+}
+
+/*
+Test that exclusion constraint rejects overlapping rents of the same car even without availability check
+*/
+func TestRentOverlapConstraint(test *testing.T) {
+	repos := NewRepositories(openTestDB(test))
+	carID, err := repos.Cars.InsertCar(storagetest.TestCar)
+	require.NoError(test, err)
+	rent := storagetest.TestRent
+	rent.CarID = int(carID)
+	_, err = repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+
+	overlapping := rent
+	overlapping.FromDate = "2022-01-15T20:00:00Z"
+	overlapping.ToDate = "2022-01-17T10:00:00Z"
+	_, err = repos.Rents.InsertRent(overlapping, nil)
+	assert.True(test, errors.Is(err, storage.ErrOverlap))
+
+	adjacent := rent
+	adjacent.FromDate = rent.ToDate
+	adjacent.ToDate = "2022-01-17T10:00:00Z"
+	_, err = repos.Rents.InsertRent(adjacent, nil)
+	assert.NoError(test, err)
+}
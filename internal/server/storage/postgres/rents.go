@@ -0,0 +1,392 @@
+package postgres
+
+import (
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type RentRepository struct {
+	internalDB *sql.DB
+}
+
+func NewRentRepository(internalDB *sql.DB) *RentRepository {
+	return &RentRepository{internalDB: internalDB}
+}
+
+/*
+Insert rent into DB, car row is locked while its rents and blackouts are checked in the same transaction.
+Overlapping rent of the same car is still rejected by exclusion constraint
+*/
+func (repo *RentRepository) InsertRent(rent domain.RentInfo, check storage.RentCheck) (int64, error) {
+	quote, err := marshalQuote(rent.Quote)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := repo.internalDB.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	if err := checkRent(tx, rent.CarID, 0, check); err != nil {
+		return 0, err
+	}
+	var id int64
+	err = tx.QueryRow(InsertIntoRentTable,
+		rent.CarID,
+		rent.FromDate,
+		rent.ToDate,
+		rent.Location,
+		pq.Array(rent.AvailableExtras),
+		pq.Array(rent.Discounts),
+		rent.CarDetails,
+		sql.NullInt64{Int64: int64(rent.BranchID), Valid: rent.BranchID != 0},
+		quote,
+		rent.AgeGroup,
+		rent.CarGroup,
+		rent.ReturnLocation,
+		sql.NullInt64{Int64: int64(rent.ReturnBranchID), Valid: rent.ReturnBranchID != 0},
+		sql.NullInt64{Int64: int64(rent.CustomerID), Valid: rent.CustomerID != 0},
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to insert rent")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to commit a transaction")
+	}
+	return id, nil
+}
+
+/*
+Get rents from DB
+*/
+func (repo *RentRepository) GetRents() ([]domain.RentInfo, error) {
+	rows, err := repo.internalDB.Query(SelectRents + " ORDER BY rent_id")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	return collectRents(rows)
+}
+
+/*
+Get rents of one car from DB
+*/
+func (repo *RentRepository) GetCarRents(carID int) ([]domain.RentInfo, error) {
+	rows, err := repo.internalDB.Query(SelectRents+" WHERE car_id=$1 ORDER BY rent_id", carID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	return collectRents(rows)
+}
+
+/*
+Get rent from DB
+*/
+func (repo *RentRepository) GetRent(rentID int) (*domain.RentInfo, error) {
+	receivedRow, err := scanRent(repo.internalDB.QueryRow(SelectRents+" WHERE rent_id=$1", rentID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Rent %d", rentID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query rent")
+	}
+	return &receivedRow, nil
+}
+
+/*
+Update rent in DB, car row is locked while its other rents and blackouts are checked in the same transaction.
+Overlapping rent is still rejected by exclusion constraint. Only reserved or picked up rent is updated
+*/
+func (repo *RentRepository) UpdateRent(rent domain.RentInfo, rentID int, check storage.RentCheck) (int64, error) {
+	quote, err := marshalQuote(rent.Quote)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := repo.internalDB.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	if err := checkRent(tx, rent.CarID, rentID, check); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(UpdateRent,
+		rent.CarID,
+		rent.FromDate,
+		rent.ToDate,
+		rent.Location,
+		pq.Array(rent.AvailableExtras),
+		pq.Array(rent.Discounts),
+		rent.CarDetails,
+		sql.NullInt64{Int64: int64(rent.BranchID), Valid: rent.BranchID != 0},
+		quote,
+		rent.AgeGroup,
+		rent.CarGroup,
+		rent.ReturnLocation,
+		sql.NullInt64{Int64: int64(rent.ReturnBranchID), Valid: rent.ReturnBranchID != 0},
+		sql.NullInt64{Int64: int64(rent.CustomerID), Valid: rent.CustomerID != 0},
+		rentID,
+		domain.ReservedRentStatus,
+		domain.PickedUpRentStatus)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to update rent")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return affect, nil
+}
+
+/*
+Remove rent from DB
+*/
+func (repo *RentRepository) RemoveRent(rentID int) (int64, error) {
+	res, err := repo.internalDB.Exec(RemoveRent, rentID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute rent delete")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	return affect, nil
+}
+
+/*
+Change rent status and record transition in one transaction, transition is not recorded when rent status was changed before
+*/
+func (repo *RentRepository) TransitRent(transition domain.RentTransition, cancellation *domain.Cancellation) (int64, error) {
+	encodedCancellation, err := marshalCancellation(cancellation)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := repo.internalDB.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(UpdateRentStatus, transition.ToStatus, encodedCancellation, transition.RentID, transition.FromStatus)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to update rent status")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if affect == 0 {
+		return 0, nil
+	}
+	var id int64
+	err = tx.QueryRow(InsertIntoRentTransitionTable,
+		transition.RentID,
+		transition.FromStatus,
+		transition.ToStatus,
+		nullableInt(transition.Odometer),
+		nullableInt(transition.FuelLevel),
+		transition.Reason,
+		transition.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to insert rent transition")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return id, nil
+}
+
+/*
+Get transitions of rent from DB
+*/
+func (repo *RentRepository) GetRentTransitions(rentID int) ([]domain.RentTransition, error) {
+	rows, err := repo.internalDB.Query(SelectRentTransitions+" WHERE rent_id=$1 ORDER BY transition_id", rentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+	var result []domain.RentTransition
+	for rows.Next() {
+		receivedRow, err := scanRentTransition(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Move reserved rent to another car and record reassignment in one transaction
+*/
+func (repo *RentRepository) ReassignRent(reassignment domain.Reassignment, carDetails string, check func(carRents []domain.RentInfo) error) (int64, error) {
+	tx, err := repo.internalDB.Begin()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(SelectRents+" WHERE car_id=$1 AND rent_id<>$2 ORDER BY rent_id FOR UPDATE", reassignment.ToCarID, reassignment.RentID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	carRents, err := collectRents(rows)
+	if err != nil {
+		return 0, err
+	}
+	if err := check(carRents); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(ReassignRent,
+		reassignment.ToCarID,
+		carDetails,
+		reassignment.RentID,
+		reassignment.FromCarID,
+		domain.ReservedRentStatus)
+	if err != nil {
+		return 0, errors.Wrap(translateError(err), "Failed to reassign rent")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if affect == 0 {
+		return 0, nil
+	}
+	var id int64
+	err = tx.QueryRow(InsertIntoRentReassignmentTable,
+		reassignment.RentID,
+		reassignment.FromCarID,
+		reassignment.ToCarID,
+		reassignment.Kind,
+		reassignment.Reason,
+		reassignment.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to insert rent reassignment")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return id, nil
+}
+
+/*
+Get reassignments of rent from DB
+*/
+func (repo *RentRepository) GetRentReassignments(rentID int) ([]domain.Reassignment, error) {
+	rows, err := repo.internalDB.Query(SelectRentReassignments+" WHERE rent_id=$1 ORDER BY reassignment_id", rentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+	var result []domain.Reassignment
+	for rows.Next() {
+		receivedRow, err := scanReassignment(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Lock car row, check that car is not retired and pass rents other than rentID and blackouts of the car to check
+*/
+func checkRent(tx *sql.Tx, carID int, rentID int, check storage.RentCheck) error {
+	var retiredAt sql.NullTime
+	err := tx.QueryRow(LockCar, carID).Scan(&retiredAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrapf(storage.ErrNotFound, "Car %d", carID)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to lock car")
+	}
+	if retiredAt.Valid {
+		return errors.Wrapf(storage.ErrRetired, "Car %d", carID)
+	}
+	if check == nil {
+		return nil
+	}
+	rows, err := tx.Query(SelectRents+" WHERE car_id=$1 AND rent_id<>$2 ORDER BY rent_id", carID, rentID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to execute a sql query")
+	}
+	carRents, err := collectRents(rows)
+	if err != nil {
+		return err
+	}
+	rows, err = tx.Query(SelectBlackouts+" WHERE car_id=$1 ORDER BY from_time, blackout_id", carID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to execute a sql query")
+	}
+	blackouts, err := collectBlackouts(rows)
+	if err != nil {
+		return err
+	}
+	return check(carRents, blackouts)
+}
+
+func collectRents(rows *sql.Rows) ([]domain.RentInfo, error) {
+	defer rows.Close()
+	var result []domain.RentInfo
+	for rows.Next() {
+		receivedRow, err := scanRent(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to scan rent")
+		}
+		result = append(result, receivedRow)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to read rents")
+	}
+	return result, nil
+}
+
+/*
+Missing value is stored as NULL
+*/
+func nullableInt(value *int) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*value), Valid: true}
+}
+
+/*
+Cancellation is stored as JSONB, NULL means rent is not cancelled
+*/
+func marshalCancellation(cancellation *domain.Cancellation) (sql.NullString, error) {
+	if cancellation == nil {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(cancellation)
+	if err != nil {
+		return sql.NullString{}, errors.Wrap(err, "Failed to encode cancellation")
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+/*
+Quote is stored as JSONB, rent without quote is stored as NULL
+*/
+func marshalQuote(quote *domain.PriceQuote) (sql.NullString, error) {
+	if quote == nil {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(quote)
+	if err != nil {
+		return sql.NullString{}, errors.Wrap(err, "Failed to encode price quote")
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
@@ -0,0 +1,306 @@
+package postgres
+
+import (
+	"car-rental/internal/server/domain"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+/*
+Common part of sql.Row and sql.Rows
+*/
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+/*
+Scan row selected with SelectCars
+*/
+func scanCar(row scanner) (domain.Car, error) {
+	var receivedRow domain.Car
+	var retiredAt sql.NullTime
+	err := row.Scan(&receivedRow.CarID,
+		&receivedRow.CarCompanyName,
+		&receivedRow.Doors,
+		&receivedRow.BigLuggage,
+		&receivedRow.SmallLuggage,
+		&receivedRow.AdultPlaces,
+		&receivedRow.AirConditioner,
+		&receivedRow.MinimumAge,
+		pq.Array(&receivedRow.AvailableLocations),
+		&receivedRow.CarGroup,
+		&receivedRow.Description,
+		&receivedRow.Price,
+		&retiredAt)
+	if err != nil {
+		return receivedRow, err
+	}
+	if retiredAt.Valid {
+		receivedRow.RetiredAt = formatTime(retiredAt.Time)
+	}
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with SelectCarsRents, rent columns are empty for cars without rents
+*/
+func scanCombinedRentInfo(row scanner) (domain.CombinedRentInfo, error) {
+	var receivedRow domain.CombinedRentInfo
+	var rentID sql.NullInt32
+	var fromDate sql.NullTime
+	var toDate sql.NullTime
+	var location sql.NullString
+	var carDetails sql.NullString
+	err := row.Scan(&receivedRow.CarID,
+		&receivedRow.CarCompanyName,
+		&receivedRow.Doors,
+		&receivedRow.BigLuggage,
+		&receivedRow.SmallLuggage,
+		&receivedRow.AdultPlaces,
+		&receivedRow.AirConditioner,
+		&receivedRow.MinimumAge,
+		pq.Array(&receivedRow.AvailableLocations),
+		&receivedRow.CarGroup,
+		&receivedRow.Description,
+		&receivedRow.Price,
+		&rentID,
+		&fromDate,
+		&toDate,
+		&location,
+		pq.Array(&receivedRow.AvailableExtras),
+		pq.Array(&receivedRow.Discounts),
+		&carDetails,
+	)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.RentID = rentID.Int32
+	if fromDate.Valid {
+		receivedRow.FromDate = formatTime(fromDate.Time)
+	}
+	if toDate.Valid {
+		receivedRow.ToDate = formatTime(toDate.Time)
+	}
+	receivedRow.Location = location.String
+	receivedRow.CarDetails = carDetails.String
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with SelectRents
+*/
+func scanRent(row scanner) (domain.RentInfo, error) {
+	var receivedRow domain.RentInfo
+	var fromDate time.Time
+	var toDate time.Time
+	var location sql.NullString
+	var carDetails sql.NullString
+	var branchID sql.NullInt64
+	var quote []byte
+	var cancellation []byte
+	var returnLocation sql.NullString
+	var returnBranchID sql.NullInt64
+	var customerID sql.NullInt64
+	err := row.Scan(
+		&receivedRow.RentID,
+		&receivedRow.CarID,
+		&fromDate,
+		&toDate,
+		&location,
+		pq.Array(&receivedRow.AvailableExtras),
+		pq.Array(&receivedRow.Discounts),
+		&carDetails,
+		&branchID,
+		&quote,
+		&receivedRow.AgeGroup,
+		&receivedRow.CarGroup,
+		&receivedRow.Status,
+		&cancellation,
+		&returnLocation,
+		&returnBranchID,
+		&customerID,
+	)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.FromDate = formatTime(fromDate)
+	receivedRow.ToDate = formatTime(toDate)
+	receivedRow.Location = location.String
+	receivedRow.CarDetails = carDetails.String
+	receivedRow.BranchID = int(branchID.Int64)
+	receivedRow.ReturnLocation = returnLocation.String
+	receivedRow.ReturnBranchID = int(returnBranchID.Int64)
+	receivedRow.CustomerID = int(customerID.Int64)
+	if len(quote) > 0 {
+		receivedRow.Quote = &domain.PriceQuote{}
+		if err := json.Unmarshal(quote, receivedRow.Quote); err != nil {
+			return receivedRow, errors.Wrapf(err, "Failed to parse price quote of rent %d", receivedRow.RentID)
+		}
+	}
+	if len(cancellation) > 0 {
+		receivedRow.Cancellation = &domain.Cancellation{}
+		if err := json.Unmarshal(cancellation, receivedRow.Cancellation); err != nil {
+			return receivedRow, errors.Wrapf(err, "Failed to parse cancellation of rent %d", receivedRow.RentID)
+		}
+	}
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with SelectRentTransitions, car state is optional
+*/
+func scanRentTransition(row scanner) (domain.RentTransition, error) {
+	var receivedRow domain.RentTransition
+	var odometer sql.NullInt64
+	var fuelLevel sql.NullInt64
+	var reason sql.NullString
+	var createdAt time.Time
+	err := row.Scan(&receivedRow.TransitionID,
+		&receivedRow.RentID,
+		&receivedRow.FromStatus,
+		&receivedRow.ToStatus,
+		&odometer,
+		&fuelLevel,
+		&reason,
+		&createdAt)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Odometer = nullableIntValue(odometer)
+	receivedRow.FuelLevel = nullableIntValue(fuelLevel)
+	receivedRow.Reason = reason.String
+	receivedRow.CreatedAt = formatTime(createdAt)
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with SelectRentReassignments
+*/
+func scanReassignment(row scanner) (domain.Reassignment, error) {
+	var receivedRow domain.Reassignment
+	var reason sql.NullString
+	var createdAt time.Time
+	err := row.Scan(&receivedRow.ReassignmentID,
+		&receivedRow.RentID,
+		&receivedRow.FromCarID,
+		&receivedRow.ToCarID,
+		&receivedRow.Kind,
+		&reason,
+		&createdAt)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Reason = reason.String
+	receivedRow.CreatedAt = formatTime(createdAt)
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with SelectBlackouts
+*/
+func scanBlackout(row scanner) (domain.Blackout, error) {
+	var receivedRow domain.Blackout
+	var fromDate time.Time
+	var toDate time.Time
+	var description sql.NullString
+	var toLocation sql.NullString
+	var toBranchID sql.NullInt64
+	err := row.Scan(&receivedRow.BlackoutID,
+		&receivedRow.CarID,
+		&fromDate,
+		&toDate,
+		&receivedRow.Reason,
+		&description,
+		&toLocation,
+		&toBranchID)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.FromDate = formatTime(fromDate)
+	receivedRow.ToDate = formatTime(toDate)
+	receivedRow.Description = description.String
+	receivedRow.ToLocation = toLocation.String
+	receivedRow.ToBranchID = int(toBranchID.Int64)
+	return receivedRow, nil
+}
+
+func nullableIntValue(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	result := int(value.Int64)
+	return &result
+}
+
+/*
+Scan row selected with SelectBranches
+*/
+func scanBranch(row scanner) (domain.Branch, error) {
+	var receivedRow domain.Branch
+	var address sql.NullString
+	var timezone sql.NullString
+	var openingHours []byte
+	err := row.Scan(&receivedRow.BranchID,
+		&receivedRow.Name,
+		&address,
+		&receivedRow.Latitude,
+		&receivedRow.Longitude,
+		&timezone,
+		&openingHours)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Address = address.String
+	receivedRow.Timezone = timezone.String
+	if len(openingHours) > 0 {
+		if err := json.Unmarshal(openingHours, &receivedRow.OpeningHours); err != nil {
+			return receivedRow, errors.Wrapf(err, "Failed to parse opening hours of branch %d", receivedRow.BranchID)
+		}
+	}
+	if len(receivedRow.OpeningHours) == 0 {
+		receivedRow.OpeningHours = nil
+	}
+	return receivedRow, nil
+}
+
+func formatTime(value time.Time) string {
+	return value.UTC().Format(domain.TimeLayout)
+}
+
+/*
+Scan row selected with SelectCustomers, dates are kept in DATE columns
+*/
+func scanCustomer(row scanner) (domain.Customer, error) {
+	var receivedRow domain.Customer
+	var phone sql.NullString
+	var dateOfBirth time.Time
+	var licenceExpiry time.Time
+	var licenceIssued sql.NullTime
+	err := row.Scan(&receivedRow.CustomerID,
+		&receivedRow.Name,
+		&receivedRow.Email,
+		&phone,
+		&dateOfBirth,
+		&receivedRow.LicenceNumber,
+		&receivedRow.LicenceCountry,
+		&licenceExpiry,
+		&licenceIssued,
+		pq.Array(&receivedRow.LicenceCategories))
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Phone = phone.String
+	receivedRow.DateOfBirth = dateOfBirth.Format(domain.DateLayout)
+	receivedRow.LicenceExpiry = licenceExpiry.Format(domain.DateLayout)
+	if licenceIssued.Valid {
+		receivedRow.LicenceIssued = licenceIssued.Time.Format(domain.DateLayout)
+	}
+	if len(receivedRow.LicenceCategories) == 0 {
+		receivedRow.LicenceCategories = nil
+	}
+	return receivedRow, nil
+}
@@ -0,0 +1,285 @@
+package postgres
+
+var (
+	InsertIntoCarTable = `INSERT INTO cars(car_comp_name , doors,
+												big_lag, small_lag,
+												adult_place, condition,
+												min_age, locations,
+												car_group, description,price) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+												RETURNING car_id`
+	InsertIntoRentTable = `INSERT INTO rents(car_id,
+											from_time,
+											to_time,
+											location,
+											extras,
+											discounts,
+											rent_detail,
+											branch_id,
+											price_quote,
+											driver_age_group,
+											requested_car_group,
+											return_location,
+											return_branch_id,
+											customer_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+											RETURNING rent_id`
+	SelectCars = `SELECT car_id,
+					car_comp_name ,
+					doors,
+					big_lag,
+					small_lag,
+					adult_place,
+					condition,
+					min_age,
+					locations,
+					car_group,
+					description,
+					price,
+					retired_at FROM cars`
+	UpdateCar = `UPDATE cars 
+				SET car_comp_name = $1 ,
+				doors = $2 ,
+				big_lag = $3 ,
+				small_lag = $4 ,
+				adult_place = $5 ,
+				condition = $6 ,
+				min_age = $7 ,
+				locations = $8 ,
+				car_group = $9 ,
+				description = $10,
+				price = $11
+				WHERE car_id = $12`
+	RetireCar = `UPDATE cars
+				SET retired_at = $1
+				WHERE car_id = $2 AND retired_at IS NULL`
+	RemoveCar = `DELETE FROM cars 
+				WHERE car_id = $1`
+	LockCar = `SELECT retired_at FROM cars
+				WHERE car_id = $1 FOR UPDATE`
+	CountActiveCarRents = `SELECT count(*) FROM rents
+				WHERE car_id = $1 AND status IN ($2, $3) AND to_time > $4`
+	SelectRents = `SELECT rent_id,
+						car_id,
+						from_time,
+						to_time,
+						location,
+						extras,
+						discounts,
+						rent_detail,
+						branch_id,
+						price_quote,
+						driver_age_group,
+						requested_car_group,
+						status,
+						cancellation,
+						return_location,
+						return_branch_id,
+						customer_id
+						FROM rents`
+	UpdateRent = `UPDATE rents
+				SET car_id = $1 ,
+				from_time = $2 ,
+				to_time = $3 ,
+				location = $4 ,
+				extras = $5 ,
+				discounts = $6 ,
+				rent_detail = $7 ,
+				branch_id = $8 ,
+				price_quote = $9 ,
+				driver_age_group = $10 ,
+				requested_car_group = $11 ,
+				return_location = $12 ,
+				return_branch_id = $13 ,
+				customer_id = $14
+				WHERE rent_id = $15 AND status IN ($16, $17)`
+	UpdateRentStatus = `UPDATE rents
+				SET status = $1 ,
+				cancellation = $2
+				WHERE rent_id = $3 AND status = $4`
+	InsertIntoRentTransitionTable = `INSERT INTO rent_transitions(rent_id,
+											from_status,
+											to_status,
+											odometer,
+											fuel_level,
+											reason,
+											created_at) VALUES ($1,$2,$3,$4,$5,$6,$7)
+											RETURNING transition_id`
+	SelectRentTransitions = `SELECT transition_id,
+						rent_id,
+						from_status,
+						to_status,
+						odometer,
+						fuel_level,
+						reason,
+						created_at
+						FROM rent_transitions`
+	ReassignRent = `UPDATE rents
+				SET car_id = $1 ,
+				rent_detail = $2
+				WHERE rent_id = $3 AND car_id = $4 AND status = $5`
+	InsertIntoRentReassignmentTable = `INSERT INTO rent_reassignments(rent_id,
+											from_car_id,
+											to_car_id,
+											kind,
+											reason,
+											created_at) VALUES ($1,$2,$3,$4,$5,$6)
+											RETURNING reassignment_id`
+	SelectRentReassignments = `SELECT reassignment_id,
+						rent_id,
+						from_car_id,
+						to_car_id,
+						kind,
+						reason,
+						created_at
+						FROM rent_reassignments`
+	InsertIntoBlackoutTable = `INSERT INTO car_blackouts(car_id,
+											from_time,
+											to_time,
+											reason,
+											description,
+											to_location,
+											to_branch_id) VALUES ($1,$2,$3,$4,$5,$6,$7)
+											RETURNING blackout_id`
+	SelectBlackouts = `SELECT blackout_id,
+						car_id,
+						from_time,
+						to_time,
+						reason,
+						description,
+						to_location,
+						to_branch_id
+						FROM car_blackouts`
+	UpdateBlackout = `UPDATE car_blackouts
+				SET from_time = $1 ,
+				to_time = $2 ,
+				reason = $3 ,
+				description = $4
+				WHERE blackout_id = $5`
+	RemoveBlackout = `DELETE FROM car_blackouts
+				WHERE blackout_id = $1`
+	RemoveRent = `DELETE FROM rents 
+							  WHERE rent_id = $1`
+	SelectCarsRents = `SELECT car_id,
+								car_comp_name ,
+								doors,
+								big_lag,
+								small_lag,
+								adult_place,
+								condition,
+								min_age,
+								locations,
+								car_group,
+								description,
+								price,
+								rent_id,
+								from_time,
+								to_time,
+								location,
+								extras,
+								discounts,
+								rent_detail
+								FROM cars
+								LEFT JOIN rents using (car_id)`
+	InsertIntoCustomerTable = `INSERT INTO customers(name,
+											email,
+											phone,
+											date_of_birth,
+											licence_number,
+											licence_country,
+											licence_expiry,
+											licence_issued,
+											licence_categories) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+											RETURNING customer_id`
+	SelectCustomers = `SELECT customer_id,
+						name,
+						email,
+						phone,
+						date_of_birth,
+						licence_number,
+						licence_country,
+						licence_expiry,
+						licence_issued,
+						licence_categories
+						FROM customers`
+	UpdateCustomer = `UPDATE customers
+				SET name = $1 ,
+				email = $2 ,
+				phone = $3 ,
+				date_of_birth = $4 ,
+				licence_number = $5 ,
+				licence_country = $6 ,
+				licence_expiry = $7 ,
+				licence_issued = $8 ,
+				licence_categories = $9
+				WHERE customer_id = $10`
+	RemoveCustomer = `DELETE FROM customers
+				WHERE customer_id = $1`
+	InsertIntoBranchTable = `INSERT INTO branches(name,
+											address,
+											latitude,
+											longitude,
+											timezone,
+											opening_hours) VALUES ($1,$2,$3,$4,$5,$6)
+											RETURNING branch_id`
+	SelectBranches = `SELECT branch_id,
+						name,
+						address,
+						latitude,
+						longitude,
+						timezone,
+						opening_hours
+						FROM branches`
+	UpdateBranch = `UPDATE branches
+				SET name = $1 ,
+				address = $2 ,
+				latitude = $3 ,
+				longitude = $4 ,
+				timezone = $5 ,
+				opening_hours = $6
+				WHERE branch_id = $7`
+	RemoveBranch = `DELETE FROM branches
+				WHERE branch_id = $1`
+	InsertIntoCarBranchTable = `INSERT INTO car_branches(car_id, branch_id) VALUES ($1,$2)`
+	RemoveCarBranches        = `DELETE FROM car_branches WHERE car_id = $1`
+	SelectCarBranches        = `SELECT car_id, branch_id FROM car_branches`
+	SelectCarIDsByBranchName = `SELECT car_id FROM car_branches JOIN branches using (branch_id)`
+)
+
+/*
+Statements by name, DB query durations are reported per statement name
+*/
+var statementNames = map[string]string{
+	"InsertIntoCarTable":              InsertIntoCarTable,
+	"InsertIntoRentTable":             InsertIntoRentTable,
+	"SelectCars":                      SelectCars,
+	"UpdateCar":                       UpdateCar,
+	"RetireCar":                       RetireCar,
+	"RemoveCar":                       RemoveCar,
+	"LockCar":                         LockCar,
+	"CountActiveCarRents":             CountActiveCarRents,
+	"SelectRents":                     SelectRents,
+	"UpdateRent":                      UpdateRent,
+	"UpdateRentStatus":                UpdateRentStatus,
+	"InsertIntoRentTransitionTable":   InsertIntoRentTransitionTable,
+	"SelectRentTransitions":           SelectRentTransitions,
+	"ReassignRent":                    ReassignRent,
+	"InsertIntoRentReassignmentTable": InsertIntoRentReassignmentTable,
+	"SelectRentReassignments":         SelectRentReassignments,
+	"InsertIntoBlackoutTable":         InsertIntoBlackoutTable,
+	"SelectBlackouts":                 SelectBlackouts,
+	"UpdateBlackout":                  UpdateBlackout,
+	"RemoveBlackout":                  RemoveBlackout,
+	"RemoveRent":                      RemoveRent,
+	"SelectCarsRents":                 SelectCarsRents,
+	"InsertIntoCustomerTable":         InsertIntoCustomerTable,
+	"SelectCustomers":                 SelectCustomers,
+	"UpdateCustomer":                  UpdateCustomer,
+	"RemoveCustomer":                  RemoveCustomer,
+	"InsertIntoBranchTable":           InsertIntoBranchTable,
+	"SelectBranches":                  SelectBranches,
+	"UpdateBranch":                    UpdateBranch,
+	"RemoveBranch":                    RemoveBranch,
+	"InsertIntoCarBranchTable":        InsertIntoCarBranchTable,
+	"RemoveCarBranches":               RemoveCarBranches,
+	"SelectCarBranches":               SelectCarBranches,
+	"SelectCarIDsByBranchName":        SelectCarIDsByBranchName,
+}
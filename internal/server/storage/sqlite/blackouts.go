@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"car-rental/internal/server/db"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+type BlackoutRepository struct {
+	dbStruct *db.DBStruct
+}
+
+func NewBlackoutRepository(dbStruct *db.DBStruct) *BlackoutRepository {
+	return &BlackoutRepository{dbStruct: dbStruct}
+}
+
+/*
+Insert blackout into DB
+*/
+func (repo *BlackoutRepository) InsertBlackout(blackout domain.Blackout) (int64, error) {
+	res, err := repo.dbStruct.Exec(db.InsertIntoBlackoutTable,
+		blackout.CarID,
+		blackout.FromDate,
+		blackout.ToDate,
+		blackout.Reason,
+		blackout.Description,
+		blackout.ToLocation,
+		nullableID(blackout.ToBranchID))
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute blackout insert")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a extract last id")
+	}
+	return id, nil
+}
+
+/*
+Get blackouts of all cars from DB
+*/
+func (repo *BlackoutRepository) GetBlackouts() ([]domain.Blackout, error) {
+	return repo.selectBlackouts(fmt.Sprintf("%s ORDER BY car_id, from_time, blackout_id", db.SelectBlackouts))
+}
+
+/*
+Get blackouts of car from DB
+*/
+func (repo *BlackoutRepository) GetCarBlackouts(carID int) ([]domain.Blackout, error) {
+	return repo.selectBlackouts(fmt.Sprintf("%s WHERE car_id=? ORDER BY from_time, blackout_id", db.SelectBlackouts), carID)
+}
+
+func (repo *BlackoutRepository) selectBlackouts(selectQuery string, args ...interface{}) ([]domain.Blackout, error) {
+	rows, err := repo.dbStruct.Query(selectQuery, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	return collectBlackouts(rows)
+}
+
+func collectBlackouts(rows *sql.Rows) ([]domain.Blackout, error) {
+	defer rows.Close()
+	var result []domain.Blackout
+	for rows.Next() {
+		receivedRow, err := scanBlackout(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to scan blackout")
+		}
+		result = append(result, receivedRow)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to read blackouts")
+	}
+	return result, nil
+}
+
+/*
+Get blackout from DB upon blackout ID
+*/
+func (repo *BlackoutRepository) GetBlackout(blackoutID int) (*domain.Blackout, error) {
+	stmt, err := repo.dbStruct.Prepare(fmt.Sprintf("%s WHERE blackout_id=?", db.SelectBlackouts))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prepare an sql query")
+	}
+	defer stmt.Close()
+	receivedRow, err := scanBlackout(stmt.QueryRow(blackoutID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Blackout %d", blackoutID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query blackout")
+	}
+	return &receivedRow, nil
+}
+
+/*
+Update blackout in DB
+*/
+func (repo *BlackoutRepository) UpdateBlackout(blackout domain.Blackout, blackoutID int) (int64, error) {
+	res, err := repo.dbStruct.Exec(db.UpdateBlackout,
+		blackout.FromDate,
+		blackout.ToDate,
+		blackout.Reason,
+		blackout.Description,
+		blackoutID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute blackout update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	return affect, nil
+}
+
+/*
+Remove blackout from DB
+*/
+func (repo *BlackoutRepository) RemoveBlackout(blackoutID int) (int64, error) {
+	res, err := repo.dbStruct.Exec(db.RemoveBlackout, blackoutID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute blackout delete")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	return affect, nil
+}
@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"car-rental/internal/server/db"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type BranchRepository struct {
+	dbStruct *db.DBStruct
+}
+
+func NewBranchRepository(dbStruct *db.DBStruct) *BranchRepository {
+	return &BranchRepository{dbStruct: dbStruct}
+}
+
+/*
+Insert branch into DB
+*/
+func (repo *BranchRepository) InsertBranch(branch domain.Branch) (int64, error) {
+	openingHours, err := marshalOpeningHours(branch.OpeningHours)
+	if err != nil {
+		return 0, err
+	}
+	res, err := repo.dbStruct.Exec(db.InsertIntoBranchTable,
+		branch.Name,
+		branch.Address,
+		branch.Latitude,
+		branch.Longitude,
+		branch.Timezone,
+		openingHours)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute branch insert")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a extract last id")
+	}
+	return id, nil
+}
+
+/*
+Get branches from DB
+*/
+func (repo *BranchRepository) GetBranches() ([]domain.Branch, error) {
+	rows, err := repo.dbStruct.Query(fmt.Sprintf("%s ORDER BY branch_id", db.SelectBranches))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+
+	var result []domain.Branch
+	for rows.Next() {
+		receivedRow, err := scanBranch(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Get branch from DB upon branch ID
+*/
+func (repo *BranchRepository) GetBranch(branchID int) (*domain.Branch, error) {
+	return repo.getBranch(fmt.Sprintf("%s WHERE branch_id=?", db.SelectBranches), branchID)
+}
+
+/*
+Get branch from DB upon branch name
+*/
+func (repo *BranchRepository) GetBranchByName(name string) (*domain.Branch, error) {
+	return repo.getBranch(fmt.Sprintf("%s WHERE name=?", db.SelectBranches), name)
+}
+
+/*
+Update branch in DB
+*/
+func (repo *BranchRepository) UpdateBranch(branch domain.Branch, branchID int) (int64, error) {
+	openingHours, err := marshalOpeningHours(branch.OpeningHours)
+	if err != nil {
+		return 0, err
+	}
+	res, err := repo.dbStruct.Exec(db.UpdateBranch,
+		branch.Name,
+		branch.Address,
+		branch.Latitude,
+		branch.Longitude,
+		branch.Timezone,
+		openingHours,
+		branchID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute branch update")
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+
+	return affect, nil
+}
+
+/*
+Remove branch from DB, branch with rents is kept
+*/
+func (repo *BranchRepository) RemoveBranch(branchID int) (int64, error) {
+	tx, err := repo.dbStruct.BeginTransaction()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+
+	var rents int
+	if err := tx.QueryRow(db.CountBranchRents, branchID, branchID, branchID).Scan(&rents); err != nil {
+		return 0, errors.Wrap(err, "Failed to count branch rents")
+	}
+	if rents > 0 {
+		return 0, errors.Wrapf(storage.ErrInUse, "Branch %d has %d rents", branchID, rents)
+	}
+	res, err := tx.Exec(db.RemoveBranch, branchID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute branch delete")
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+
+	return affect, nil
+}
+
+func (repo *BranchRepository) getBranch(selectQuery string, arg interface{}) (*domain.Branch, error) {
+	stmt, err := repo.dbStruct.Prepare(selectQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prepare an sql query")
+	}
+	defer stmt.Close()
+	receivedRow, err := scanBranch(stmt.QueryRow(arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Branch %v", arg)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query branch")
+	}
+	return &receivedRow, nil
+}
+
+func marshalOpeningHours(openingHours []domain.OpeningHours) (string, error) {
+	if openingHours == nil {
+		openingHours = []domain.OpeningHours{}
+	}
+	encoded, err := json.Marshal(openingHours)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to encode opening hours")
+	}
+	return string(encoded), nil
+}
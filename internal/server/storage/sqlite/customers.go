@@ -0,0 +1,163 @@
+package sqlite
+
+import (
+	"car-rental/internal/server/db"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type CustomerRepository struct {
+	dbStruct *db.DBStruct
+}
+
+func NewCustomerRepository(dbStruct *db.DBStruct) *CustomerRepository {
+	return &CustomerRepository{dbStruct: dbStruct}
+}
+
+/*
+Insert customer into DB
+*/
+func (repo *CustomerRepository) InsertCustomer(customer domain.Customer) (int64, error) {
+	res, err := repo.dbStruct.Exec(db.InsertIntoCustomerTable,
+		customer.Name,
+		customer.Email,
+		customer.Phone,
+		customer.DateOfBirth,
+		customer.LicenceNumber,
+		customer.LicenceCountry,
+		customer.LicenceExpiry,
+		nullableText(customer.LicenceIssued),
+		strings.Join(customer.LicenceCategories, ","))
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute customer insert")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a extract last id")
+	}
+	return id, nil
+}
+
+/*
+Get customers from DB
+*/
+func (repo *CustomerRepository) GetCustomers() ([]domain.Customer, error) {
+	rows, err := repo.dbStruct.Query(fmt.Sprintf("%s ORDER BY customer_id", db.SelectCustomers))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+
+	var result []domain.Customer
+	for rows.Next() {
+		receivedRow, err := scanCustomer(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Get customer from DB upon customer ID
+*/
+func (repo *CustomerRepository) GetCustomer(customerID int) (*domain.Customer, error) {
+	return repo.getCustomer(fmt.Sprintf("%s WHERE customer_id=?", db.SelectCustomers), customerID)
+}
+
+/*
+Get customer from DB upon driver licence
+*/
+func (repo *CustomerRepository) GetCustomerByLicence(country string, number string) (*domain.Customer, error) {
+	return repo.getCustomer(fmt.Sprintf("%s WHERE licence_country=? AND licence_number=?", db.SelectCustomers), country, number)
+}
+
+/*
+Update customer in DB
+*/
+func (repo *CustomerRepository) UpdateCustomer(customer domain.Customer, customerID int) (int64, error) {
+	res, err := repo.dbStruct.Exec(db.UpdateCustomer,
+		customer.Name,
+		customer.Email,
+		customer.Phone,
+		customer.DateOfBirth,
+		customer.LicenceNumber,
+		customer.LicenceCountry,
+		customer.LicenceExpiry,
+		nullableText(customer.LicenceIssued),
+		strings.Join(customer.LicenceCategories, ","),
+		customerID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute customer update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	return affect, nil
+}
+
+/*
+Remove customer from DB, customer with rents is kept
+*/
+func (repo *CustomerRepository) RemoveCustomer(customerID int) (int64, error) {
+	tx, err := repo.dbStruct.BeginTransaction()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+
+	var rents int
+	if err := tx.QueryRow(db.CountCustomerRents, customerID).Scan(&rents); err != nil {
+		return 0, errors.Wrap(err, "Failed to count customer rents")
+	}
+	if rents > 0 {
+		return 0, errors.Wrapf(storage.ErrInUse, "Customer %d has %d rents", customerID, rents)
+	}
+	res, err := tx.Exec(db.RemoveCustomer, customerID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute customer delete")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return affect, nil
+}
+
+func (repo *CustomerRepository) getCustomer(selectQuery string, args ...interface{}) (*domain.Customer, error) {
+	stmt, err := repo.dbStruct.Prepare(selectQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prepare an sql query")
+	}
+	defer stmt.Close()
+	receivedRow, err := scanCustomer(stmt.QueryRow(args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Customer %v", args)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query customer")
+	}
+	return &receivedRow, nil
+}
+
+/*
+Empty value is stored as NULL
+*/
+func nullableText(value string) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return value
+}
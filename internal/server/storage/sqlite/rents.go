@@ -0,0 +1,428 @@
+package sqlite
+
+import (
+	"car-rental/internal/server/db"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type RentRepository struct {
+	dbStruct *db.DBStruct
+}
+
+func NewRentRepository(dbStruct *db.DBStruct) *RentRepository {
+	return &RentRepository{dbStruct: dbStruct}
+}
+
+/*
+Insert rent into DB, rents and blackouts of the car are selected and checked in the same transaction
+*/
+func (repo *RentRepository) InsertRent(rent domain.RentInfo, check storage.RentCheck) (int64, error) {
+	quote, err := marshalQuote(rent.Quote)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := repo.dbStruct.BeginCheckedTransaction()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	if err := checkRent(tx, rent.CarID, 0, check); err != nil {
+		return 0, err
+	}
+	statement, err := tx.Prepare(db.InsertIntoRentTable)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to prepare stmt")
+	}
+	res, err := statement.Exec(rent.CarID,
+		rent.FromDate,
+		rent.ToDate,
+		rent.Location,
+		strings.Join(rent.AvailableExtras, ","),
+		strings.Join(rent.Discounts, ","),
+		rent.CarDetails,
+		nullableID(rent.BranchID),
+		quote,
+		rent.AgeGroup,
+		rent.CarGroup,
+		rent.ReturnLocation,
+		nullableID(rent.ReturnBranchID),
+		nullableID(rent.CustomerID),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a prepared statement")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a extract last id")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+
+	return id, nil
+}
+
+/*
+Get rents from DB
+*/
+func (repo *RentRepository) GetRents() ([]domain.RentInfo, error) {
+	rows, err := repo.dbStruct.Query(db.SelectRents)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	return collectRents(rows)
+}
+
+/*
+Get rents of one car from DB
+*/
+func (repo *RentRepository) GetCarRents(carID int) ([]domain.RentInfo, error) {
+	rows, err := repo.dbStruct.Query(fmt.Sprintf("%s WHERE car_id=?", db.SelectRents), carID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	return collectRents(rows)
+}
+
+/*
+Get rent from DB
+*/
+func (repo *RentRepository) GetRent(rentID int) (*domain.RentInfo, error) {
+	stmt, err := repo.dbStruct.Prepare(fmt.Sprintf("%s WHERE rent_id=?", db.SelectRents))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prepare an sql query")
+	}
+	defer stmt.Close()
+	receivedRow, err := scanRent(stmt.QueryRow(rentID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Wrapf(storage.ErrNotFound, "Rent %d", rentID)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to query rent")
+	}
+	return &receivedRow, nil
+}
+
+/*
+Update rent in DB, other rents and blackouts of the car are selected and checked in the same transaction.
+Only reserved or picked up rent is updated
+*/
+func (repo *RentRepository) UpdateRent(rent domain.RentInfo, rentID int, check storage.RentCheck) (int64, error) {
+	quote, err := marshalQuote(rent.Quote)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := repo.dbStruct.BeginCheckedTransaction()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	if err := checkRent(tx, rent.CarID, rentID, check); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(db.UpdateRent,
+		rent.CarID,
+		rent.FromDate,
+		rent.ToDate,
+		rent.Location,
+		strings.Join(rent.AvailableExtras, ","),
+		strings.Join(rent.Discounts, ","),
+		rent.CarDetails,
+		nullableID(rent.BranchID),
+		quote,
+		rent.AgeGroup,
+		rent.CarGroup,
+		rent.ReturnLocation,
+		nullableID(rent.ReturnBranchID),
+		nullableID(rent.CustomerID),
+		rentID,
+		domain.ReservedRentStatus,
+		domain.PickedUpRentStatus)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute rent update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+
+	return affect, nil
+}
+
+/*
+Remove rent from DB
+*/
+func (repo *RentRepository) RemoveRent(rentID int) (int64, error) {
+	stmt, err := repo.dbStruct.Prepare(db.RemoveRent)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to prepare an sql query")
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(rentID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute rent delete")
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows deleted number")
+	}
+
+	return affect, nil
+}
+
+/*
+Change rent status and record transition in one transaction, transition is not recorded when rent status was changed before
+*/
+func (repo *RentRepository) TransitRent(transition domain.RentTransition, cancellation *domain.Cancellation) (int64, error) {
+	encodedCancellation, err := marshalCancellation(cancellation)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := repo.dbStruct.BeginTransaction()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(db.UpdateRentStatus, transition.ToStatus, encodedCancellation, transition.RentID, transition.FromStatus)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute rent status update")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if affect == 0 {
+		return 0, nil
+	}
+	res, err = tx.Exec(db.InsertIntoRentTransitionTable,
+		transition.RentID,
+		transition.FromStatus,
+		transition.ToStatus,
+		nullableInt(transition.Odometer),
+		nullableInt(transition.FuelLevel),
+		transition.Reason,
+		transition.CreatedAt)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute rent transition insert")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a extract last id")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return id, nil
+}
+
+/*
+Get transitions of rent from DB
+*/
+func (repo *RentRepository) GetRentTransitions(rentID int) ([]domain.RentTransition, error) {
+	rows, err := repo.dbStruct.Query(fmt.Sprintf("%s WHERE rent_id=? ORDER BY transition_id", db.SelectRentTransitions), rentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+	var result []domain.RentTransition
+	for rows.Next() {
+		receivedRow, err := scanRentTransition(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Move reserved rent to another car and record reassignment in one transaction
+*/
+func (repo *RentRepository) ReassignRent(reassignment domain.Reassignment, carDetails string, check func(carRents []domain.RentInfo) error) (int64, error) {
+	tx, err := repo.dbStruct.BeginCheckedTransaction()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to start a transaction")
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query(fmt.Sprintf("%s WHERE car_id=? AND rent_id<>?", db.SelectRents), reassignment.ToCarID, reassignment.RentID)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	carRents, err := collectRents(rows)
+	if err != nil {
+		return 0, err
+	}
+	if err := check(carRents); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(db.ReassignRent,
+		reassignment.ToCarID,
+		carDetails,
+		reassignment.RentID,
+		reassignment.FromCarID,
+		domain.ReservedRentStatus)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute rent reassignment")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to extract rows updated number")
+	}
+	if affect == 0 {
+		return 0, nil
+	}
+	res, err = tx.Exec(db.InsertIntoRentReassignmentTable,
+		reassignment.RentID,
+		reassignment.FromCarID,
+		reassignment.ToCarID,
+		reassignment.Kind,
+		reassignment.Reason,
+		reassignment.CreatedAt)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute rent reassignment insert")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to execute a extract last id")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "Failed to commit a transaction")
+	}
+	return id, nil
+}
+
+/*
+Get reassignments of rent from DB
+*/
+func (repo *RentRepository) GetRentReassignments(rentID int) ([]domain.Reassignment, error) {
+	rows, err := repo.dbStruct.Query(fmt.Sprintf("%s WHERE rent_id=? ORDER BY reassignment_id", db.SelectRentReassignments), rentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to execute a sql query")
+	}
+	defer rows.Close()
+	var result []domain.Reassignment
+	for rows.Next() {
+		receivedRow, err := scanReassignment(rows)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		result = append(result, receivedRow)
+	}
+	return result, nil
+}
+
+/*
+Check that car is not retired, then select rents other than rentID and blackouts of car in transaction and pass them to check
+*/
+func checkRent(tx *db.CheckedTx, carID int, rentID int, check storage.RentCheck) error {
+	var retiredAt sql.NullString
+	err := tx.QueryRow(db.SelectCarRetirement, carID).Scan(&retiredAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrapf(storage.ErrNotFound, "Car %d", carID)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to query car retirement")
+	}
+	if retiredAt.Valid {
+		return errors.Wrapf(storage.ErrRetired, "Car %d", carID)
+	}
+	if check == nil {
+		return nil
+	}
+	rows, err := tx.Query(fmt.Sprintf("%s WHERE car_id=? AND rent_id<>?", db.SelectRents), carID, rentID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to execute a sql query")
+	}
+	carRents, err := collectRents(rows)
+	if err != nil {
+		return err
+	}
+	rows, err = tx.Query(fmt.Sprintf("%s WHERE car_id=? ORDER BY from_time, blackout_id", db.SelectBlackouts), carID)
+	if err != nil {
+		return errors.Wrap(err, "Failed to execute a sql query")
+	}
+	blackouts, err := collectBlackouts(rows)
+	if err != nil {
+		return err
+	}
+	return check(carRents, blackouts)
+}
+
+func collectRents(rows *sql.Rows) ([]domain.RentInfo, error) {
+	defer rows.Close()
+	var result []domain.RentInfo
+	for rows.Next() {
+		receivedRow, err := scanRent(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to scan rent")
+		}
+		result = append(result, receivedRow)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to read rents")
+	}
+	return result, nil
+}
+
+/*
+Zero ID means no relation and is stored as NULL
+*/
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+/*
+Missing value is stored as NULL
+*/
+func nullableInt(value *int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+/*
+Cancellation is stored as JSON, NULL means rent is not cancelled
+*/
+func marshalCancellation(cancellation *domain.Cancellation) (interface{}, error) {
+	if cancellation == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(cancellation)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode cancellation")
+	}
+	return string(encoded), nil
+}
+
+/*
+Quote is stored as JSON, rent without quote is stored as NULL
+*/
+func marshalQuote(quote *domain.PriceQuote) (interface{}, error) {
+	if quote == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(quote)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to encode price quote")
+	}
+	return string(encoded), nil
+}
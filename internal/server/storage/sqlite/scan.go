@@ -0,0 +1,286 @@
+package sqlite
+
+import (
+	"car-rental/internal/server/domain"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Common part of sql.Row and sql.Rows
+*/
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+/*
+Scan row selected with db.SelectCars
+*/
+func scanCar(row scanner) (domain.Car, error) {
+	var receivedRow domain.Car
+	var locations string
+	var retiredAt sql.NullString
+	err := row.Scan(&receivedRow.CarID,
+		&receivedRow.CarCompanyName,
+		&receivedRow.Doors,
+		&receivedRow.BigLuggage,
+		&receivedRow.SmallLuggage,
+		&receivedRow.AdultPlaces,
+		&receivedRow.AirConditioner,
+		&receivedRow.MinimumAge,
+		&locations,
+		&receivedRow.CarGroup,
+		&receivedRow.Description,
+		&receivedRow.Price,
+		&retiredAt)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.AvailableLocations = strings.Split(locations, ",")
+	receivedRow.RetiredAt = retiredAt.String
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with db.SelectCarsRents, rent columns are empty for cars without rents
+*/
+func scanCombinedRentInfo(row scanner) (domain.CombinedRentInfo, error) {
+	var receivedRow domain.CombinedRentInfo
+	var locations string
+	var rentID sql.NullInt32
+	var fromDate sql.NullString
+	var toDate sql.NullString
+	var location sql.NullString
+	var extras sql.NullString
+	var discounts sql.NullString
+	var carDetails sql.NullString
+	err := row.Scan(&receivedRow.CarID,
+		&receivedRow.CarCompanyName,
+		&receivedRow.Doors,
+		&receivedRow.BigLuggage,
+		&receivedRow.SmallLuggage,
+		&receivedRow.AdultPlaces,
+		&receivedRow.AirConditioner,
+		&receivedRow.MinimumAge,
+		&locations,
+		&receivedRow.CarGroup,
+		&receivedRow.Description,
+		&receivedRow.Price,
+		&rentID,
+		&fromDate,
+		&toDate,
+		&location,
+		&extras,
+		&discounts,
+		&carDetails,
+	)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.RentID = rentID.Int32
+	receivedRow.FromDate = fromDate.String
+	receivedRow.ToDate = toDate.String
+	receivedRow.Location = location.String
+	receivedRow.AvailableExtras = strings.Split(extras.String, ",")
+	receivedRow.Discounts = strings.Split(discounts.String, ",")
+	receivedRow.AvailableLocations = strings.Split(locations, ",")
+	receivedRow.CarDetails = carDetails.String
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with db.SelectRents
+*/
+func scanRent(row scanner) (domain.RentInfo, error) {
+	var receivedRow domain.RentInfo
+	var extras string
+	var discounts string
+	var branchID sql.NullInt64
+	var quote sql.NullString
+	var cancellation sql.NullString
+	var returnLocation sql.NullString
+	var returnBranchID sql.NullInt64
+	var customerID sql.NullInt64
+	err := row.Scan(
+		&receivedRow.RentID,
+		&receivedRow.CarID,
+		&receivedRow.FromDate,
+		&receivedRow.ToDate,
+		&receivedRow.Location,
+		&extras,
+		&discounts,
+		&receivedRow.CarDetails,
+		&branchID,
+		&quote,
+		&receivedRow.AgeGroup,
+		&receivedRow.CarGroup,
+		&receivedRow.Status,
+		&cancellation,
+		&returnLocation,
+		&returnBranchID,
+		&customerID,
+	)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.BranchID = int(branchID.Int64)
+	receivedRow.ReturnLocation = returnLocation.String
+	receivedRow.ReturnBranchID = int(returnBranchID.Int64)
+	receivedRow.CustomerID = int(customerID.Int64)
+	if quote.Valid {
+		receivedRow.Quote = &domain.PriceQuote{}
+		if err := json.Unmarshal([]byte(quote.String), receivedRow.Quote); err != nil {
+			return receivedRow, errors.Wrapf(err, "Failed to parse price quote of rent %d", receivedRow.RentID)
+		}
+	}
+	if cancellation.Valid {
+		receivedRow.Cancellation = &domain.Cancellation{}
+		if err := json.Unmarshal([]byte(cancellation.String), receivedRow.Cancellation); err != nil {
+			return receivedRow, errors.Wrapf(err, "Failed to parse cancellation of rent %d", receivedRow.RentID)
+		}
+	}
+	receivedRow.AvailableExtras = strings.Split(extras, ",")
+	receivedRow.Discounts = strings.Split(discounts, ",")
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with db.SelectRentTransitions, car state is optional
+*/
+func scanRentTransition(row scanner) (domain.RentTransition, error) {
+	var receivedRow domain.RentTransition
+	var odometer sql.NullInt64
+	var fuelLevel sql.NullInt64
+	var reason sql.NullString
+	err := row.Scan(&receivedRow.TransitionID,
+		&receivedRow.RentID,
+		&receivedRow.FromStatus,
+		&receivedRow.ToStatus,
+		&odometer,
+		&fuelLevel,
+		&reason,
+		&receivedRow.CreatedAt)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Odometer = nullableIntValue(odometer)
+	receivedRow.FuelLevel = nullableIntValue(fuelLevel)
+	receivedRow.Reason = reason.String
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with SelectRentReassignments
+*/
+func scanReassignment(row scanner) (domain.Reassignment, error) {
+	var receivedRow domain.Reassignment
+	var reason sql.NullString
+	err := row.Scan(&receivedRow.ReassignmentID,
+		&receivedRow.RentID,
+		&receivedRow.FromCarID,
+		&receivedRow.ToCarID,
+		&receivedRow.Kind,
+		&reason,
+		&receivedRow.CreatedAt)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Reason = reason.String
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with SelectBlackouts
+*/
+func scanBlackout(row scanner) (domain.Blackout, error) {
+	var receivedRow domain.Blackout
+	var description sql.NullString
+	var toLocation sql.NullString
+	var toBranchID sql.NullInt64
+	err := row.Scan(&receivedRow.BlackoutID,
+		&receivedRow.CarID,
+		&receivedRow.FromDate,
+		&receivedRow.ToDate,
+		&receivedRow.Reason,
+		&description,
+		&toLocation,
+		&toBranchID)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Description = description.String
+	receivedRow.ToLocation = toLocation.String
+	receivedRow.ToBranchID = int(toBranchID.Int64)
+	return receivedRow, nil
+}
+
+func nullableIntValue(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	result := int(value.Int64)
+	return &result
+}
+
+/*
+Scan row selected with db.SelectBranches, opening hours are stored as JSON
+*/
+func scanBranch(row scanner) (domain.Branch, error) {
+	var receivedRow domain.Branch
+	var address sql.NullString
+	var timezone sql.NullString
+	var openingHours sql.NullString
+	err := row.Scan(&receivedRow.BranchID,
+		&receivedRow.Name,
+		&address,
+		&receivedRow.Latitude,
+		&receivedRow.Longitude,
+		&timezone,
+		&openingHours)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Address = address.String
+	receivedRow.Timezone = timezone.String
+	if openingHours.Valid && len(openingHours.String) > 0 {
+		if err := json.Unmarshal([]byte(openingHours.String), &receivedRow.OpeningHours); err != nil {
+			return receivedRow, errors.Wrapf(err, "Failed to parse opening hours of branch %d", receivedRow.BranchID)
+		}
+	}
+	if len(receivedRow.OpeningHours) == 0 {
+		receivedRow.OpeningHours = nil
+	}
+	return receivedRow, nil
+}
+
+/*
+Scan row selected with db.SelectCustomers
+*/
+func scanCustomer(row scanner) (domain.Customer, error) {
+	var receivedRow domain.Customer
+	var phone sql.NullString
+	var licenceIssued sql.NullString
+	var licenceCategories string
+	err := row.Scan(&receivedRow.CustomerID,
+		&receivedRow.Name,
+		&receivedRow.Email,
+		&phone,
+		&receivedRow.DateOfBirth,
+		&receivedRow.LicenceNumber,
+		&receivedRow.LicenceCountry,
+		&receivedRow.LicenceExpiry,
+		&licenceIssued,
+		&licenceCategories)
+	if err != nil {
+		return receivedRow, err
+	}
+	receivedRow.Phone = phone.String
+	receivedRow.LicenceIssued = licenceIssued.String
+	if len(licenceCategories) > 0 {
+		receivedRow.LicenceCategories = strings.Split(licenceCategories, ",")
+	}
+	return receivedRow, nil
+}
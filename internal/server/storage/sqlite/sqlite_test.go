@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"car-rental/internal/server/config"
+	"car-rental/internal/server/db"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"car-rental/internal/server/storage/storagetest"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositories(test *testing.T) {
+	dbNumber := 0
+	storagetest.RunRepositoryTests(test, func(test *testing.T) storage.Repositories {
+		dbNumber++
+		dbStruct, err := db.NewDBStruct(config.DBConfig{DSN: fmt.Sprintf("file:sqlite_test_%d?mode=memory&cache=shared&_fk=true", dbNumber)})
+		require.NoError(test, err)
+		test.Cleanup(func() { dbStruct.Close() })
+		return NewRepositories(dbStruct)
+	})
+}
+
+/*
+Test that rent which can not be read rejects the write instead of being skipped by the check
+*/
+func TestRentCheckFailsOnBrokenRent(test *testing.T) {
+	dbStruct, err := db.NewDBStruct(config.DBConfig{DSN: "file:sqlite_broken_rent?mode=memory&cache=shared&_fk=true"})
+	require.NoError(test, err)
+	test.Cleanup(func() { dbStruct.Close() })
+	repos := NewRepositories(dbStruct)
+	carID, err := repos.Cars.InsertCar(storagetest.TestCar)
+	require.NoError(test, err)
+	rent := storagetest.TestRent
+	rent.CarID = int(carID)
+	_, err = repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+	_, err = dbStruct.Exec("UPDATE rents SET price_quote = 'broken'")
+	require.NoError(test, err)
+
+	checked := false
+	_, err = repos.Rents.InsertRent(rent, func([]domain.RentInfo, []domain.Blackout) error {
+		checked = true
+		return nil
+	})
+	assert.Error(test, err)
+	assert.False(test, checked, "Check does not run without all rents of the car")
+	_, err = repos.Rents.GetCarRents(int(carID))
+	assert.Error(test, err)
+}
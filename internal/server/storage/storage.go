@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/db/query"
+	"car-rental/internal/server/domain"
+	"errors"
+)
+
+var (
+	ErrNotFound = errors.New("Record not found")
+	// Returned by backends which enforce rent overlap on storage level
+	ErrOverlap = errors.New("Rent overlaps another rent of the same car")
+	// Returned when record is still referenced by other records
+	ErrInUse = errors.New("Record is in use")
+	// Returned when rent is written for retired car
+	ErrRetired = errors.New("Car is retired")
+)
+
+type (
+	/*
+		Validated car search filter, nil fields are not applied
+	*/
+	CarFilter struct {
+		Dates *query.DateRange
+		// Names of branches, car linked to any of them matches
+		Locations []string
+		Age       *query.IntRange
+		CarGroup  *int
+		// Rules between rents applied to Dates, availability.DefaultRules when nil
+		Rules *availability.Rules
+	}
+
+	// Car repositories store car.BranchIDs as car to branch relations.
+	// Retired cars are returned only by ID, car.RetiredAt is changed only by RetireCar
+	CarRepository interface {
+		InsertCar(car domain.Car) (int64, error)
+		GetCars() ([]domain.Car, error)
+		GetCar(carID int) (*domain.Car, error)
+		// Returns cars joined with their rents, cars with rent conflicting with filter dates are skipped
+		SearchCars(filter CarFilter) ([]domain.CombinedRentInfo, error)
+		UpdateCar(car domain.Car, carID int) (int64, error)
+		// Returns 0 when car does not exist or is already retired.
+		// Returns ErrInUse when reserved or picked up rent of the car ends after retiredAt
+		RetireCar(carID int, retiredAt string) (int64, error)
+		RemoveCar(carID int) (int64, error)
+	}
+
+	// Checks rent against other rents and blackouts of its car before it is written, error rejects the write.
+	// Rents and blackouts are read in the transaction of the write, so concurrent writes can not pass the same check
+	RentCheck func(carRents []domain.RentInfo, blackouts []domain.Blackout) error
+
+	// Rents are stored as reserved, status is changed only by transitions. Rent check may be nil.
+	// Rent of retired car is not written, ErrRetired is returned instead
+	RentRepository interface {
+		InsertRent(rent domain.RentInfo, check RentCheck) (int64, error)
+		GetRents() ([]domain.RentInfo, error)
+		GetRent(rentID int) (*domain.RentInfo, error)
+		GetCarRents(carID int) ([]domain.RentInfo, error)
+		// Replaces rent in one transaction, check gets other rents of the rent car.
+		// Returns 0 when rent does not exist or is not reserved or picked up anymore
+		UpdateRent(rent domain.RentInfo, rentID int, check RentCheck) (int64, error)
+		RemoveRent(rentID int) (int64, error)
+		// Moves rent from transition.FromStatus to transition.ToStatus and records transition in one transaction,
+		// cancellation replaces the one stored with the rent.
+		// Returns ID of recorded transition, 0 when rent does not exist or is not in transition.FromStatus anymore
+		TransitRent(transition domain.RentTransition, cancellation *domain.Cancellation) (int64, error)
+		// Returns transitions of the rent in order they were made
+		GetRentTransitions(rentID int) ([]domain.RentTransition, error)
+		// Moves reserved rent from reassignment.FromCarID to reassignment.ToCarID and records reassignment in one transaction,
+		// check gets rents of the new car and rejects move by returning error.
+		// Returns ID of recorded reassignment, 0 when rent does not exist or is not reserved on reassignment.FromCarID anymore
+		ReassignRent(reassignment domain.Reassignment, carDetails string, check func(carRents []domain.RentInfo) error) (int64, error)
+		// Returns reassignments of the rent in order they were made
+		GetRentReassignments(rentID int) ([]domain.Reassignment, error)
+	}
+
+	// Blackouts are removed together with their car. Transfer blackout has destination branch
+	BlackoutRepository interface {
+		InsertBlackout(blackout domain.Blackout) (int64, error)
+		// Returns blackouts of all cars ordered by car and from date
+		GetBlackouts() ([]domain.Blackout, error)
+		// Returns blackouts of the car ordered by from date
+		GetCarBlackouts(carID int) ([]domain.Blackout, error)
+		GetBlackout(blackoutID int) (*domain.Blackout, error)
+		// Changes dates, reason and description, car and destination of blackout are kept
+		UpdateBlackout(blackout domain.Blackout, blackoutID int) (int64, error)
+		RemoveBlackout(blackoutID int) (int64, error)
+	}
+
+	BranchRepository interface {
+		InsertBranch(branch domain.Branch) (int64, error)
+		GetBranches() ([]domain.Branch, error)
+		GetBranch(branchID int) (*domain.Branch, error)
+		GetBranchByName(name string) (*domain.Branch, error)
+		UpdateBranch(branch domain.Branch, branchID int) (int64, error)
+		// Returns ErrInUse when branch has rents or transfers, links to cars are removed together with branch
+		RemoveBranch(branchID int) (int64, error)
+	}
+
+	// Licence country and number of customer are unique
+	CustomerRepository interface {
+		InsertCustomer(customer domain.Customer) (int64, error)
+		GetCustomers() ([]domain.Customer, error)
+		GetCustomer(customerID int) (*domain.Customer, error)
+		GetCustomerByLicence(country string, number string) (*domain.Customer, error)
+		UpdateCustomer(customer domain.Customer, customerID int) (int64, error)
+		// Returns ErrInUse when customer has rents
+		RemoveCustomer(customerID int) (int64, error)
+	}
+
+	/*
+		All repositories of one storage backend
+	*/
+	Repositories struct {
+		Cars      CarRepository
+		Rents     RentRepository
+		Branches  BranchRepository
+		Blackouts BlackoutRepository
+		Customers CustomerRepository
+	}
+)
+
+/*
+Window of filter dates, nil when dates are not filtered
+*/
+func (filter CarFilter) Window() *availability.Window {
+	if filter.Dates == nil {
+		return nil
+	}
+	rules := availability.DefaultRules
+	if filter.Rules != nil {
+		rules = *filter.Rules
+	}
+	window := rules.Window(availability.Interval{From: filter.Dates.From, To: filter.Dates.To})
+	return &window
+}
@@ -0,0 +1,761 @@
+package storagetest
+
+import (
+	"car-rental/internal/server/availability"
+	"car-rental/internal/server/db/query"
+	"car-rental/internal/server/domain"
+	"car-rental/internal/server/storage"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Creates empty repositories for every test
+*/
+type Factory func(test *testing.T) storage.Repositories
+
+var (
+	TestCar = domain.Car{
+		CarCompanyName:     "Kia",
+		Doors:              5,
+		BigLuggage:         2,
+		SmallLuggage:       3,
+		AdultPlaces:        4,
+		AirConditioner:     true,
+		MinimumAge:         30,
+		Price:              120,
+		AvailableLocations: []string{"Haifa", "Tel Aviv"},
+		CarGroup:           2,
+		Description:        "Brand new car",
+	}
+	TestCustomer = domain.Customer{
+		Name:              "Dana Cohen",
+		Email:             "dana@example.com",
+		Phone:             "+972-50-1234567",
+		DateOfBirth:       "1982-03-14",
+		LicenceNumber:     "1234567",
+		LicenceCountry:    "IL",
+		LicenceExpiry:     "2030-03-14",
+		LicenceIssued:     "2001-05-20",
+		LicenceCategories: []string{"B", "C1"},
+	}
+	TestRent = domain.RentInfo{
+		FromDate:        "2022-01-15T10:00:00Z",
+		ToDate:          "2022-01-16T10:00:00Z",
+		Location:        "Haifa",
+		AvailableExtras: []string{"GPS"},
+		Discounts:       []string{"5%"},
+		CarDetails:      "Kia Brand new car",
+		AgeGroup:        "40",
+		CarGroup:        2,
+		Status:          domain.ReservedRentStatus,
+		Quote: &domain.PriceQuote{
+			DailyRate: 12000,
+			Days:      1,
+			Items: []domain.PriceItem{
+				{Kind: domain.RentalPriceItem, Name: "Daily rate", Quantity: 1, UnitPrice: 12000, Amount: 12000},
+				{Kind: domain.DiscountPriceItem, Name: "5%", Quantity: 1, Amount: -600},
+			},
+			Subtotal: 12000,
+			Discount: 600,
+			Total:    11400,
+		},
+	}
+)
+
+/*
+Runs behaviour every storage backend has to follow
+*/
+func RunRepositoryTests(test *testing.T, newRepositories Factory) {
+	test.Run("Cars", func(test *testing.T) { testCars(test, newRepositories(test)) })
+	test.Run("CarRetirement", func(test *testing.T) { testCarRetirement(test, newRepositories(test)) })
+	test.Run("Rents", func(test *testing.T) { testRents(test, newRepositories(test)) })
+	test.Run("ConcurrentRents", func(test *testing.T) { testConcurrentRents(test, newRepositories(test)) })
+	test.Run("RentTransitions", func(test *testing.T) { testRentTransitions(test, newRepositories(test)) })
+	test.Run("RentReassignments", func(test *testing.T) { testRentReassignments(test, newRepositories(test)) })
+	test.Run("SearchCars", func(test *testing.T) { testSearchCars(test, newRepositories(test)) })
+	test.Run("Blackouts", func(test *testing.T) { testBlackouts(test, newRepositories(test)) })
+	test.Run("Branches", func(test *testing.T) { testBranches(test, newRepositories(test)) })
+	test.Run("Customers", func(test *testing.T) { testCustomers(test, newRepositories(test)) })
+}
+
+/*
+Links car to branches named in its available locations, missing branches are created
+*/
+func withBranches(test *testing.T, repos storage.Repositories, car domain.Car) domain.Car {
+	car.BranchIDs = nil
+	for _, location := range car.AvailableLocations {
+		car.BranchIDs = append(car.BranchIDs, branchNamed(test, repos, location).BranchID)
+	}
+	sort.Ints(car.BranchIDs)
+	return car
+}
+
+/*
+Finds branch by name, storage starts without branches so missing branch is created
+*/
+func branchNamed(test *testing.T, repos storage.Repositories, name string) domain.Branch {
+	branch, err := repos.Branches.GetBranchByName(name)
+	if errors.Is(err, storage.ErrNotFound) {
+		var id int64
+		id, err = repos.Branches.InsertBranch(domain.Branch{Name: name, Timezone: "Asia/Jerusalem"})
+		require.NoError(test, err)
+		branch, err = repos.Branches.GetBranch(int(id))
+	}
+	require.NoError(test, err)
+	return *branch
+}
+
+func testCars(test *testing.T, repos storage.Repositories) {
+	expected := withBranches(test, repos, TestCar)
+	id, err := repos.Cars.InsertCar(expected)
+	require.NoError(test, err)
+	expected.CarID = int(id)
+
+	car, err := repos.Cars.GetCar(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, expected, *car)
+
+	cars, err := repos.Cars.GetCars()
+	require.NoError(test, err)
+	assert.Equal(test, []domain.Car{expected}, cars)
+
+	updated := expected
+	updated.Price = 150
+	updated.AvailableLocations = []string{"Holon"}
+	updated = withBranches(test, repos, updated)
+	affected, err := repos.Cars.UpdateCar(updated, int(id))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	car, err = repos.Cars.GetCar(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, updated, *car)
+
+	affected, err = repos.Cars.RemoveCar(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	_, err = repos.Cars.GetCar(int(id))
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+}
+
+func testCarRetirement(test *testing.T, repos storage.Repositories) {
+	activeID, err := repos.Cars.InsertCar(withBranches(test, repos, TestCar))
+	require.NoError(test, err)
+	retiredID, err := repos.Cars.InsertCar(withBranches(test, repos, TestCar))
+	require.NoError(test, err)
+	rent := TestRent
+	rent.CarID = int(retiredID)
+	rentID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+
+	_, err = repos.Cars.RetireCar(int(retiredID), "2022-01-15T12:00:00Z")
+	assert.True(test, errors.Is(err, storage.ErrInUse), "Car is not retired while its rent is active")
+	affected, err := repos.Cars.RetireCar(int(retiredID), "2022-01-20T10:00:00Z")
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	affected, err = repos.Cars.RetireCar(int(retiredID), "2022-01-21T10:00:00Z")
+	require.NoError(test, err)
+	assert.Equal(test, int64(0), affected, "Car is retired once")
+	affected, err = repos.Cars.RetireCar(1000, "2022-01-21T10:00:00Z")
+	require.NoError(test, err)
+	assert.Equal(test, int64(0), affected)
+
+	retired, err := repos.Cars.GetCar(int(retiredID))
+	require.NoError(test, err)
+	assert.Equal(test, "2022-01-20T10:00:00Z", retired.RetiredAt)
+	retired.Price = 200
+	_, err = repos.Cars.UpdateCar(*retired, int(retiredID))
+	require.NoError(test, err)
+	retired, err = repos.Cars.GetCar(int(retiredID))
+	require.NoError(test, err)
+	assert.Equal(test, "2022-01-20T10:00:00Z", retired.RetiredAt, "Retirement is not changed by car update")
+
+	cars, err := repos.Cars.GetCars()
+	require.NoError(test, err)
+	require.Len(test, cars, 1)
+	assert.Equal(test, int(activeID), cars[0].CarID)
+	found, err := repos.Cars.SearchCars(storage.CarFilter{})
+	require.NoError(test, err)
+	require.Len(test, found, 1)
+	assert.Equal(test, int(activeID), found[0].CarID, "Retired car is hidden from search")
+
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, int(retiredID), received.CarID, "Rents of retired car are kept")
+
+	later := rent
+	later.FromDate = "2022-01-25T10:00:00Z"
+	later.ToDate = "2022-01-26T10:00:00Z"
+	_, err = repos.Rents.InsertRent(later, nil)
+	assert.True(test, errors.Is(err, storage.ErrRetired), "Retired car is not rented")
+	_, err = repos.Rents.UpdateRent(later, int(rentID), nil)
+	assert.True(test, errors.Is(err, storage.ErrRetired))
+}
+
+func testRents(test *testing.T, repos storage.Repositories) {
+	carID, err := repos.Cars.InsertCar(TestCar)
+	require.NoError(test, err)
+	otherCarID, err := repos.Cars.InsertCar(TestCar)
+	require.NoError(test, err)
+
+	haifa := branchNamed(test, repos, TestRent.Location)
+	jerusalem := branchNamed(test, repos, "Jerusalem")
+	rent := TestRent
+	rent.CarID = int(carID)
+	rent.BranchID = haifa.BranchID
+	rent.ReturnLocation = jerusalem.Name
+	rent.ReturnBranchID = jerusalem.BranchID
+	rentID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+	rent.RentID = int(rentID)
+	otherRent := TestRent
+	otherRent.CarID = int(otherCarID)
+	_, err = repos.Rents.InsertRent(otherRent, nil)
+	require.NoError(test, err)
+
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, rent, *received)
+
+	carRents, err := repos.Rents.GetCarRents(int(carID))
+	require.NoError(test, err)
+	assert.Equal(test, []domain.RentInfo{rent}, carRents)
+
+	rents, err := repos.Rents.GetRents()
+	require.NoError(test, err)
+	assert.Len(test, rents, 2)
+
+	_, err = repos.Cars.RemoveCar(int(carID))
+	assert.Error(test, err, "Car with rents should not be removed")
+
+	updated := rent
+	updated.ToDate = "2022-01-17T10:00:00Z"
+	updated.Quote = nil
+	var checkedRents []domain.RentInfo
+	affected, err := repos.Rents.UpdateRent(updated, int(rentID), func(otherRents []domain.RentInfo, blackouts []domain.Blackout) error {
+		checkedRents = otherRents
+		return nil
+	})
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	assert.Empty(test, checkedRents, "Updated rent is not checked against itself")
+	received, err = repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, updated, *received)
+
+	movedRent := updated
+	movedRent.CarID = int(otherCarID)
+	rejected := errors.New("Rejected by check")
+	_, err = repos.Rents.UpdateRent(movedRent, int(rentID), func(otherRents []domain.RentInfo, blackouts []domain.Blackout) error {
+		checkedRents = otherRents
+		return rejected
+	})
+	assert.True(test, errors.Is(err, rejected))
+	assert.Len(test, checkedRents, 1, "Rents of the new car are checked")
+	received, err = repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, updated, *received, "Rejected update is not applied")
+
+	affected, err = repos.Rents.UpdateRent(updated, 1000, nil)
+	require.NoError(test, err)
+	assert.Equal(test, int64(0), affected)
+
+	affected, err = repos.Rents.RemoveRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	_, err = repos.Rents.GetRent(int(rentID))
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+}
+
+/*
+Rents of the same dates are booked at once, check in the write transaction lets only one of them in
+*/
+func testConcurrentRents(test *testing.T, repos storage.Repositories) {
+	carID, err := repos.Cars.InsertCar(withBranches(test, repos, TestCar))
+	require.NoError(test, err)
+	blackout := domain.Blackout{CarID: int(carID), FromDate: "2022-02-01T08:00:00Z", ToDate: "2022-02-02T08:00:00Z", Reason: domain.InspectionBlackoutReason}
+	_, err = repos.Blackouts.InsertBlackout(blackout)
+	require.NoError(test, err)
+	rejected := errors.New("Car is booked")
+	rent := TestRent
+	rent.CarID = int(carID)
+
+	const bookings = 8
+	var wait sync.WaitGroup
+	results := make(chan error, bookings)
+	for i := 0; i < bookings; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			_, err := repos.Rents.InsertRent(rent, func(carRents []domain.RentInfo, blackouts []domain.Blackout) error {
+				if len(blackouts) != 1 {
+					return errors.New("Blackouts of the car are not checked")
+				}
+				for _, carRent := range carRents {
+					if carRent.Status != domain.CancelledRentStatus {
+						return rejected
+					}
+				}
+				return nil
+			})
+			results <- err
+		}()
+	}
+	wait.Wait()
+	close(results)
+	booked := 0
+	for err := range results {
+		if err == nil {
+			booked++
+		} else {
+			assert.True(test, errors.Is(err, rejected), err.Error())
+		}
+	}
+	assert.Equal(test, 1, booked)
+	carRents, err := repos.Rents.GetCarRents(int(carID))
+	require.NoError(test, err)
+	assert.Len(test, carRents, 1)
+}
+
+func testRentReassignments(test *testing.T, repos storage.Repositories) {
+	fromCarID, err := repos.Cars.InsertCar(TestCar)
+	require.NoError(test, err)
+	toCarID, err := repos.Cars.InsertCar(TestCar)
+	require.NoError(test, err)
+	rent := TestRent
+	rent.CarID = int(fromCarID)
+	rentID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+
+	reassignment := domain.Reassignment{
+		RentID:    int(rentID),
+		FromCarID: int(fromCarID),
+		ToCarID:   int(toCarID),
+		Kind:      domain.LikeForLikeReassignment,
+		Reason:    "Damaged",
+		CreatedAt: "2022-01-14T10:00:00Z",
+	}
+	rejected := errors.New("Car is busy")
+	_, err = repos.Rents.ReassignRent(reassignment, "Moved", func([]domain.RentInfo) error { return rejected })
+	assert.True(test, errors.Is(err, rejected))
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, int(fromCarID), received.CarID, "Rejected reassignment does not move rent")
+
+	id, err := repos.Rents.ReassignRent(reassignment, "Moved", func([]domain.RentInfo) error { return nil })
+	require.NoError(test, err)
+	require.NotZero(test, id)
+	reassignment.ReassignmentID = int(id)
+	received, err = repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, int(toCarID), received.CarID)
+	assert.Equal(test, "Moved", received.CarDetails)
+	assert.Equal(test, domain.ReservedRentStatus, received.Status)
+
+	id, err = repos.Rents.ReassignRent(reassignment, "Moved", func([]domain.RentInfo) error { return nil })
+	require.NoError(test, err)
+	assert.Zero(test, id, "Rent which is not on from car anymore is not moved")
+
+	reassignments, err := repos.Rents.GetRentReassignments(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, []domain.Reassignment{reassignment}, reassignments)
+}
+
+func testRentTransitions(test *testing.T, repos storage.Repositories) {
+	carID, err := repos.Cars.InsertCar(TestCar)
+	require.NoError(test, err)
+	rent := TestRent
+	rent.CarID = int(carID)
+	rentID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+
+	odometer, fuelLevel := 1200, 75
+	pickup := domain.RentTransition{
+		RentID:     int(rentID),
+		FromStatus: domain.ReservedRentStatus,
+		ToStatus:   domain.PickedUpRentStatus,
+		Odometer:   &odometer,
+		FuelLevel:  &fuelLevel,
+		CreatedAt:  "2022-01-15T10:05:00Z",
+	}
+	id, err := repos.Rents.TransitRent(pickup, nil)
+	require.NoError(test, err)
+	require.NotZero(test, id)
+	pickup.TransitionID = int(id)
+	received, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, domain.PickedUpRentStatus, received.Status)
+
+	updated := *received
+	updated.ToDate = "2022-01-17T10:00:00Z"
+	_, err = repos.Rents.UpdateRent(updated, int(rentID), nil)
+	require.NoError(test, err)
+	received, err = repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, domain.PickedUpRentStatus, received.Status, "Status is not changed by rent update")
+
+	id, err = repos.Rents.TransitRent(pickup, nil)
+	require.NoError(test, err)
+	assert.Zero(test, id, "Rent which is not in from status is not changed")
+	id, err = repos.Rents.TransitRent(domain.RentTransition{RentID: 1000, FromStatus: domain.ReservedRentStatus, ToStatus: domain.CancelledRentStatus, CreatedAt: pickup.CreatedAt}, nil)
+	require.NoError(test, err)
+	assert.Zero(test, id)
+
+	giveBack := domain.RentTransition{
+		RentID:     int(rentID),
+		FromStatus: domain.PickedUpRentStatus,
+		ToStatus:   domain.ReturnedRentStatus,
+		Reason:     "Returned at night box",
+		CreatedAt:  "2022-01-17T09:00:00Z",
+	}
+	id, err = repos.Rents.TransitRent(giveBack, nil)
+	require.NoError(test, err)
+	giveBack.TransitionID = int(id)
+
+	returned := updated
+	returned.ToDate = "2022-01-18T10:00:00Z"
+	affected, err := repos.Rents.UpdateRent(returned, int(rentID), nil)
+	require.NoError(test, err)
+	assert.Equal(test, int64(0), affected, "Returned rent is not updated")
+	received, err = repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, updated.ToDate, received.ToDate)
+
+	transitions, err := repos.Rents.GetRentTransitions(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, []domain.RentTransition{pickup, giveBack}, transitions)
+
+	cancelledID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+	cancellation := domain.Cancellation{
+		Reason:      "Flight cancelled",
+		CancelledAt: "2022-01-14T10:00:00Z",
+		Policy:      "50% fee when cancelled at least 0h before pickup",
+		Total:       11400,
+		Fee:         5700,
+		Refund:      5700,
+	}
+	_, err = repos.Rents.TransitRent(domain.RentTransition{
+		RentID:     int(cancelledID),
+		FromStatus: domain.ReservedRentStatus,
+		ToStatus:   domain.CancelledRentStatus,
+		Reason:     cancellation.Reason,
+		CreatedAt:  cancellation.CancelledAt,
+	}, &cancellation)
+	require.NoError(test, err)
+	received, err = repos.Rents.GetRent(int(cancelledID))
+	require.NoError(test, err)
+	assert.Equal(test, domain.CancelledRentStatus, received.Status)
+	assert.Equal(test, &cancellation, received.Cancellation, "Cancellation is kept with the rent")
+
+	_, err = repos.Rents.RemoveRent(int(rentID))
+	require.NoError(test, err)
+	transitions, err = repos.Rents.GetRentTransitions(int(rentID))
+	require.NoError(test, err)
+	assert.Empty(test, transitions, "Transitions are removed together with rent")
+}
+
+func testSearchCars(test *testing.T, repos storage.Repositories) {
+	haifaCar := withBranches(test, repos, TestCar)
+	haifaCarID, err := repos.Cars.InsertCar(haifaCar)
+	require.NoError(test, err)
+	holonCar := TestCar
+	holonCar.AvailableLocations = []string{"Holon"}
+	holonCar.MinimumAge = 50
+	holonCar.CarGroup = 3
+	holonCar = withBranches(test, repos, holonCar)
+	holonCarID, err := repos.Cars.InsertCar(holonCar)
+	require.NoError(test, err)
+	rent := TestRent
+	rent.CarID = int(haifaCarID)
+	rentID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+	laterRent := rent
+	laterRent.FromDate = "2022-01-20T10:00:00Z"
+	laterRent.ToDate = "2022-01-21T10:00:00Z"
+	_, err = repos.Rents.InsertRent(laterRent, nil)
+	require.NoError(test, err)
+
+	// Car is found once for every rent, IDs of the same car are listed once
+	carIDs := func(filter storage.CarFilter) []int {
+		found, err := repos.Cars.SearchCars(filter)
+		require.NoError(test, err)
+		var ids []int
+		for _, row := range found {
+			if len(ids) == 0 || ids[len(ids)-1] != row.CarID {
+				ids = append(ids, row.CarID)
+			}
+		}
+		return ids
+	}
+	carGroup := 3
+	assert.Equal(test, []int{int(haifaCarID), int(holonCarID)}, carIDs(storage.CarFilter{}))
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{Locations: []string{"Holon"}}))
+	assert.Equal(test, []int{int(haifaCarID), int(holonCarID)}, carIDs(storage.CarFilter{Locations: []string{"Holon", "Tel Aviv"}}))
+	assert.Empty(test, carIDs(storage.CarFilter{Locations: []string{"Hol"}}), "Locations are matched by whole branch name")
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{CarGroup: &carGroup}))
+	assert.Equal(test, []int{int(haifaCarID)}, carIDs(storage.CarFilter{Age: &query.IntRange{Min: 20, Max: 40, Bounded: true}}))
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{Age: &query.IntRange{Min: 40}}))
+
+	busy := query.DateRange{From: parseTime(test, "2022-01-15T12:00:00Z"), To: parseTime(test, "2022-01-15T13:00:00Z")}
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{Dates: &busy}))
+	free := query.DateRange{From: parseTime(test, "2022-01-17T12:00:00Z"), To: parseTime(test, "2022-01-18T13:00:00Z")}
+	assert.Equal(test, []int{int(haifaCarID), int(holonCarID)}, carIDs(storage.CarFilter{Dates: &free}))
+	bothRents := query.DateRange{From: parseTime(test, "2022-01-15T12:00:00Z"), To: parseTime(test, "2022-01-25T12:00:00Z")}
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{Dates: &bothRents}))
+
+	// Back to back slot is free as it is for booking, unless rules keep the car for cleaning or for the rest of the day
+	backToBack := query.DateRange{From: parseTime(test, "2022-01-16T10:00:00Z"), To: parseTime(test, "2022-01-17T10:00:00Z")}
+	assert.Equal(test, []int{int(haifaCarID), int(holonCarID)}, carIDs(storage.CarFilter{Dates: &backToBack}))
+	cleaning := availability.Rules{CleaningBuffer: 2 * time.Hour, AllowSameDayTurnaround: true}
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{Dates: &backToBack, Rules: &cleaning}))
+	sameDay := query.DateRange{From: parseTime(test, "2022-01-16T18:00:00Z"), To: parseTime(test, "2022-01-17T10:00:00Z")}
+	assert.Equal(test, []int{int(haifaCarID), int(holonCarID)}, carIDs(storage.CarFilter{Dates: &sameDay, Rules: &cleaning}))
+	noTurnaround := availability.Rules{AllowSameDayTurnaround: false}
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{Dates: &sameDay, Rules: &noTurnaround}))
+	beforeRent := query.DateRange{From: parseTime(test, "2022-01-19T08:00:00Z"), To: parseTime(test, "2022-01-20T08:00:00Z")}
+	assert.Equal(test, []int{int(holonCarID)}, carIDs(storage.CarFilter{Dates: &beforeRent, Rules: &noTurnaround}), "Car is picked up on return day")
+
+	_, err = repos.Rents.TransitRent(domain.RentTransition{
+		RentID:     int(rentID),
+		FromStatus: domain.ReservedRentStatus,
+		ToStatus:   domain.CancelledRentStatus,
+		CreatedAt:  "2022-01-10T10:00:00Z",
+	}, nil)
+	require.NoError(test, err)
+	assert.Equal(test, []int{int(haifaCarID), int(holonCarID)}, carIDs(storage.CarFilter{Dates: &busy}), "Cancelled rent does not hold the car")
+}
+
+func testBlackouts(test *testing.T, repos storage.Repositories) {
+	carID, err := repos.Cars.InsertCar(withBranches(test, repos, TestCar))
+	require.NoError(test, err)
+	inspection := domain.Blackout{
+		CarID:    int(carID),
+		FromDate: "2022-02-01T08:00:00Z",
+		ToDate:   "2022-02-01T12:00:00Z",
+		Reason:   domain.InspectionBlackoutReason,
+	}
+	service := domain.Blackout{
+		CarID:       int(carID),
+		FromDate:    "2022-01-20T08:00:00Z",
+		ToDate:      "2022-01-22T08:00:00Z",
+		Reason:      domain.ServiceBlackoutReason,
+		Description: "Oil change",
+	}
+	for _, blackout := range []*domain.Blackout{&inspection, &service} {
+		id, err := repos.Blackouts.InsertBlackout(*blackout)
+		require.NoError(test, err)
+		blackout.BlackoutID = int(id)
+	}
+	blackouts, err := repos.Blackouts.GetCarBlackouts(int(carID))
+	require.NoError(test, err)
+	assert.Equal(test, []domain.Blackout{service, inspection}, blackouts, "Blackouts are ordered by from date")
+
+	service.Reason = domain.DamageBlackoutReason
+	service.ToDate = "2022-01-25T08:00:00Z"
+	affected, err := repos.Blackouts.UpdateBlackout(service, service.BlackoutID)
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	received, err := repos.Blackouts.GetBlackout(service.BlackoutID)
+	require.NoError(test, err)
+	assert.Equal(test, service, *received)
+
+	carIDs := func(dates query.DateRange) []int {
+		found, err := repos.Cars.SearchCars(storage.CarFilter{Dates: &dates})
+		require.NoError(test, err)
+		var ids []int
+		for _, row := range found {
+			ids = append(ids, row.CarID)
+		}
+		return ids
+	}
+	assert.Empty(test, carIDs(query.DateRange{From: parseTime(test, "2022-01-24T12:00:00Z"), To: parseTime(test, "2022-01-26T12:00:00Z")}), "Blackout holds the car like rent")
+	assert.Equal(test, []int{int(carID)}, carIDs(query.DateRange{From: parseTime(test, "2022-01-26T12:00:00Z"), To: parseTime(test, "2022-01-28T12:00:00Z")}))
+	pickupAtBlackoutEnd := query.DateRange{From: parseTime(test, "2022-01-25T08:00:00Z"), To: parseTime(test, "2022-01-26T08:00:00Z")}
+	assert.Equal(test, []int{int(carID)}, carIDs(pickupAtBlackoutEnd), "Car is free when blackout ends")
+	returnAtBlackoutStart := query.DateRange{From: parseTime(test, "2022-01-31T08:00:00Z"), To: parseTime(test, "2022-02-01T08:00:00Z")}
+	assert.Equal(test, []int{int(carID)}, carIDs(returnAtBlackoutStart), "Car is free until blackout starts")
+	cleaning := availability.Rules{CleaningBuffer: time.Hour, AllowSameDayTurnaround: true}
+	found, err := repos.Cars.SearchCars(storage.CarFilter{Dates: &pickupAtBlackoutEnd, Rules: &cleaning})
+	require.NoError(test, err)
+	assert.Empty(test, found, "Cleaning buffer is kept after blackout")
+
+	affected, err = repos.Blackouts.RemoveBlackout(service.BlackoutID)
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	_, err = repos.Blackouts.GetBlackout(service.BlackoutID)
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+	affected, err = repos.Blackouts.UpdateBlackout(service, service.BlackoutID)
+	require.NoError(test, err)
+	assert.Zero(test, affected)
+
+	jerusalem := branchNamed(test, repos, "Jerusalem")
+	transferCarID, err := repos.Cars.InsertCar(withBranches(test, repos, TestCar))
+	require.NoError(test, err)
+	transfer := domain.Blackout{
+		CarID:      int(transferCarID),
+		FromDate:   "2022-01-10T08:00:00Z",
+		ToDate:     "2022-01-10T12:00:00Z",
+		Reason:     domain.TransferBlackoutReason,
+		ToLocation: jerusalem.Name,
+		ToBranchID: jerusalem.BranchID,
+	}
+	id, err := repos.Blackouts.InsertBlackout(transfer)
+	require.NoError(test, err)
+	transfer.BlackoutID = int(id)
+	blackouts, err = repos.Blackouts.GetBlackouts()
+	require.NoError(test, err)
+	assert.Equal(test, []domain.Blackout{inspection, transfer}, blackouts, "Blackouts are ordered by car and from date")
+	_, err = repos.Branches.RemoveBranch(jerusalem.BranchID)
+	assert.True(test, errors.Is(err, storage.ErrInUse), "Destination of transfer should not be removed")
+}
+
+func testBranches(test *testing.T, repos storage.Repositories) {
+	branches, err := repos.Branches.GetBranches()
+	require.NoError(test, err)
+	assert.Empty(test, branches, "Storage starts without branches")
+
+	branch := domain.Branch{
+		Name:      "Eilat",
+		Address:   "Hativat HaNegev 1",
+		Latitude:  29.5577,
+		Longitude: 34.9519,
+		Timezone:  "Asia/Jerusalem",
+		OpeningHours: []domain.OpeningHours{
+			{Day: "Sunday", Open: "08:00", Close: "18:00"},
+		},
+	}
+	id, err := repos.Branches.InsertBranch(branch)
+	require.NoError(test, err)
+	branch.BranchID = int(id)
+	received, err := repos.Branches.GetBranch(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, branch, *received)
+	received, err = repos.Branches.GetBranchByName("Eilat")
+	require.NoError(test, err)
+	assert.Equal(test, branch, *received)
+	_, err = repos.Branches.InsertBranch(branch)
+	assert.Error(test, err, "Branch name should be unique")
+
+	branch.OpeningHours = nil
+	branch.Address = "Eilat airport"
+	affected, err := repos.Branches.UpdateBranch(branch, int(id))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	received, err = repos.Branches.GetBranch(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, branch, *received)
+
+	car := TestCar
+	car.BranchIDs = []int{int(id)}
+	carID, err := repos.Cars.InsertCar(car)
+	require.NoError(test, err)
+	rent := TestRent
+	rent.CarID = int(carID)
+	rent.Location = "Eilat"
+	rent.BranchID = int(id)
+	rentID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+	_, err = repos.Branches.RemoveBranch(int(id))
+	assert.True(test, errors.Is(err, storage.ErrInUse), "Branch with rents should not be removed")
+
+	_, err = repos.Rents.RemoveRent(int(rentID))
+	require.NoError(test, err)
+	oneWay := TestRent
+	oneWay.CarID = int(carID)
+	oneWay.ReturnLocation = "Eilat"
+	oneWay.ReturnBranchID = int(id)
+	rentID, err = repos.Rents.InsertRent(oneWay, nil)
+	require.NoError(test, err)
+	_, err = repos.Branches.RemoveBranch(int(id))
+	assert.True(test, errors.Is(err, storage.ErrInUse), "Drop-off branch of rent should not be removed")
+	_, err = repos.Rents.RemoveRent(int(rentID))
+	require.NoError(test, err)
+	affected, err = repos.Branches.RemoveBranch(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	_, err = repos.Branches.GetBranch(int(id))
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+	received, err = repos.Branches.GetBranchByName("Eilat")
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+	assert.Nil(test, received)
+	unlinkedCar, err := repos.Cars.GetCar(int(carID))
+	require.NoError(test, err)
+	assert.Empty(test, unlinkedCar.BranchIDs, "Links to removed branch are removed")
+}
+
+func testCustomers(test *testing.T, repos storage.Repositories) {
+	customer := TestCustomer
+	id, err := repos.Customers.InsertCustomer(customer)
+	require.NoError(test, err)
+	customer.CustomerID = int(id)
+	received, err := repos.Customers.GetCustomer(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, customer, *received)
+	received, err = repos.Customers.GetCustomerByLicence("IL", "1234567")
+	require.NoError(test, err)
+	assert.Equal(test, customer, *received)
+	_, err = repos.Customers.InsertCustomer(customer)
+	assert.Error(test, err, "Licence should be unique")
+
+	other := TestCustomer
+	other.LicenceNumber = "7654321"
+	other.Phone = ""
+	// Customer created before licence rules
+	other.LicenceIssued = ""
+	other.LicenceCategories = nil
+	otherID, err := repos.Customers.InsertCustomer(other)
+	require.NoError(test, err)
+	other.CustomerID = int(otherID)
+	customers, err := repos.Customers.GetCustomers()
+	require.NoError(test, err)
+	assert.Equal(test, []domain.Customer{customer, other}, customers)
+
+	customer.Email = "dana.cohen@example.com"
+	customer.LicenceExpiry = "2031-01-01"
+	customer.LicenceCategories = []string{"B"}
+	affected, err := repos.Customers.UpdateCustomer(customer, int(id))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	received, err = repos.Customers.GetCustomer(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, customer, *received)
+	affected, err = repos.Customers.UpdateCustomer(customer, 1000)
+	require.NoError(test, err)
+	assert.Equal(test, int64(0), affected)
+
+	carID, err := repos.Cars.InsertCar(TestCar)
+	require.NoError(test, err)
+	rent := TestRent
+	rent.CarID = int(carID)
+	rent.CustomerID = int(id)
+	rentID, err := repos.Rents.InsertRent(rent, nil)
+	require.NoError(test, err)
+	receivedRent, err := repos.Rents.GetRent(int(rentID))
+	require.NoError(test, err)
+	assert.Equal(test, int(id), receivedRent.CustomerID)
+	_, err = repos.Customers.RemoveCustomer(int(id))
+	assert.True(test, errors.Is(err, storage.ErrInUse), "Customer with rents should not be removed")
+
+	_, err = repos.Rents.RemoveRent(int(rentID))
+	require.NoError(test, err)
+	affected, err = repos.Customers.RemoveCustomer(int(id))
+	require.NoError(test, err)
+	assert.Equal(test, int64(1), affected)
+	_, err = repos.Customers.GetCustomer(int(id))
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+	_, err = repos.Customers.GetCustomerByLicence("IL", "1234567")
+	assert.True(test, errors.Is(err, storage.ErrNotFound))
+}
+
+func parseTime(test *testing.T, value string) time.Time {
+	parsed, err := time.Parse(domain.TimeLayout, value)
+	require.NoError(test, err)
+	return parsed
+}
@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Machine readable reasons of violations
+*/
+const (
+	// Request is malformed
+	Required      = "required"
+	InvalidFormat = "invalid_format"
+	OutOfRange    = "out_of_range"
+	NotAllowed    = "not_allowed"
+	// Request is well formed, but breaks rules of stored data
+	NotFound     = "not_found"
+	Duplicate    = "duplicate"
+	Mismatch     = "mismatch"
+	NotAvailable = "not_available"
+)
+
+var malformedCodes = map[string]bool{
+	Required:      true,
+	InvalidFormat: true,
+	OutOfRange:    true,
+	NotAllowed:    true,
+}
+
+/*
+Single incorrect value of request
+*/
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (violation *Violation) Error() string {
+	return fmt.Sprintf("Incorrect value of [%s]: %s", violation.Field, violation.Message)
+}
+
+func New(field string, code string, format string, args ...interface{}) *Violation {
+	return &Violation{Field: field, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+/*
+Every violation found in request, returned when request is checked as a whole
+*/
+type Violations []Violation
+
+func (violations Violations) Error() string {
+	var messages []string
+	for index := range violations {
+		messages = append(messages, violations[index].Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+/*
+Request is malformed when at least one violation does not depend on stored data
+*/
+func (violations Violations) Malformed() bool {
+	for _, violation := range violations {
+		if malformedCodes[violation.Code] {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Extract violations from error, false is returned when error is not caused by validation
+*/
+func From(err error) (Violations, bool) {
+	var violations Violations
+	if errors.As(err, &violations) {
+		return violations, true
+	}
+	var violation *Violation
+	if errors.As(err, &violation) {
+		return Violations{*violation}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(test *testing.T) {
+	_, ok := From(errors.New("Failed to query car"))
+	assert.False(test, ok)
+
+	violations, ok := From(errors.Wrap(New("carGroup", Mismatch, "Please provide correct car group"), "Failed to insert rent"))
+	if assert.True(test, ok) {
+		assert.Equal(test, Violations{{Field: "carGroup", Code: Mismatch, Message: "Please provide correct car group"}}, violations)
+		assert.False(test, violations.Malformed())
+	}
+
+	violations, ok = From(Violations{
+		{Field: "carGroup", Code: Mismatch},
+		{Field: "ageGroup", Code: InvalidFormat},
+	})
+	if assert.True(test, ok) {
+		assert.Len(test, violations, 2)
+		assert.True(test, violations.Malformed())
+	}
+}